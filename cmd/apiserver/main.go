@@ -25,6 +25,8 @@ func main() {
 		log.Fatal(err)
 	}
 
+	config.LoadEnv()
+
 	if err := apiserver.Start(config); err != nil {
 		log.Fatal(err)
 	}
@@ -0,0 +1,315 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/events"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+var (
+	errInvalidOrderID    = errors.New("invalid order ID")
+	errInvalidUserFilter = errors.New("user_id must be a positive integer")
+	errInvalidItemFilter = errors.New("menu_item_id must be a positive integer")
+	errInvalidTotal      = errors.New("min_total and max_total must be non-negative integers")
+	errInvalidTotalRange = errors.New("min_total must not be greater than max_total")
+	errNoOrderIDs        = errors.New("order_ids must not be empty")
+	errTooManyOrderIDs   = errors.New("at most 100 orders can be changed at once")
+	errDuplicateOrderIDs = errors.New("order_ids must not contain duplicates")
+	errStatusRequired    = errors.New("status is required")
+)
+
+// adminOrder is an order as admins see it, with its amounts and timestamps.
+type adminOrder struct {
+	ID             int                    `json:"id"`
+	UserId         int                    `json:"user_id"`
+	Status         string                 `json:"status"`
+	CreatedAt      time.Time              `json:"created_at"`
+	PaymentMethod  string                 `json:"payment_method"`
+	PaymentStatus  string                 `json:"payment_status"`
+	PaymentRef     string                 `json:"payment_ref,omitempty"`
+	PickupSlotId   *int                   `json:"pickup_slot_id,omitempty"`
+	CouponCode     string                 `json:"coupon_code,omitempty"`
+	Subtotal       int                    `json:"subtotal"`
+	DiscountAmount int                    `json:"discount_amount"`
+	Total          int                    `json:"total"`
+	Items          []*model.OrderItem     `json:"items"`
+	Discounts      []*model.OrderDiscount `json:"discounts,omitempty"`
+}
+
+func newAdminOrder(o *model.Order, items []*model.OrderItem) *adminOrder {
+	if items == nil {
+		items = []*model.OrderItem{}
+	}
+
+	return &adminOrder{
+		ID:             o.ID,
+		UserId:         o.UserId,
+		Status:         o.Status,
+		CreatedAt:      o.CreatedAt,
+		PaymentMethod:  o.PaymentMethod,
+		PaymentStatus:  o.PaymentStatus,
+		PaymentRef:     o.PaymentRef,
+		PickupSlotId:   o.PickupSlotId,
+		CouponCode:     o.CouponCode,
+		Subtotal:       model.OrderTotal(items),
+		DiscountAmount: o.DiscountAmount,
+		Total:          o.TotalAmount,
+		Items:          items,
+		Discounts:      o.Discounts,
+	}
+}
+
+// parseAdminOrderFilter reads the parameters of parseOrderFilter and the
+// search parameters only admins have: user_id, menu_item_id, min_total and
+// max_total.
+func parseAdminOrderFilter(query url.Values) (*store.OrderFilter, error) {
+	f, err := parseOrderFilter(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if raw := query.Get("user_id"); raw != "" {
+		userId, err := strconv.Atoi(raw)
+		if err != nil || userId <= 0 {
+			return nil, errInvalidUserFilter
+		}
+		f.UserId = userId
+	}
+
+	if raw := query.Get("menu_item_id"); raw != "" {
+		menuItemId, err := strconv.Atoi(raw)
+		if err != nil || menuItemId <= 0 {
+			return nil, errInvalidItemFilter
+		}
+		f.MenuItemId = menuItemId
+	}
+
+	for param, dest := range map[string]**int{"min_total": &f.MinTotal, "max_total": &f.MaxTotal} {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+
+		total, err := strconv.Atoi(raw)
+		if err != nil || total < 0 {
+			return nil, errInvalidTotal
+		}
+		*dest = &total
+	}
+
+	if f.MinTotal != nil && f.MaxTotal != nil && *f.MinTotal > *f.MaxTotal {
+		return nil, errInvalidTotalRange
+	}
+
+	return f, nil
+}
+
+// handleAdminOrdersGet searches the orders of every user. Results come in
+// pages like the order history of a user.
+func (s *server) handleAdminOrdersGet() http.HandlerFunc {
+	type response struct {
+		Orders     []*adminOrder `json:"orders"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		filter, err := parseAdminOrderFilter(request.URL.Query())
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		orders, nextCursor, err := s.listOrders(filter)
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := response{Orders: []*adminOrder{}, NextCursor: nextCursor}
+		for _, o := range orders {
+			res.Orders = append(res.Orders, newAdminOrder(o, o.Items))
+		}
+
+		setNextLink(writer, request, nextCursor)
+		s.respond(writer, request, http.StatusOK, res)
+	}
+}
+
+// handleAdminOrderGet returns a single order with its lines, discounts and
+// status history.
+func (s *server) handleAdminOrderGet() http.HandlerFunc {
+	type response struct {
+		*adminOrder
+		History []*model.OrderStatusChange `json:"history"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidOrderID)
+			return
+		}
+
+		o, err := s.store.Order().Find(id)
+		if err != nil {
+			s.orderError(writer, request, err)
+			return
+		}
+
+		items, err := s.store.OrderItem().GetOrderItems(id)
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		o.Discounts, err = s.store.Promotion().GetDiscountsByOrder(id)
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		history, err := s.orders.History(id)
+		if err != nil {
+			s.orderError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, response{
+			adminOrder: newAdminOrder(o, items),
+			History:    history,
+		})
+	}
+}
+
+func (s *server) handleAdminOrderCancel() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidOrderID)
+			return
+		}
+
+		actor := request.Context().Value(ctxKeyUser).(*model.User)
+
+		o, err := s.orders.Cancel(request.Context(), id, actor)
+		if err != nil {
+			s.orderError(writer, request, err)
+			return
+		}
+		s.logPendingRefund(o)
+
+		s.events.Publish(events.TypeOrderStatusChanged, &kitchenStatusChange{
+			OrderId:   o.ID,
+			Status:    o.Status,
+			ChangedBy: actor.ID,
+		})
+
+		s.respond(writer, request, http.StatusOK, o)
+	}
+}
+
+func (s *server) handleAdminOrderRefund() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidOrderID)
+			return
+		}
+
+		actor := request.Context().Value(ctxKeyUser).(*model.User)
+
+		o, err := s.orders.Refund(request.Context(), id, actor)
+		if err != nil {
+			s.orderError(writer, request, err)
+			return
+		}
+		s.logPendingRefund(o)
+
+		s.respond(writer, request, http.StatusOK, o)
+	}
+}
+
+// handleAdminOrdersStatus moves several orders to the same status. Every
+// order is changed on its own, so one order that cannot move does not hold
+// back the others; the response reports the outcome per order.
+func (s *server) handleAdminOrdersStatus() http.HandlerFunc {
+	type requests struct {
+		OrderIds []int  `json:"order_ids"`
+		Status   string `json:"status"`
+	}
+
+	type result struct {
+		OrderId int    `json:"order_id"`
+		Status  string `json:"status,omitempty"`
+		Error   string `json:"error,omitempty"`
+	}
+
+	type response struct {
+		Changed int      `json:"changed"`
+		Failed  int      `json:"failed"`
+		Results []result `json:"results"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		switch {
+		case req.Status == "":
+			s.error(writer, request, http.StatusBadRequest, errStatusRequired)
+			return
+		case !model.IsValidOrderStatus(req.Status):
+			s.error(writer, request, http.StatusBadRequest, errInvalidStatus)
+			return
+		case len(req.OrderIds) == 0:
+			s.error(writer, request, http.StatusBadRequest, errNoOrderIDs)
+			return
+		case len(req.OrderIds) > maxPageSize:
+			s.error(writer, request, http.StatusBadRequest, errTooManyOrderIDs)
+			return
+		}
+
+		seen := make(map[int]bool, len(req.OrderIds))
+		for _, id := range req.OrderIds {
+			if seen[id] {
+				s.error(writer, request, http.StatusBadRequest, errDuplicateOrderIDs)
+				return
+			}
+			seen[id] = true
+		}
+
+		actor := request.Context().Value(ctxKeyUser).(*model.User)
+
+		res := response{Results: make([]result, 0, len(req.OrderIds))}
+		for _, id := range req.OrderIds {
+			o, err := s.orders.ChangeStatus(request.Context(), id, actor, req.Status)
+			if err != nil {
+				res.Failed++
+				res.Results = append(res.Results, result{OrderId: id, Error: err.Error()})
+				continue
+			}
+			s.logPendingRefund(o)
+
+			s.events.Publish(events.TypeOrderStatusChanged, &kitchenStatusChange{
+				OrderId:   o.ID,
+				Status:    o.Status,
+				ChangedBy: actor.ID,
+			})
+
+			res.Changed++
+			res.Results = append(res.Results, result{OrderId: id, Status: o.Status})
+		}
+
+		s.respond(writer, request, http.StatusOK, res)
+	}
+}
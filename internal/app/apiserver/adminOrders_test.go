@@ -0,0 +1,111 @@
+package apiserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/events"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestServer_HandleAdminOrdersStatus(t *testing.T) {
+	st := teststore.New()
+	s := &server{
+		store:  st,
+		orders: service.NewOrderService(st, nil, service.OpeningHours{Close: 24 * time.Hour}, service.LoyaltyProgram{}),
+		events: events.NewBus(kitchenHistorySize),
+	}
+
+	u := &model.User{Email: "user@example.org", Username: "user", Password: "password"}
+	require.NoError(t, st.User().Create(u))
+	admin := &model.User{Email: "admin@example.org", Username: "admin", Password: "password", Role: model.RoleAdmin}
+	require.NoError(t, st.User().Create(admin))
+	m := &model.MenuItem{Name: "soup", Price: 300}
+	require.NoError(t, st.MenuItem().Create(m))
+
+	var ids []int
+	for i := 0; i < 3; i++ {
+		o, _, err := s.orders.Place(context.Background(), &service.PlaceOrder{
+			UserId:        u.ID,
+			Lines:         []service.OrderLine{{MenuItemId: m.ID, Quantity: 1}},
+			PaymentMethod: model.PaymentMethodCash,
+		})
+		require.NoError(t, err)
+		ids = append(ids, o.ID)
+	}
+
+	_, err := s.orders.ChangeStatus(context.Background(), ids[1], admin, model.OrderStatusRejected)
+	require.NoError(t, err)
+
+	statusChange := func(body interface{}) *httptest.ResponseRecorder {
+		b := &bytes.Buffer{}
+		require.NoError(t, json.NewEncoder(b).Encode(body))
+
+		request := httptest.NewRequest(http.MethodPost, "/admin/orders/status", b)
+		request = request.WithContext(context.WithValue(request.Context(), ctxKeyUser, admin))
+		recorder := httptest.NewRecorder()
+		s.handleAdminOrdersStatus().ServeHTTP(recorder, request)
+
+		return recorder
+	}
+
+	for _, tc := range []struct {
+		name string
+		body map[string]interface{}
+	}{
+		{"no status", map[string]interface{}{"order_ids": ids}},
+		{"unknown status", map[string]interface{}{"order_ids": ids, "status": "eaten"}},
+		{"no orders", map[string]interface{}{"order_ids": []int{}, "status": model.OrderStatusAccepted}},
+		{"duplicates", map[string]interface{}{"order_ids": []int{ids[0], ids[0]}, "status": model.OrderStatusAccepted}},
+		{"too many", map[string]interface{}{"order_ids": make([]int, maxPageSize+1), "status": model.OrderStatusAccepted}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, statusChange(tc.body).Code)
+		})
+	}
+
+	_, sub, cancel := s.events.Subscribe(0)
+	defer cancel()
+
+	recorder := statusChange(map[string]interface{}{
+		"order_ids": []int{ids[0], ids[1], ids[2], ids[2] + 100},
+		"status":    model.OrderStatusAccepted,
+	})
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var res struct {
+		Changed int `json:"changed"`
+		Failed  int `json:"failed"`
+		Results []struct {
+			OrderId int    `json:"order_id"`
+			Status  string `json:"status"`
+			Error   string `json:"error"`
+		} `json:"results"`
+	}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&res))
+	assert.Equal(t, 2, res.Changed)
+	assert.Equal(t, 2, res.Failed)
+	require.Len(t, res.Results, 4)
+	assert.Equal(t, model.OrderStatusAccepted, res.Results[0].Status)
+	assert.Equal(t, service.ErrInvalidTransition.Error(), res.Results[1].Error)
+	assert.Equal(t, model.OrderStatusAccepted, res.Results[2].Status)
+	assert.NotEmpty(t, res.Results[3].Error)
+
+	for _, id := range []int{ids[0], ids[2]} {
+		e := <-sub
+		assert.Equal(t, events.TypeOrderStatusChanged, e.Type)
+		assert.Equal(t, id, e.Data.(*kitchenStatusChange).OrderId)
+	}
+
+	o, err := st.Order().Find(ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusRejected, o.Status)
+}
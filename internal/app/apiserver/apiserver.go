@@ -5,6 +5,7 @@ import (
 	"github.com/gorilla/sessions"
 	"github.com/yeboka/final-project/internal/app/store/sqlstore"
 	"net/http"
+	"time"
 )
 
 // Start ...
@@ -16,7 +17,18 @@ func Start(config *Config) error {
 	defer db.Close()
 	store := sqlstore.New(db)
 	sessionsStore := sessions.NewCookieStore([]byte(config.SessionKey))
-	srv := newServer(store, sessionsStore)
+	srv, err := newServer(store, sessionsStore, config)
+	if err != nil {
+		return err
+	}
+
+	if config.RefundRetryMinutes > 0 {
+		go srv.retryRefunds(time.Duration(config.RefundRetryMinutes) * time.Minute)
+	}
+
+	if config.LoginFailureWindowMinutes > 0 {
+		go srv.forgetLoginFailures(time.Duration(config.LoginFailureWindowMinutes) * time.Minute)
+	}
 
 	return http.ListenAndServe(config.BindAddr, srv)
 }
@@ -0,0 +1,51 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"strconv"
+)
+
+var errInvalidComboID = errors.New("invalid combo ID")
+
+func (s *server) handleComboCreate() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		c := &model.Combo{}
+		if err := json.NewDecoder(request.Body).Decode(c); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		// The combo and its slots are inserted together.
+		err := s.store.WithTx(request.Context(), func(tx store.Store) error {
+			return tx.Combo().Create(c)
+		})
+		if err != nil {
+			s.error(writer, request, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusCreated, c)
+	}
+}
+
+func (s *server) handleComboDelete() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidComboID)
+			return
+		}
+
+		if err := s.store.Combo().Delete(id); err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, "deleted")
+	}
+}
@@ -1,17 +1,96 @@
 package apiserver
 
+import "os"
+
 // Config ...
 type Config struct {
 	BindAddr    string `toml:"bind_addr"`
 	LogLevel    string `toml:"log_level"`
 	DatabaseURL string `toml:"database_url"`
 	SessionKey  string `toml:"session_key"`
+
+	JWTSecret             string `toml:"jwt_secret"`
+	AccessTokenTTLMinutes int    `toml:"access_token_ttl_minutes"`
+	RefreshTokenTTLDays   int    `toml:"refresh_token_ttl_days"`
+
+	LoginMaxFailures          int `toml:"login_max_failures"`
+	LoginMaxIPFailures        int `toml:"login_max_ip_failures"`
+	LoginFailureWindowMinutes int `toml:"login_failure_window_minutes"`
+	LoginLockoutMinutes       int `toml:"login_lockout_minutes"`
+	LoginDelaySeconds         int `toml:"login_delay_seconds"`
+	LoginMaxDelaySeconds      int `toml:"login_max_delay_seconds"`
+
+	TrustedProxies []string `toml:"trusted_proxies"`
+
+	Notifier                string `toml:"notifier"`
+	NotifierFile            string `toml:"notifier_file"`
+	PasswordResetURL        string `toml:"password_reset_url"`
+	PasswordResetTTLMinutes int    `toml:"password_reset_ttl_minutes"`
+
+	EmailVerificationURL      string `toml:"email_verification_url"`
+	EmailVerificationTTLHours int    `toml:"email_verification_ttl_hours"`
+
+	SMTPHost     string `toml:"smtp_host"`
+	SMTPPort     int    `toml:"smtp_port"`
+	SMTPUsername string `toml:"smtp_username"`
+	SMTPPassword string `toml:"smtp_password"`
+	SMTPFrom     string `toml:"smtp_from"`
+
+	PaymentProvider      string `toml:"payment_provider"`
+	PaymentWebhookSecret string `toml:"payment_webhook_secret"`
+	RefundRetryMinutes   int    `toml:"refund_retry_minutes"`
+
+	OpeningTime string `toml:"opening_time"`
+	ClosingTime string `toml:"closing_time"`
+
+	LoyaltyEarnRate   int `toml:"loyalty_earn_rate"`
+	LoyaltyPointValue int `toml:"loyalty_point_value"`
+	LoyaltyExpiryDays int `toml:"loyalty_expiry_days"`
 }
 
 // NewConfig ...
 func NewConfig() *Config {
 	return &Config{
-		BindAddr: ":8080",
-		LogLevel: "debug",
+		BindAddr:    ":8080",
+		LogLevel:    "debug",
+		OpeningTime: "08:00",
+		ClosingTime: "17:00",
+
+		AccessTokenTTLMinutes: 15,
+		RefreshTokenTTLDays:   30,
+
+		LoginMaxFailures:          5,
+		LoginMaxIPFailures:        20,
+		LoginFailureWindowMinutes: 15,
+		LoginLockoutMinutes:       15,
+		LoginDelaySeconds:         1,
+		LoginMaxDelaySeconds:      30,
+
+		Notifier:                "log",
+		PasswordResetTTLMinutes: 60,
+
+		EmailVerificationTTLHours: 48,
+		SMTPPort:                  587,
+
+		RefundRetryMinutes: 5,
+
+		LoyaltyEarnRate:   1,
+		LoyaltyPointValue: 1,
+		LoyaltyExpiryDays: 365,
+	}
+}
+
+// LoadEnv takes the secrets from the JWT_SECRET, PAYMENT_WEBHOOK_SECRET and
+// SMTP_PASSWORD environment variables where they are set, so that they need
+// not be kept in the config file.
+func (c *Config) LoadEnv() {
+	for name, value := range map[string]*string{
+		"JWT_SECRET":             &c.JWTSecret,
+		"PAYMENT_WEBHOOK_SECRET": &c.PaymentWebhookSecret,
+		"SMTP_PASSWORD":          &c.SMTPPassword,
+	} {
+		if v := os.Getenv(name); v != "" {
+			*value = v
+		}
 	}
 }
@@ -0,0 +1,112 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// menuFilter narrows the menu down to what a customer can eat: items that
+// contain none of ExcludeAllergens and are tagged with every one of Diets.
+type menuFilter struct {
+	ExcludeAllergens []string
+	Diets            []string
+}
+
+// parseMenuFilter reads the exclude_allergens and diet query parameters, both
+// comma-separated lists.
+func parseMenuFilter(query url.Values) (*menuFilter, error) {
+	f := &menuFilter{
+		ExcludeAllergens: splitList(query.Get("exclude_allergens")),
+		Diets:            splitList(query.Get("diet")),
+	}
+
+	// The validation of menu item tags covers the filter values as well.
+	probe := &model.MenuItem{Allergens: f.ExcludeAllergens, Diets: f.Diets}
+	if err := probe.Validate(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func splitList(raw string) []string {
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func (f *menuFilter) isEmpty() bool {
+	return len(f.ExcludeAllergens) == 0 && len(f.Diets) == 0
+}
+
+func (f *menuFilter) allows(mi *model.MenuItem) bool {
+	return !mi.ContainsAnyAllergen(f.ExcludeAllergens) && mi.SuitsDiets(f.Diets)
+}
+
+func (s *server) handleDietaryTagsGet() http.HandlerFunc {
+	type response struct {
+		Allergens []string `json:"allergens"`
+		Diets     []string `json:"diets"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		s.respond(writer, request, http.StatusOK, response{
+			Allergens: model.Allergens,
+			Diets:     model.Diets,
+		})
+	}
+}
+
+func (s *server) handleMenuItemTagsSet() http.HandlerFunc {
+	type requests struct {
+		Allergens []string `json:"allergens"`
+		Diets     []string `json:"diets"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidMenuItemID)
+			return
+		}
+
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		tagged := &model.MenuItem{Allergens: req.Allergens, Diets: req.Diets}
+		if err := tagged.Validate(); err != nil {
+			s.error(writer, request, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		var mi *model.MenuItem
+		err = s.store.WithTx(request.Context(), func(tx store.Store) error {
+			if err := tx.MenuItem().SetTags(id, req.Allergens, req.Diets); err != nil {
+				return err
+			}
+
+			mi, err = tx.MenuItem().Find(id)
+			return err
+		})
+		if err != nil {
+			s.inventoryError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, mi)
+	}
+}
@@ -0,0 +1,114 @@
+package apiserver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"net/url"
+	"testing"
+)
+
+func TestParseMenuFilter(t *testing.T) {
+	f, err := parseMenuFilter(url.Values{"exclude_allergens": {" nuts,,milk "}, "diet": {"vegan"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nuts", "milk"}, f.ExcludeAllergens)
+	assert.Equal(t, []string{"vegan"}, f.Diets)
+	assert.False(t, f.isEmpty())
+
+	f, err = parseMenuFilter(url.Values{})
+	require.NoError(t, err)
+	assert.True(t, f.isEmpty())
+
+	_, err = parseMenuFilter(url.Values{"exclude_allergens": {"nutz"}})
+	assert.Error(t, err)
+
+	_, err = parseMenuFilter(url.Values{"diet": {"keto"}})
+	assert.Error(t, err)
+}
+
+func TestMenuFilter_Allows(t *testing.T) {
+	salad := &model.MenuItem{Allergens: []string{model.AllergenNuts}, Diets: []string{model.DietVegan, model.DietVegetarian}}
+	omelette := &model.MenuItem{Allergens: []string{model.AllergenEggs, model.AllergenMilk}, Diets: []string{model.DietVegetarian}}
+	bread := &model.MenuItem{}
+
+	for _, tc := range []struct {
+		name   string
+		filter menuFilter
+		allows []bool
+	}{
+		{"empty", menuFilter{}, []bool{true, true, true}},
+		{"no nuts", menuFilter{ExcludeAllergens: []string{model.AllergenNuts}}, []bool{false, true, true}},
+		{"no nuts or milk", menuFilter{ExcludeAllergens: []string{model.AllergenNuts, model.AllergenMilk}}, []bool{false, false, true}},
+		{"vegetarian", menuFilter{Diets: []string{model.DietVegetarian}}, []bool{true, true, false}},
+		{"vegan and vegetarian", menuFilter{Diets: []string{model.DietVegetarian, model.DietVegan}}, []bool{true, false, false}},
+		{"vegetarian without eggs", menuFilter{ExcludeAllergens: []string{model.AllergenEggs}, Diets: []string{model.DietVegetarian}}, []bool{true, false, false}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, mi := range []*model.MenuItem{salad, omelette, bread} {
+				assert.Equal(t, tc.allows[i], tc.filter.allows(mi), "item %d", i)
+			}
+		})
+	}
+}
+
+func TestPruneMenu(t *testing.T) {
+	soup := &model.MenuItem{ID: 1, CategoryID: 2, Name: "soup", Diets: []string{model.DietVegan}}
+	stew := &model.MenuItem{ID: 2, CategoryID: 2, Name: "stew", Allergens: []string{model.AllergenCelery}}
+	steak := &model.MenuItem{ID: 3, CategoryID: 3, Name: "steak"}
+	tea := &model.MenuItem{ID: 4, CategoryID: 4, Name: "tea", Diets: []string{model.DietVegan}}
+
+	soups := 2
+	lunch := &model.Combo{ID: 1, Name: "lunch", Slots: []*model.ComboSlot{
+		{Name: "soup", CategoryID: &soups},
+		{Name: "drink", MenuItemIds: []int{tea.ID}},
+	}}
+	dinner := &model.Combo{ID: 2, Name: "dinner", Slots: []*model.ComboSlot{
+		{Name: "main", MenuItemIds: []int{steak.ID}},
+	}}
+
+	// prune rewrites the trees it is given, so every call gets a fresh menu.
+	menu := func() []*CategoryTree {
+		return []*CategoryTree{
+			{ID: 1, Name: "food", Combos: []*model.Combo{lunch, dinner}, Children: []*CategoryTree{
+				{ID: 2, Name: "soups", MenuItems: []*model.MenuItem{soup, stew}},
+				{ID: 3, Name: "mains", MenuItems: []*model.MenuItem{steak}},
+			}},
+			{ID: 4, Name: "drinks", MenuItems: []*model.MenuItem{tea}},
+		}
+	}
+	items := []*model.MenuItem{soup, stew, steak, tea}
+
+	f := &menuFilter{Diets: []string{model.DietVegan}}
+	pruned := pruneMenu(menu(), items, f.allows, nil)
+
+	require.Len(t, pruned, 2)
+	food := pruned[0]
+	assert.Equal(t, []*model.Combo{lunch}, food.Combos)
+	require.Len(t, food.Children, 1)
+	assert.Equal(t, "soups", food.Children[0].Name)
+	assert.Equal(t, []*model.MenuItem{soup}, food.Children[0].MenuItems)
+	assert.Equal(t, []*model.MenuItem{tea}, pruned[1].MenuItems)
+
+	f = &menuFilter{ExcludeAllergens: []string{model.AllergenCelery}}
+	pruned = pruneMenu(menu(), items, f.allows, nil)
+
+	require.Len(t, pruned, 2)
+	food = pruned[0]
+	assert.Equal(t, []*model.Combo{lunch, dinner}, food.Combos)
+	require.Len(t, food.Children, 2)
+	assert.Equal(t, []*model.MenuItem{soup}, food.Children[0].MenuItems)
+
+	f = &menuFilter{Diets: []string{model.DietHalal}}
+	assert.Empty(t, pruneMenu(menu(), items, f.allows, nil))
+
+	// A closed category takes its subcategories and combos with it.
+	f = &menuFilter{}
+	pruned = pruneMenu(menu(), items, f.allows, func(categoryId int) bool { return categoryId != 1 })
+	require.Len(t, pruned, 1)
+	assert.Equal(t, "drinks", pruned[0].Name)
+
+	// So does a closed category whose items a combo needs.
+	pruned = pruneMenu(menu(), items, func(mi *model.MenuItem) bool { return mi.CategoryID != 3 }, nil)
+	require.Len(t, pruned, 2)
+	assert.Equal(t, []*model.Combo{lunch}, pruned[0].Combos)
+}
@@ -0,0 +1,129 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"strconv"
+)
+
+var (
+	errInvalidMenuItemID   = errors.New("invalid menu item ID")
+	errNonPositiveRestock  = errors.New("restock quantity must be greater than zero")
+	errNegativeStockValues = errors.New("stock and low_stock_threshold must not be negative")
+)
+
+func (s *server) handleMenuItemRestock() http.HandlerFunc {
+	type requests struct {
+		Quantity int `json:"quantity"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidMenuItemID)
+			return
+		}
+
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Quantity <= 0 {
+			s.error(writer, request, http.StatusUnprocessableEntity, errNonPositiveRestock)
+			return
+		}
+
+		var mi *model.MenuItem
+		err = s.store.WithTx(request.Context(), func(tx store.Store) error {
+			current, err := tx.MenuItem().Find(id)
+			if err != nil {
+				return err
+			}
+
+			// Restocking an untracked item starts tracking it.
+			if current.Stock == nil {
+				err = tx.MenuItem().SetStock(id, &req.Quantity, current.LowStockThreshold)
+			} else {
+				err = tx.MenuItem().IncrementStock(id, req.Quantity)
+			}
+			if err != nil {
+				return err
+			}
+
+			mi, err = tx.MenuItem().Find(id)
+			return err
+		})
+		if err != nil {
+			s.inventoryError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, mi)
+	}
+}
+
+func (s *server) handleMenuItemStockSet() http.HandlerFunc {
+	type requests struct {
+		Stock             *int `json:"stock"`
+		LowStockThreshold int  `json:"low_stock_threshold"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidMenuItemID)
+			return
+		}
+
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if (req.Stock != nil && *req.Stock < 0) || req.LowStockThreshold < 0 {
+			s.error(writer, request, http.StatusUnprocessableEntity, errNegativeStockValues)
+			return
+		}
+
+		if err := s.store.MenuItem().SetStock(id, req.Stock, req.LowStockThreshold); err != nil {
+			s.inventoryError(writer, request, err)
+			return
+		}
+
+		mi, err := s.store.MenuItem().Find(id)
+		if err != nil {
+			s.inventoryError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, mi)
+	}
+}
+
+func (s *server) handleLowStockGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		items, err := s.store.MenuItem().FindLowStock()
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, items)
+	}
+}
+
+func (s *server) inventoryError(writer http.ResponseWriter, request *http.Request, err error) {
+	if errors.Is(err, store.ErrRecordNotFound) {
+		s.error(writer, request, http.StatusNotFound, err)
+		return
+	}
+
+	s.error(writer, request, http.StatusInternalServerError, err)
+}
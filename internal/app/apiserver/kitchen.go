@@ -0,0 +1,222 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/yeboka/final-project/internal/app/events"
+	"github.com/yeboka/final-project/internal/app/model"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	kitchenHistorySize = 256
+	kitchenHeartbeat   = 15 * time.Second
+	kitchenWriteWait   = 10 * time.Second
+	kitchenPongWait    = 2 * kitchenHeartbeat
+)
+
+var errStreamingUnsupported = errors.New("streaming unsupported")
+
+var kitchenUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type kitchenItem struct {
+	MenuItemId int      `json:"menu_item_id"`
+	Name       string   `json:"name"`
+	Quantity   int      `json:"quantity"`
+	Modifiers  []string `json:"modifiers,omitempty"`
+	Components []string `json:"components,omitempty"`
+}
+
+type kitchenOrder struct {
+	ID        int            `json:"id"`
+	UserId    int            `json:"user_id"`
+	Status    string         `json:"status"`
+	CreatedAt time.Time      `json:"created_at"`
+	Items     []*kitchenItem `json:"items"`
+}
+
+type kitchenStatusChange struct {
+	OrderId   int    `json:"order_id"`
+	Status    string `json:"status"`
+	ChangedBy int    `json:"changed_by"`
+}
+
+// publishOrder sends the order with its item lines to the kitchen feed.
+func (s *server) publishOrder(eventType string, o *model.Order, items []*model.OrderItem) {
+	ko := &kitchenOrder{
+		ID:        o.ID,
+		UserId:    o.UserId,
+		Status:    o.Status,
+		CreatedAt: o.CreatedAt,
+	}
+
+	for _, item := range items {
+		ki := &kitchenItem{
+			MenuItemId: item.MenuItemId,
+			Name:       item.ItemName,
+			Quantity:   item.Quantity,
+		}
+
+		for _, m := range item.Modifiers {
+			ki.Modifiers = append(ki.Modifiers, m.Name)
+		}
+
+		for _, c := range item.Components {
+			ki.Components = append(ki.Components, c.ItemName)
+		}
+
+		ko.Items = append(ko.Items, ki)
+	}
+
+	s.events.Publish(eventType, ko)
+}
+
+func (s *server) handleKitchenStream() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		flusher, ok := writer.(http.Flusher)
+		if !ok {
+			s.error(writer, request, http.StatusInternalServerError, errStreamingUnsupported)
+			return
+		}
+
+		replay, feed, cancel := s.events.Subscribe(lastEventID(request))
+		defer cancel()
+
+		writer.Header().Set("Content-Type", "text/event-stream")
+		writer.Header().Set("Cache-Control", "no-cache")
+		writer.Header().Set("Connection", "keep-alive")
+		writer.Header().Set("X-Accel-Buffering", "no")
+		writer.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(writer, "retry: %d\n\n", 3000)
+		for _, e := range replay {
+			if err := writeSSE(writer, e); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		ticker := time.NewTicker(kitchenHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-request.Context().Done():
+				return
+			case e, ok := <-feed:
+				if !ok {
+					return
+				}
+
+				if err := writeSSE(writer, e); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-ticker.C:
+				if _, err := fmt.Fprint(writer, ": ping\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+func (s *server) handleKitchenSocket() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		conn, err := kitchenUpgrader.Upgrade(writer, request, nil)
+		if err != nil {
+			// Upgrade has already replied to the client.
+			return
+		}
+		defer conn.Close()
+
+		replay, feed, cancel := s.events.Subscribe(lastEventID(request))
+		defer cancel()
+
+		// The kitchen display only listens, but reading is required to
+		// process pongs and notice when the client goes away.
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+
+			conn.SetReadDeadline(time.Now().Add(kitchenPongWait))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(kitchenPongWait))
+			})
+
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		write := func(e events.Event) error {
+			conn.SetWriteDeadline(time.Now().Add(kitchenWriteWait))
+			return conn.WriteJSON(e)
+		}
+
+		for _, e := range replay {
+			if err := write(e); err != nil {
+				return
+			}
+		}
+
+		ticker := time.NewTicker(kitchenHeartbeat)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case e, ok := <-feed:
+				if !ok {
+					return
+				}
+
+				if err := write(e); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(kitchenWriteWait)); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+func writeSSE(writer http.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
+
+// lastEventID reads the replay position from the Last-Event-ID header sent by
+// reconnecting EventSource clients, or from the last_event_id query parameter
+// for clients that cannot set headers (WebSocket, first EventSource connect).
+func lastEventID(request *http.Request) int64 {
+	raw := request.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = request.URL.Query().Get("last_event_id")
+	}
+
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0
+	}
+
+	return id
+}
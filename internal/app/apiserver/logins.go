@@ -0,0 +1,104 @@
+package apiserver
+
+import (
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const defaultAuditEntries = 50
+
+// loginGuardError answers a login the guard turned away, telling the client
+// in Retry-After how many seconds to wait.
+func (s *server) loginGuardError(writer http.ResponseWriter, request *http.Request, wait time.Duration, err error) {
+	switch {
+	case errors.Is(err, service.ErrLoginThrottled), errors.Is(err, service.ErrAccountLocked),
+		errors.Is(err, service.ErrIPLocked):
+		writer.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		s.error(writer, request, http.StatusTooManyRequests, err)
+	default:
+		s.error(writer, request, http.StatusInternalServerError, err)
+	}
+}
+
+// forgetLoginFailures removes stale login throttles every interval. It runs
+// for the lifetime of the server.
+func (s *server) forgetLoginFailures(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		n, err := s.logins.ForgetStale()
+		if err != nil {
+			s.logger.Errorf("login throttles: %v", err)
+			continue
+		}
+
+		if n > 0 {
+			s.logger.Infof("login throttles: %d stale removed", n)
+		}
+	}
+}
+
+// handleUserUnlock lifts the login lockout of a user.
+func (s *server) handleUserUnlock() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid user ID"))
+			return
+		}
+
+		u, err := s.store.User().Find(id)
+		if errors.Is(err, store.ErrRecordNotFound) {
+			s.error(writer, request, http.StatusNotFound, err)
+			return
+		}
+
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		actor := request.Context().Value(ctxKeyUser).(*model.User)
+
+		if err := s.logins.Unlock(request.Context(), u, actor); err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, map[string]string{"message": "User unlocked"})
+	}
+}
+
+// handleAuditGet lists the latest audit entries, newest first, optionally
+// only those of one action.
+func (s *server) handleAuditGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+
+		limit := defaultAuditEntries
+		if raw := query.Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxPageSize {
+				s.error(writer, request, http.StatusBadRequest, errInvalidLimit)
+				return
+			}
+			limit = n
+		}
+
+		entries, err := s.store.Audit().List(query.Get("action"), limit)
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, entries)
+	}
+}
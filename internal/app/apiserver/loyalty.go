@@ -0,0 +1,100 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"strconv"
+)
+
+func (s *server) handleLoyaltyGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		u := request.Context().Value(ctxKeyUser).(*model.User)
+
+		loyalty, err := s.loyalty.Get(request.Context(), u.ID)
+		if err != nil {
+			s.loyaltyError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, loyalty)
+	}
+}
+
+func (s *server) handleAdminLoyaltyGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userId, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid user ID"))
+			return
+		}
+
+		loyalty, err := s.loyalty.Get(request.Context(), userId)
+		if err != nil {
+			s.loyaltyError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, loyalty)
+	}
+}
+
+func (s *server) handleLoyaltyAdjust() http.HandlerFunc {
+	type requests struct {
+		Points int    `json:"points"`
+		Note   string `json:"note"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userId, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid user ID"))
+			return
+		}
+
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		actor := request.Context().Value(ctxKeyUser).(*model.User)
+
+		entry, err := s.loyalty.Adjust(request.Context(), userId, req.Points, actor, req.Note)
+		if err != nil {
+			s.loyaltyError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusCreated, entry)
+	}
+}
+
+func (s *server) handleLoyaltyBalancesGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		balances, err := s.loyalty.Balances(request.Context())
+		if err != nil {
+			s.loyaltyError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, balances)
+	}
+}
+
+func (s *server) loyaltyError(writer http.ResponseWriter, request *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrRecordNotFound):
+		s.error(writer, request, http.StatusNotFound, err)
+	case errors.Is(err, service.ErrInsufficientPoints):
+		s.error(writer, request, http.StatusConflict, err)
+	case errors.Is(err, service.ErrZeroPoints):
+		s.error(writer, request, http.StatusUnprocessableEntity, err)
+	default:
+		s.error(writer, request, http.StatusInternalServerError, err)
+	}
+}
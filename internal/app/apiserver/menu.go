@@ -0,0 +1,199 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	errInvalidTime       = errors.New("invalid time, expected HH:MM")
+	errInvalidScheduleID = errors.New("invalid menu schedule ID")
+)
+
+// pruneMenu drops the menu items allows rejects, the categories categoryOpen
+// rejects, the combos that are left with a slot nothing can be picked for
+// and the categories that end up empty. items holds the menu items of every
+// category. A nil categoryOpen keeps every category.
+func pruneMenu(roots []*CategoryTree, items []*model.MenuItem, allows func(*model.MenuItem) bool, categoryOpen func(int) bool) []*CategoryTree {
+	var allowed []*model.MenuItem
+	for _, mi := range items {
+		if allows(mi) {
+			allowed = append(allowed, mi)
+		}
+	}
+
+	comboAllowed := func(c *model.Combo) bool {
+		for _, slot := range c.Slots {
+			ok := false
+			for _, mi := range allowed {
+				if slot.Allows(mi) {
+					ok = true
+					break
+				}
+			}
+
+			if !ok {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	var prune func(trees []*CategoryTree) []*CategoryTree
+	prune = func(trees []*CategoryTree) []*CategoryTree {
+		var kept []*CategoryTree
+		for _, tree := range trees {
+			if categoryOpen != nil && !categoryOpen(tree.ID) {
+				continue
+			}
+
+			menuItems := []*model.MenuItem{}
+			for _, mi := range tree.MenuItems {
+				if allows(mi) {
+					menuItems = append(menuItems, mi)
+				}
+			}
+
+			var combos []*model.Combo
+			for _, c := range tree.Combos {
+				if comboAllowed(c) {
+					combos = append(combos, c)
+				}
+			}
+
+			tree.MenuItems = menuItems
+			tree.Combos = combos
+			tree.Children = prune(tree.Children)
+
+			if len(tree.MenuItems) > 0 || len(tree.Combos) > 0 || len(tree.Children) > 0 {
+				kept = append(kept, tree)
+			}
+		}
+
+		return kept
+	}
+
+	return prune(roots)
+}
+
+// handleMenuGet returns the menu of a day, today unless the date parameter
+// says otherwise. With a time parameter only what is available at that time
+// is listed; without one, everything that is on the menu at some time of the
+// day. The allergen and diet filters of /category apply as well.
+func (s *server) handleMenuGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		query := request.URL.Query()
+
+		now := time.Now()
+		date := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		if raw := query.Get("date"); raw != "" {
+			d, err := time.ParseInLocation(dateLayout, raw, time.Local)
+			if err != nil {
+				s.error(writer, request, http.StatusBadRequest, errInvalidDate)
+				return
+			}
+			date = d
+		}
+
+		var at *time.Time
+		if raw := query.Get("time"); raw != "" {
+			clock, err := time.Parse("15:04", raw)
+			if err != nil {
+				s.error(writer, request, http.StatusBadRequest, errInvalidTime)
+				return
+			}
+
+			t := date.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+			at = &t
+		}
+
+		filter, err := parseMenuFilter(query)
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		availability, err := service.LoadAvailability(s.store)
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		roots, items, err := s.categoryTree()
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		allows := func(mi *model.MenuItem) bool {
+			if at != nil && !availability.ItemAvailableAt(mi, *at) {
+				return false
+			}
+
+			return availability.ItemAvailableOn(mi, date) && filter.allows(mi)
+		}
+
+		categoryOpen := func(categoryId int) bool {
+			if at != nil && !availability.CategoryAvailableAt(categoryId, *at) {
+				return false
+			}
+
+			return availability.CategoryAvailableOn(categoryId, date)
+		}
+
+		s.respond(writer, request, http.StatusOK, pruneMenu(roots, items, allows, categoryOpen))
+	}
+}
+
+func (s *server) handleMenuScheduleCreate() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		ms := &model.MenuSchedule{}
+		if err := json.NewDecoder(request.Body).Decode(ms); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := s.store.MenuSchedule().Create(ms); err != nil {
+			s.error(writer, request, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusCreated, ms)
+	}
+}
+
+func (s *server) handleMenuSchedulesGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		schedules, err := s.store.MenuSchedule().GetAll()
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, schedules)
+	}
+}
+
+func (s *server) handleMenuScheduleDelete() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidScheduleID)
+			return
+		}
+
+		if err := s.store.MenuSchedule().Delete(id); err != nil {
+			s.error(writer, request, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, "deleted")
+	}
+}
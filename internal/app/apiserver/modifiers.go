@@ -0,0 +1,120 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"strconv"
+)
+
+var errInvalidModifierID = errors.New("invalid modifier ID")
+
+func (s *server) handleModifierGroupCreate() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		menuItemId, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidMenuItemID)
+			return
+		}
+
+		g := &model.ModifierGroup{}
+		if err := json.NewDecoder(request.Body).Decode(g); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		g.MenuItemId = menuItemId
+
+		err = s.store.WithTx(request.Context(), func(tx store.Store) error {
+			if _, err := tx.MenuItem().Find(menuItemId); err != nil {
+				return err
+			}
+
+			return tx.Modifier().CreateGroup(g)
+		})
+		if err != nil {
+			s.modifierError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusCreated, g)
+	}
+}
+
+func (s *server) handleModifierGroupDelete() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidModifierID)
+			return
+		}
+
+		if err := s.store.Modifier().DeleteGroup(id); err != nil {
+			s.modifierError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, "deleted")
+	}
+}
+
+func (s *server) handleModifierCreate() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		groupId, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidModifierID)
+			return
+		}
+
+		m := &model.Modifier{}
+		if err := json.NewDecoder(request.Body).Decode(m); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		m.GroupId = groupId
+
+		err = s.store.WithTx(request.Context(), func(tx store.Store) error {
+			if _, err := tx.Modifier().FindGroup(groupId); err != nil {
+				return err
+			}
+
+			return tx.Modifier().CreateModifier(m)
+		})
+		if err != nil {
+			s.modifierError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusCreated, m)
+	}
+}
+
+func (s *server) handleModifierDelete() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidModifierID)
+			return
+		}
+
+		if err := s.store.Modifier().DeleteModifier(id); err != nil {
+			s.modifierError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, "deleted")
+	}
+}
+
+// modifierError maps store errors of the modifier endpoints to HTTP
+// responses; anything else is a validation failure.
+func (s *server) modifierError(writer http.ResponseWriter, request *http.Request, err error) {
+	if errors.Is(err, store.ErrRecordNotFound) {
+		s.error(writer, request, http.StatusNotFound, err)
+		return
+	}
+
+	s.error(writer, request, http.StatusUnprocessableEntity, err)
+}
@@ -0,0 +1,161 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var errInvalidDateRange = errors.New("from must not be after to")
+
+func (s *server) handleMenuItemNutritionSet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidMenuItemID)
+			return
+		}
+
+		n := &model.Nutrition{}
+		if err := json.NewDecoder(request.Body).Decode(n); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := n.Validate(); err != nil {
+			s.error(writer, request, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.setNutrition(writer, request, id, n)
+	}
+}
+
+func (s *server) handleMenuItemNutritionDelete() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidMenuItemID)
+			return
+		}
+
+		s.setNutrition(writer, request, id, nil)
+	}
+}
+
+func (s *server) setNutrition(writer http.ResponseWriter, request *http.Request, id int, n *model.Nutrition) {
+	var mi *model.MenuItem
+	err := s.store.WithTx(request.Context(), func(tx store.Store) error {
+		if err := tx.MenuItem().SetNutrition(id, n); err != nil {
+			return err
+		}
+
+		var err error
+		mi, err = tx.MenuItem().Find(id)
+		return err
+	})
+	if err != nil {
+		s.inventoryError(writer, request, err)
+		return
+	}
+
+	s.respond(writer, request, http.StatusOK, mi)
+}
+
+// handleNutritionGet sums up what the user ordered between the from and to
+// dates, both inclusive and defaulting to today. Cancelled and rejected
+// orders do not count.
+func (s *server) handleNutritionGet() http.HandlerFunc {
+	type respondDay struct {
+		Date      string                `json:"date"`
+		Nutrition *model.NutritionTotal `json:"nutrition"`
+	}
+
+	type response struct {
+		From  string                `json:"from"`
+		To    string                `json:"to"`
+		Total *model.NutritionTotal `json:"total"`
+		Days  []*respondDay         `json:"days"`
+	}
+
+	parseDate := func(raw string, def time.Time) (time.Time, error) {
+		if raw == "" {
+			return def, nil
+		}
+
+		return time.ParseInLocation(dateLayout, raw, time.Local)
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userId, err := s.getUserId(writer, request)
+		if err != nil {
+			s.error(writer, request, http.StatusUnauthorized, err)
+			return
+		}
+
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+		from, err := parseDate(request.URL.Query().Get("from"), today)
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidDate)
+			return
+		}
+
+		to, err := parseDate(request.URL.Query().Get("to"), today)
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidDate)
+			return
+		}
+
+		if to.Before(from) {
+			s.error(writer, request, http.StatusBadRequest, errInvalidDateRange)
+			return
+		}
+
+		end := to.AddDate(0, 0, 1)
+		orders, err := s.store.Order().List(store.OrderFilter{
+			UserId: userId,
+			From:   &from,
+			To:     &end,
+			Sort:   store.OrderSortOldest,
+		})
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		resp := &response{
+			From:  from.Format(dateLayout),
+			To:    to.Format(dateLayout),
+			Total: &model.NutritionTotal{},
+			Days:  []*respondDay{},
+		}
+
+		days := make(map[string]*respondDay)
+		for _, order := range orders {
+			if order.Status == model.OrderStatusCancelled || order.Status == model.OrderStatusRejected {
+				continue
+			}
+
+			date := order.CreatedAt.In(time.Local).Format(dateLayout)
+			day, ok := days[date]
+			if !ok {
+				day = &respondDay{Date: date, Nutrition: &model.NutritionTotal{}}
+				days[date] = day
+				resp.Days = append(resp.Days, day)
+			}
+
+			n := model.OrderNutrition(order.Items)
+			day.Nutrition.Add(n)
+			resp.Total.Add(n)
+		}
+
+		s.respond(writer, request, http.StatusOK, resp)
+	}
+}
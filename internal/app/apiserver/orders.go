@@ -0,0 +1,115 @@
+package apiserver
+
+import (
+	"errors"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/policy"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+)
+
+// orderLineRequest is a single order line: a menu item with its chosen
+// modifiers, or a combo with the dish picked for each slot.
+type orderLineRequest struct {
+	MenuItemId  int                  `json:"menu_item_id"`
+	ComboId     int                  `json:"combo_id"`
+	Quantity    int                  `json:"quantity"`
+	ModifierIds []int                `json:"modifier_ids"`
+	Components  []comboChoiceRequest `json:"components"`
+}
+
+type comboChoiceRequest struct {
+	SlotId     int `json:"slot_id"`
+	MenuItemId int `json:"menu_item_id"`
+}
+
+// orderLines builds the service order lines from the items array, falling
+// back to the parallel menu item / quantity arrays sent by older clients.
+func orderLines(items []orderLineRequest, menuItemIds, quantities []int) ([]service.OrderLine, error) {
+	if len(items) > 0 {
+		lines := make([]service.OrderLine, len(items))
+		for i, item := range items {
+			lines[i] = service.OrderLine{
+				MenuItemId:  item.MenuItemId,
+				ComboId:     item.ComboId,
+				Quantity:    item.Quantity,
+				ModifierIds: item.ModifierIds,
+			}
+
+			for _, c := range item.Components {
+				lines[i].Components = append(lines[i].Components, service.ComboChoice{
+					SlotId:     c.SlotId,
+					MenuItemId: c.MenuItemId,
+				})
+			}
+		}
+
+		return lines, nil
+	}
+
+	if len(menuItemIds) != len(quantities) {
+		return nil, errOrderLinesMismatch
+	}
+
+	lines := make([]service.OrderLine, len(menuItemIds))
+	for i := range menuItemIds {
+		lines[i] = service.OrderLine{
+			MenuItemId: menuItemIds[i],
+			Quantity:   quantities[i],
+		}
+	}
+
+	return lines, nil
+}
+
+// orderError maps errors returned by the order service to HTTP responses.
+func (s *server) orderError(writer http.ResponseWriter, request *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrRecordNotFound):
+		s.error(writer, request, http.StatusNotFound, err)
+	case errors.Is(err, policy.ErrForbidden):
+		s.error(writer, request, http.StatusForbidden, err)
+	case errors.Is(err, service.ErrInsufficientFunds), errors.Is(err, service.ErrPaymentDeclined):
+		s.error(writer, request, http.StatusPaymentRequired, err)
+	case errors.Is(err, service.ErrOrderNotEditable), errors.Is(err, service.ErrInvalidTransition),
+		errors.Is(err, service.ErrSoldOut), errors.Is(err, service.ErrPaymentPending),
+		errors.Is(err, service.ErrCardOrderNotEditable), errors.Is(err, service.ErrSlotFull),
+		errors.Is(err, service.ErrSlotUnavailable), errors.Is(err, service.ErrInsufficientPoints),
+		errors.Is(err, service.ErrNotOnMenu), errors.Is(err, service.ErrOrderNotRefundable),
+		errors.Is(err, service.ErrAlreadyRefunded):
+		s.error(writer, request, http.StatusConflict, err)
+	case errors.Is(err, service.ErrEmptyOrder), errors.Is(err, service.ErrNonPositiveQuantity),
+		errors.Is(err, service.ErrUnknownMenuItem), errors.Is(err, service.ErrUnknownStatus),
+		errors.Is(err, service.ErrUnknownPaymentMethod), errors.Is(err, service.ErrCardPaymentsDisabled),
+		errors.Is(err, service.ErrUnknownSlot), errors.Is(err, service.ErrInvalidModifier),
+		errors.Is(err, service.ErrDuplicateModifier), errors.Is(err, service.ErrModifierSelection),
+		errors.Is(err, service.ErrNegativePrice), errors.Is(err, service.ErrUnknownCombo),
+		errors.Is(err, service.ErrInvalidOrderLine), errors.Is(err, service.ErrInvalidComboChoice),
+		errors.Is(err, service.ErrUnknownCoupon), errors.Is(err, service.ErrCouponExpired),
+		errors.Is(err, service.ErrCouponUsedUp), errors.Is(err, service.ErrCouponNotApplicable),
+		errors.Is(err, service.ErrNonPositivePoints):
+		s.error(writer, request, http.StatusUnprocessableEntity, err)
+	default:
+		s.error(writer, request, http.StatusInternalServerError, err)
+	}
+}
+
+// authorizeOrder loads the order and checks it against rule for the session
+// user. It writes a 404 or 403 response and returns false when the request
+// must not proceed.
+func (s *server) authorizeOrder(writer http.ResponseWriter, request *http.Request, orderId int, rule func(*model.User, *model.Order) error) bool {
+	o, err := s.store.Order().Find(orderId)
+	if err != nil {
+		s.orderError(writer, request, err)
+		return false
+	}
+
+	u := request.Context().Value(ctxKeyUser).(*model.User)
+	if err := rule(u, o); err != nil {
+		s.orderError(writer, request, err)
+		return false
+	}
+
+	return true
+}
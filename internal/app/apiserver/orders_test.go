@@ -0,0 +1,138 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/policy"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestServer_OrderError(t *testing.T) {
+	s := &server{}
+
+	for _, tc := range []struct {
+		err  error
+		code int
+	}{
+		{err: store.ErrRecordNotFound, code: http.StatusNotFound},
+		{err: policy.ErrForbidden, code: http.StatusForbidden},
+		{err: service.ErrInsufficientFunds, code: http.StatusPaymentRequired},
+		{err: service.ErrInvalidTransition, code: http.StatusConflict},
+		{err: fmt.Errorf("soup: %w", service.ErrNotOnMenu), code: http.StatusConflict},
+		{err: service.ErrEmptyOrder, code: http.StatusUnprocessableEntity},
+		{err: fmt.Errorf("menu item 1: %w", service.ErrModifierSelection), code: http.StatusUnprocessableEntity},
+		{err: service.ErrUnknownCoupon, code: http.StatusUnprocessableEntity},
+		{err: errors.New("connection reset by peer"), code: http.StatusInternalServerError},
+	} {
+		recorder := httptest.NewRecorder()
+		s.orderError(recorder, httptest.NewRequest(http.MethodPost, "/private/orders", nil), tc.err)
+		assert.Equal(t, tc.code, recorder.Code, tc.err.Error())
+	}
+}
+
+// orderBody is the body of an order for quantity times the menu item.
+func orderBody(menuItemId int, quantity int) map[string]interface{} {
+	return map[string]interface{}{
+		"items":          []orderLineRequest{{MenuItemId: menuItemId, Quantity: quantity}},
+		"payment_method": model.PaymentMethodCash,
+	}
+}
+
+func TestServer_OrderFlow(t *testing.T) {
+	s, st := newTestServer(t)
+	u := testUser(t, st, model.RoleUser)
+	other := testUser(t, st, model.RoleUser)
+	staff := testUser(t, st, model.RoleStaff)
+	m := &model.MenuItem{Name: "soup", Price: 300}
+	require.NoError(t, st.MenuItem().Create(m))
+
+	token := logIn(t, s, u).AccessToken
+	otherToken := logIn(t, s, other).AccessToken
+	staffToken := logIn(t, s, staff).AccessToken
+
+	recorder := serve(t, s, http.MethodPost, "/private/orders", orderBody(m.ID, 2), "")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serve(t, s, http.MethodPost, "/private/orders", orderBody(m.ID+100, 2), token)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	recorder = serve(t, s, http.MethodPost, "/private/orders", orderBody(m.ID, 2), token)
+	require.Equal(t, http.StatusCreated, recorder.Code, recorder.Body.String())
+
+	var placed struct {
+		Id         int    `json:"id"`
+		TotalPrice int    `json:"total_price"`
+		Status     string `json:"status"`
+	}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&placed))
+	assert.Equal(t, 600, placed.TotalPrice)
+	assert.Equal(t, model.OrderStatusPlaced, placed.Status)
+
+	path := fmt.Sprintf("/private/updateOrder/%d", placed.Id)
+	history := fmt.Sprintf("/private/orders/%d/history", placed.Id)
+	status := fmt.Sprintf("/staff/orders/%d/status", placed.Id)
+
+	assert.Equal(t, http.StatusForbidden, serve(t, s, http.MethodPatch, path, orderBody(m.ID, 1), otherToken).Code)
+	assert.Equal(t, http.StatusForbidden, serve(t, s, http.MethodDelete, fmt.Sprintf("/private/orders/%d", placed.Id), nil, otherToken).Code)
+	assert.Equal(t, http.StatusForbidden, serve(t, s, http.MethodGet, history, nil, otherToken).Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, s, http.MethodPatch, fmt.Sprintf("/private/updateOrder/%d", placed.Id+100), orderBody(m.ID, 1), token).Code)
+
+	recorder = serve(t, s, http.MethodPatch, path, orderBody(m.ID, 3), token)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	assert.Equal(t, 900, findOrder(t, st, placed.Id).TotalAmount)
+
+	assert.Equal(t, http.StatusForbidden, serve(t, s, http.MethodPatch, status, map[string]string{"status": model.OrderStatusAccepted}, token).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, serve(t, s, http.MethodPatch, status, map[string]string{"status": "eaten"}, staffToken).Code)
+
+	recorder = serve(t, s, http.MethodPatch, status, map[string]string{"status": model.OrderStatusAccepted}, staffToken)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	assert.Equal(t, http.StatusConflict, serve(t, s, http.MethodPatch, path, orderBody(m.ID, 1), token).Code)
+	assert.Equal(t, http.StatusConflict, serve(t, s, http.MethodPatch, status, map[string]string{"status": model.OrderStatusPlaced}, staffToken).Code)
+
+	recorder = serve(t, s, http.MethodGet, history, nil, token)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var changes []*model.OrderStatusChange
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&changes))
+	assert.Len(t, changes, 2)
+
+	recorder = serve(t, s, http.MethodPost, "/private/orders", orderBody(m.ID, 1), token)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&placed))
+
+	deletePath := fmt.Sprintf("/private/orders/%d", placed.Id)
+	assert.Equal(t, http.StatusOK, serve(t, s, http.MethodDelete, deletePath, nil, token).Code)
+	assert.Equal(t, http.StatusNotFound, serve(t, s, http.MethodDelete, deletePath, nil, token).Code)
+
+	recorder = serve(t, s, http.MethodGet, "/private/allMyOrders", nil, token)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var mine struct {
+		Orders []struct {
+			Id int `json:"id"`
+		} `json:"orders"`
+	}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&mine))
+	require.Len(t, mine.Orders, 1)
+
+	recorder = serve(t, s, http.MethodGet, "/private/allMyOrders", nil, otherToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&mine))
+	assert.Empty(t, mine.Orders)
+}
+
+func findOrder(t *testing.T, st store.Store, id int) *model.Order {
+	t.Helper()
+
+	o, err := st.Order().Find(id)
+	require.NoError(t, err)
+
+	return o
+}
@@ -0,0 +1,144 @@
+package apiserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+var (
+	errInvalidLimit  = errors.New("limit must be between 1 and 100")
+	errInvalidCursor = errors.New("invalid cursor")
+	errInvalidSort   = errors.New("sort must be one of created_at, -created_at, total, -total")
+	errInvalidStatus = errors.New("invalid order status")
+)
+
+// encodeCursor turns an order cursor into the opaque string clients pass back.
+func encodeCursor(c *store.OrderCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(s string) (*store.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+
+	c := &store.OrderCursor{}
+	if err := json.Unmarshal(raw, c); err != nil || c.ID <= 0 {
+		return nil, errInvalidCursor
+	}
+
+	return c, nil
+}
+
+// parseOrderFilter reads the listing parameters of an order listing: limit,
+// cursor, status (comma-separated), from and to (inclusive dates) and sort.
+func parseOrderFilter(query url.Values) (*store.OrderFilter, error) {
+	f := &store.OrderFilter{
+		Sort:  store.OrderSortNewest,
+		Limit: defaultPageSize,
+	}
+
+	if raw := query.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxPageSize {
+			return nil, errInvalidLimit
+		}
+		f.Limit = limit
+	}
+
+	if raw := query.Get("cursor"); raw != "" {
+		c, err := decodeCursor(raw)
+		if err != nil {
+			return nil, err
+		}
+		f.After = c
+	}
+
+	if raw := query.Get("sort"); raw != "" {
+		if !store.IsValidOrderSort(raw) {
+			return nil, errInvalidSort
+		}
+		f.Sort = raw
+	}
+
+	for _, status := range splitList(query.Get("status")) {
+		if !model.IsValidOrderStatus(status) {
+			return nil, errInvalidStatus
+		}
+		f.Statuses = append(f.Statuses, status)
+	}
+
+	if raw := query.Get("from"); raw != "" {
+		from, err := time.ParseInLocation(dateLayout, raw, time.Local)
+		if err != nil {
+			return nil, errInvalidDate
+		}
+		f.From = &from
+	}
+
+	if raw := query.Get("to"); raw != "" {
+		to, err := time.ParseInLocation(dateLayout, raw, time.Local)
+		if err != nil {
+			return nil, errInvalidDate
+		}
+
+		end := to.AddDate(0, 0, 1)
+		f.To = &end
+	}
+
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		return nil, errInvalidDateRange
+	}
+
+	return f, nil
+}
+
+// listOrders loads one page of orders. It asks the store for one order more
+// than the page holds to find out whether there is a next page, and returns
+// the cursor of that page, or an empty string on the last page.
+func (s *server) listOrders(f *store.OrderFilter) ([]*model.Order, string, error) {
+	limit := f.Limit
+	f.Limit++
+
+	orders, err := s.store.Order().List(*f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(orders) <= limit {
+		return orders, "", nil
+	}
+
+	orders = orders[:limit]
+
+	return orders, encodeCursor(store.CursorOf(orders[limit-1])), nil
+}
+
+// setNextLink points the Link header at the next page, which is the current
+// request with its cursor replaced.
+func setNextLink(writer http.ResponseWriter, request *http.Request, cursor string) {
+	if cursor == "" {
+		return
+	}
+
+	next := *request.URL
+	query := next.Query()
+	query.Set("cursor", cursor)
+	next.RawQuery = query.Encode()
+
+	writer.Header().Set("Link", "<"+next.String()+">; rel=\"next\"")
+}
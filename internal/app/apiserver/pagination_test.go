@@ -0,0 +1,127 @@
+package apiserver
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	c := &store.OrderCursor{
+		CreatedAt:   time.Date(2024, 6, 3, 12, 30, 0, 123456789, time.UTC),
+		TotalAmount: 1500,
+		ID:          42,
+	}
+
+	encoded := encodeCursor(c)
+	assert.NotContains(t, encoded, "=")
+	assert.NotContains(t, encoded, "+")
+	assert.NotContains(t, encoded, "/")
+
+	decoded, err := decodeCursor(encoded)
+	require.NoError(t, err)
+	assert.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, c.TotalAmount, decoded.TotalAmount)
+	assert.Equal(t, c.ID, decoded.ID)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, raw := range []string{
+		"not base64!",
+		"bm90IGpzb24",              // "not json"
+		"eyJpZCI6MH0",              // {"id":0}
+		"eyJjcmVhdGVkX2F0IjoxfQ",   // {"created_at":1}
+		"eyJpZCI6NX0=",             // padded
+		"eyJ0b3RhbF9hbW91bnQiOjF9", // {"total_amount":1}
+	} {
+		_, err := decodeCursor(raw)
+		assert.ErrorIs(t, err, errInvalidCursor, raw)
+	}
+}
+
+func TestParseOrderFilter(t *testing.T) {
+	f, err := parseOrderFilter(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, store.OrderSortNewest, f.Sort)
+	assert.Equal(t, defaultPageSize, f.Limit)
+
+	f, err = parseOrderFilter(url.Values{
+		"limit":  {"5"},
+		"sort":   {"total"},
+		"status": {"placed,ready"},
+		"from":   {"2024-06-03"},
+		"to":     {"2024-06-03"},
+		"cursor": {encodeCursor(&store.OrderCursor{ID: 7})},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5, f.Limit)
+	assert.Equal(t, store.OrderSortTotalAsc, f.Sort)
+	assert.Equal(t, []string{model.OrderStatusPlaced, model.OrderStatusReady}, f.Statuses)
+	assert.Equal(t, 24*time.Hour, f.To.Sub(*f.From))
+	assert.Equal(t, 7, f.After.ID)
+
+	for _, query := range []url.Values{
+		{"limit": {"0"}},
+		{"limit": {"101"}},
+		{"sort": {"name"}},
+		{"status": {"eaten"}},
+		{"from": {"03.06.2024"}},
+		{"from": {"2024-06-04"}, "to": {"2024-06-03"}},
+		{"cursor": {"x"}},
+	} {
+		_, err := parseOrderFilter(query)
+		assert.Error(t, err, query.Encode())
+	}
+}
+
+func TestListOrders_KeysetAcrossTies(t *testing.T) {
+	st := teststore.New()
+	s := &server{store: st}
+
+	u := &model.User{Email: "user@example.org", Username: "user", Password: "password"}
+	require.NoError(t, st.User().Create(u))
+
+	totals := []int{200, 100, 200, 300, 200, 100, 200}
+	for _, total := range totals {
+		require.NoError(t, st.Order().Create(&model.Order{UserId: u.ID, TotalAmount: total}))
+	}
+
+	// collect pages through the listing and returns the order IDs in the order
+	// they were listed.
+	collect := func(sort string, limit int) []int {
+		var ids []int
+		f := &store.OrderFilter{Sort: sort, Limit: limit}
+		for pages := 0; pages < 10; pages++ {
+			orders, cursor, err := s.listOrders(f)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(orders), limit)
+
+			for _, o := range orders {
+				ids = append(ids, o.ID)
+			}
+
+			if cursor == "" {
+				return ids
+			}
+
+			f.After, err = decodeCursor(cursor)
+			require.NoError(t, err)
+			f.Limit = limit
+		}
+
+		t.Fatalf("listing with sort %s did not end", sort)
+		return nil
+	}
+
+	for _, limit := range []int{1, 2, 3, 7, 20} {
+		assert.Equal(t, []int{2, 6, 1, 3, 5, 7, 4}, collect(store.OrderSortTotalAsc, limit), "total, limit %d", limit)
+		assert.Equal(t, []int{4, 7, 5, 3, 1, 6, 2}, collect(store.OrderSortTotalDesc, limit), "-total, limit %d", limit)
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, collect(store.OrderSortOldest, limit), "created_at, limit %d", limit)
+		assert.Equal(t, []int{7, 6, 5, 4, 3, 2, 1}, collect(store.OrderSortNewest, limit), "-created_at, limit %d", limit)
+	}
+}
@@ -0,0 +1,140 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/notify"
+	"github.com/yeboka/final-project/internal/app/service"
+	"net/http"
+	"os"
+)
+
+var (
+	errUnknownNotifier   = errors.New("unknown notifier")
+	errMissingEmail      = errors.New("email is required")
+	errMissingResetToken = errors.New("token is required")
+)
+
+// newNotifier returns the notifier of the configuration. The log notifier
+// appends to notifier_file, or writes to stdout when none is set; the outbox
+// keeps messages in memory for tests.
+func newNotifier(config *Config) (notify.Notifier, error) {
+	switch config.Notifier {
+	case "smtp":
+		return notify.NewSMTPNotifier(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.SMTPFrom), nil
+	case "outbox":
+		return notify.NewOutbox(), nil
+	case "", "log":
+		if config.NotifierFile == "" {
+			return notify.NewLogNotifier(os.Stdout), nil
+		}
+
+		f, err := os.OpenFile(config.NotifierFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+
+		return notify.NewLogNotifier(f), nil
+	default:
+		return nil, errUnknownNotifier
+	}
+}
+
+// passwordError maps errors returned by the password service to HTTP
+// responses.
+func (s *server) passwordError(writer http.ResponseWriter, request *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrWrongPassword):
+		s.error(writer, request, http.StatusForbidden, err)
+	case errors.Is(err, service.ErrInvalidResetToken):
+		s.error(writer, request, http.StatusBadRequest, err)
+	default:
+		s.error(writer, request, http.StatusUnprocessableEntity, err)
+	}
+}
+
+// handlePasswordChange sets a new password for the user. The session the
+// request is made with stays signed in, every other one is revoked.
+func (s *server) handlePasswordChange() http.HandlerFunc {
+	type requests struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		u := request.Context().Value(ctxKeyUser).(*model.User)
+		sess := request.Context().Value(ctxKeySession).(*model.Session)
+
+		if err := s.passwords.Change(request.Context(), u, req.CurrentPassword, req.NewPassword, sess.ID); err != nil {
+			s.passwordError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, map[string]string{"message": "Password changed"})
+	}
+}
+
+// handlePasswordForgot sends a reset link to the email address. It answers
+// the same whether or not the address has an account.
+func (s *server) handlePasswordForgot() http.HandlerFunc {
+	type requests struct {
+		Email string `json:"email"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Email == "" {
+			s.error(writer, request, http.StatusBadRequest, errMissingEmail)
+			return
+		}
+
+		if err := s.passwords.RequestReset(request.Context(), req.Email); err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusAccepted, map[string]string{
+			"message": "If the address has an account, a reset link is on its way",
+		})
+	}
+}
+
+// handlePasswordReset sets a new password with the token of a reset link.
+func (s *server) handlePasswordReset() http.HandlerFunc {
+	type requests struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Token == "" {
+			s.error(writer, request, http.StatusBadRequest, errMissingResetToken)
+			return
+		}
+
+		if err := s.passwords.Reset(request.Context(), req.Token, req.Password); err != nil {
+			s.passwordError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, map[string]string{"message": "Password reset"})
+	}
+}
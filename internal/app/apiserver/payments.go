@@ -0,0 +1,152 @@
+package apiserver
+
+import (
+	"context"
+	"errors"
+	"github.com/yeboka/final-project/internal/app/events"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/payment"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	paymentSignatureHeader = "X-Payment-Signature"
+	maxWebhookBody         = 64 << 10
+)
+
+var (
+	errUnknownPaymentProvider = errors.New("unknown payment provider")
+	errNoPaymentProvider      = errors.New("card payments are not enabled")
+)
+
+// newPaymentProvider returns the card payment gateway of the configuration,
+// or nil when none is configured, which turns card payments off. The fake
+// gateway approves every card, so it has to be asked for by name.
+func newPaymentProvider(config *Config) (payment.Provider, error) {
+	switch config.PaymentProvider {
+	case "":
+		return nil, nil
+	case "fake":
+		return payment.NewFakeProvider(config.PaymentWebhookSecret)
+	default:
+		return nil, errUnknownPaymentProvider
+	}
+}
+
+func (s *server) handlePaymentWebhook() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		if s.payments == nil {
+			s.error(writer, request, http.StatusNotFound, errNoPaymentProvider)
+			return
+		}
+
+		payload, err := io.ReadAll(io.LimitReader(request.Body, maxWebhookBody))
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		e, err := s.payments.VerifyWebhook(payload, request.Header.Get(paymentSignatureHeader))
+		if err != nil {
+			s.error(writer, request, http.StatusUnauthorized, err)
+			return
+		}
+
+		o, released, err := s.orders.HandlePaymentEvent(request.Context(), e)
+		if err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				s.error(writer, request, http.StatusNotFound, err)
+				return
+			}
+
+			if errors.Is(err, service.ErrMissingEventID) {
+				s.error(writer, request, http.StatusBadRequest, err)
+				return
+			}
+
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+		s.logPendingRefund(o)
+
+		if released {
+			items, err := s.store.OrderItem().GetOrderItems(o.ID)
+			if err != nil {
+				s.logger.Errorf("payment webhook: order %d items: %v", o.ID, err)
+			} else {
+				s.publishOrder(events.TypeOrderCreated, o, items)
+			}
+		}
+
+		s.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+// logPendingRefund logs an order whose card refund the provider has not
+// accepted yet. The refund is kept and retried by retryRefunds.
+func (s *server) logPendingRefund(o *model.Order) {
+	if o.PaymentStatus == model.PaymentStatusRefundPending {
+		s.logger.Warnf("order %d: card refund not accepted by the provider, queued for retry", o.ID)
+	}
+}
+
+// retryRefunds sends the pending card refunds to the provider again every
+// interval. It runs for the lifetime of the server.
+func (s *server) retryRefunds(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.settleRefunds(context.Background())
+	}
+}
+
+// settleRefunds runs one round of refund retries and logs its outcome.
+func (s *server) settleRefunds(ctx context.Context) (int, error) {
+	settled, err := s.orders.RetryRefunds(ctx)
+	if settled > 0 {
+		s.logger.Infof("refunds: %d settled", settled)
+	}
+
+	if err != nil {
+		s.logger.Errorf("refunds: %v", err)
+	}
+
+	return settled, err
+}
+
+func (s *server) handleRefundsGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		refunds, err := s.orders.PendingRefunds()
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, refunds)
+	}
+}
+
+// handleRefundsRetry sends the pending card refunds to the provider right
+// away instead of waiting for the next retry round.
+func (s *server) handleRefundsRetry() http.HandlerFunc {
+	type response struct {
+		Settled int    `json:"settled"`
+		Error   string `json:"error,omitempty"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		settled, err := s.settleRefunds(request.Context())
+
+		res := response{Settled: settled}
+		if err != nil {
+			res.Error = err.Error()
+		}
+
+		s.respond(writer, request, http.StatusOK, res)
+	}
+}
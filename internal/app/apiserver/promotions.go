@@ -0,0 +1,58 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"net/http"
+	"strconv"
+)
+
+var errInvalidPromotionID = errors.New("invalid promotion ID")
+
+func (s *server) handlePromotionCreate() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		p := &model.Promotion{}
+		if err := json.NewDecoder(request.Body).Decode(p); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := s.store.Promotion().Create(p); err != nil {
+			s.error(writer, request, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusCreated, p)
+	}
+}
+
+func (s *server) handlePromotionsGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		promotions, err := s.store.Promotion().GetAll()
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, promotions)
+	}
+}
+
+func (s *server) handlePromotionDelete() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidPromotionID)
+			return
+		}
+
+		if err := s.store.Promotion().Delete(id); err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, "deleted")
+	}
+}
@@ -0,0 +1,262 @@
+package apiserver
+
+import (
+	"encoding/csv"
+	"errors"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultReportDays = 30
+	defaultTopItems   = 10
+)
+
+var (
+	errInvalidPeriod = errors.New("period must be one of day, week, month")
+	errInvalidFormat = errors.New("format must be json or csv")
+)
+
+// reportTable is a report in rows and columns, for CSV output.
+type reportTable struct {
+	header []string
+	rows   [][]string
+}
+
+// csvText makes a text cell safe to open in a spreadsheet: text starting
+// with a character that starts a formula is prefixed with a quote so that it
+// is shown as text rather than evaluated.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+
+	return s
+}
+
+// parseReportRange reads the from and to dates of a report, both inclusive.
+// Without them a report covers the last 30 days up to today.
+func parseReportRange(query url.Values) (store.ReportRange, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	to := today
+	if raw := query.Get("to"); raw != "" {
+		t, err := time.ParseInLocation(dateLayout, raw, time.Local)
+		if err != nil {
+			return store.ReportRange{}, errInvalidDate
+		}
+		to = t
+	}
+
+	from := to.AddDate(0, 0, 1-defaultReportDays)
+	if raw := query.Get("from"); raw != "" {
+		t, err := time.ParseInLocation(dateLayout, raw, time.Local)
+		if err != nil {
+			return store.ReportRange{}, errInvalidDate
+		}
+		from = t
+	}
+
+	if to.Before(from) {
+		return store.ReportRange{}, errInvalidDateRange
+	}
+
+	return store.ReportRange{From: from, To: to.AddDate(0, 0, 1)}, nil
+}
+
+// wantsCSV picks the output format from the format parameter, falling back
+// to the Accept header.
+func wantsCSV(request *http.Request) (bool, error) {
+	switch request.URL.Query().Get("format") {
+	case "csv":
+		return true, nil
+	case "json":
+		return false, nil
+	case "":
+		return strings.Contains(request.Header.Get("Accept"), "text/csv"), nil
+	}
+
+	return false, errInvalidFormat
+}
+
+// report runs a report over the range of the request and writes it as JSON,
+// under key next to the range, or as a CSV download.
+func (s *server) report(key string, run func(query url.Values, rng store.ReportRange) (interface{}, *reportTable, error)) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		asCSV, err := wantsCSV(request)
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		query := request.URL.Query()
+
+		rng, err := parseReportRange(query)
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		data, table, err := run(query, rng)
+		if errors.Is(err, errInvalidPeriod) || errors.Is(err, errInvalidLimit) {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		from := rng.From.Format(dateLayout)
+		to := rng.To.AddDate(0, 0, -1).Format(dateLayout)
+
+		if !asCSV {
+			s.respond(writer, request, http.StatusOK, map[string]interface{}{
+				"from": from,
+				"to":   to,
+				key:    data,
+			})
+			return
+		}
+
+		writer.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		writer.Header().Set("Content-Disposition", "attachment; filename=\""+strings.ReplaceAll(key, "_", "-")+"_"+from+"_"+to+".csv\"")
+		writer.WriteHeader(http.StatusOK)
+
+		// The status is sent already, so a failed write can only be logged.
+		w := csv.NewWriter(writer)
+		if err := w.Write(table.header); err != nil {
+			s.logger.Errorf("report %s: %v", key, err)
+			return
+		}
+
+		if err := w.WriteAll(table.rows); err != nil {
+			s.logger.Errorf("report %s: %v", key, err)
+		}
+	}
+}
+
+func (s *server) handleRevenueReport() http.HandlerFunc {
+	return s.report("revenue", func(query url.Values, rng store.ReportRange) (interface{}, *reportTable, error) {
+		period := query.Get("period")
+		if period == "" {
+			period = store.ReportPeriodDay
+		}
+
+		if !store.IsValidReportPeriod(period) {
+			return nil, nil, errInvalidPeriod
+		}
+
+		periods, err := s.store.Report().Revenue(rng, period)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		table := &reportTable{header: []string{"period_start", "orders", "subtotal", "discounts", "revenue"}}
+		for _, p := range periods {
+			table.rows = append(table.rows, []string{
+				p.PeriodStart.Format(dateLayout),
+				strconv.Itoa(p.Orders),
+				strconv.Itoa(p.Subtotal),
+				strconv.Itoa(p.Discounts),
+				strconv.Itoa(p.Revenue),
+			})
+		}
+
+		return periods, table, nil
+	})
+}
+
+func (s *server) handleTopItemsReport() http.HandlerFunc {
+	return s.report("items", func(query url.Values, rng store.ReportRange) (interface{}, *reportTable, error) {
+		limit := defaultTopItems
+		if raw := query.Get("limit"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 || n > maxPageSize {
+				return nil, nil, errInvalidLimit
+			}
+			limit = n
+		}
+
+		items, err := s.store.Report().TopItems(rng, limit)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		table := &reportTable{header: []string{"menu_item_id", "name", "category_id", "quantity", "combo_quantity", "revenue"}}
+		for _, i := range items {
+			table.rows = append(table.rows, []string{
+				strconv.Itoa(i.MenuItemId),
+				csvText(i.Name),
+				strconv.Itoa(i.CategoryID),
+				strconv.Itoa(i.Quantity),
+				strconv.Itoa(i.ComboQuantity),
+				strconv.Itoa(i.Revenue),
+			})
+		}
+
+		return items, table, nil
+	})
+}
+
+func (s *server) handleCategorySalesReport() http.HandlerFunc {
+	return s.report("categories", func(query url.Values, rng store.ReportRange) (interface{}, *reportTable, error) {
+		categories, err := s.store.Report().SalesByCategory(rng)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		table := &reportTable{header: []string{"category_id", "parent_id", "name", "quantity", "revenue"}}
+		for _, c := range categories {
+			table.rows = append(table.rows, []string{
+				strconv.Itoa(c.CategoryID),
+				strconv.Itoa(c.ParentID),
+				csvText(c.Name),
+				strconv.Itoa(c.Quantity),
+				strconv.Itoa(c.Revenue),
+			})
+		}
+
+		return categories, table, nil
+	})
+}
+
+func (s *server) handleOrderValueReport() http.HandlerFunc {
+	return s.report("order_value", func(query url.Values, rng store.ReportRange) (interface{}, *reportTable, error) {
+		v, err := s.store.Report().OrderValue(rng)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return v, &reportTable{
+			header: []string{"orders", "revenue", "average"},
+			rows:   [][]string{{strconv.Itoa(v.Orders), strconv.Itoa(v.Revenue), strconv.Itoa(v.Average)}},
+		}, nil
+	})
+}
+
+func (s *server) handlePeakHoursReport() http.HandlerFunc {
+	return s.report("hours", func(query url.Values, rng store.ReportRange) (interface{}, *reportTable, error) {
+		hours, err := s.store.Report().PeakHours(rng)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		table := &reportTable{header: []string{"hour", "orders", "revenue"}}
+		for _, h := range hours {
+			table.rows = append(table.rows, []string{
+				strconv.Itoa(h.Hour),
+				strconv.Itoa(h.Orders),
+				strconv.Itoa(h.Revenue),
+			})
+		}
+
+		return hours, table, nil
+	})
+}
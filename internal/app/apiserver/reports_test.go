@@ -0,0 +1,86 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestCSVText(t *testing.T) {
+	for _, tc := range []struct {
+		in       string
+		expected string
+	}{
+		{in: "Soup", expected: "Soup"},
+		{in: "", expected: ""},
+		{in: "=HYPERLINK(\"http://example.org\")", expected: "'=HYPERLINK(\"http://example.org\")"},
+		{in: "+1", expected: "'+1"},
+		{in: "-1", expected: "'-1"},
+		{in: "@SUM(A1)", expected: "'@SUM(A1)"},
+		{in: "\tTab", expected: "'\tTab"},
+		{in: "Salad = fresh", expected: "Salad = fresh"},
+	} {
+		assert.Equal(t, tc.expected, csvText(tc.in))
+	}
+}
+
+func TestServer_HandleCategorySalesReport(t *testing.T) {
+	s, st := newTestServer(t)
+	u := testUser(t, st, model.RoleUser)
+	admin := testUser(t, st, model.RoleAdmin)
+
+	root := &model.Category{Name: "=Mains"}
+	require.NoError(t, st.Category().Create(root))
+	child := &model.Category{Name: "Soups", ParentID: root.ID}
+	require.NoError(t, st.Category().Create(child))
+	m := &model.MenuItem{Name: "@Borscht", Price: 300, CategoryID: child.ID}
+	require.NoError(t, st.MenuItem().Create(m))
+
+	o, _, err := s.orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{{MenuItemId: m.ID, Quantity: 2}},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	require.NoError(t, err)
+	for _, status := range []string{model.OrderStatusAccepted, model.OrderStatusPreparing, model.OrderStatusReady, model.OrderStatusPickedUp} {
+		_, err := s.orders.ChangeStatus(context.Background(), o.ID, admin, status)
+		require.NoError(t, err)
+	}
+
+	token := logIn(t, s, admin).AccessToken
+
+	recorder := serve(t, s, http.MethodGet, "/admin/reports/categories", nil, token)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+	var res struct {
+		Categories []*model.CategorySales `json:"categories"`
+	}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&res))
+	require.Len(t, res.Categories, 2)
+	parents := map[int]int{}
+	for _, c := range res.Categories {
+		parents[c.CategoryID] = c.ParentID
+	}
+	assert.Equal(t, map[int]int{root.ID: -1, child.ID: root.ID}, parents)
+
+	recorder = serve(t, s, http.MethodGet, "/admin/reports/categories?format=csv", nil, token)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	rows, err := csv.NewReader(recorder.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+	assert.Contains(t, rows[1:], []string{strconv.Itoa(root.ID), "-1", "'=Mains", "2", "600"})
+	assert.Contains(t, rows[1:], []string{strconv.Itoa(child.ID), strconv.Itoa(root.ID), "Soups", "2", "600"})
+
+	recorder = serve(t, s, http.MethodGet, "/admin/reports/top-items?format=csv", nil, token)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	rows, err = csv.NewReader(recorder.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "'@Borscht", rows[1][1])
+}
@@ -4,13 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/sessions"
 	"github.com/sirupsen/logrus"
+	"github.com/yeboka/final-project/internal/app/auth"
+	"github.com/yeboka/final-project/internal/app/events"
 	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/notify"
+	"github.com/yeboka/final-project/internal/app/payment"
+	"github.com/yeboka/final-project/internal/app/policy"
+	"github.com/yeboka/final-project/internal/app/service"
 	"github.com/yeboka/final-project/internal/app/store"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -20,11 +28,13 @@ const (
 	sessionName        = "canteen"
 	ctxKeyUser  ctxKey = iota
 	ctxKeyRequestID
+	ctxKeySession
 )
 
 var (
 	errIncorrectEmailOrPassword = errors.New("incorrect email or password")
 	errNotAuthenticated         = errors.New("not authenticated")
+	errOrderLinesMismatch       = errors.New("menu_item_id and quantity must have the same length")
 )
 
 type ctxKey int8
@@ -34,20 +44,90 @@ type server struct {
 	logger       *logrus.Logger
 	store        store.Store
 	sessionStore sessions.Store
+	orders       *service.OrderService
+	wallets      *service.WalletService
+	loyalty      *service.LoyaltyService
+	slots        *service.SlotService
+	tokens       *service.AuthService
+	passwords    *service.PasswordService
+	verifier     *service.VerificationService
+	logins       *service.LoginGuard
+	notifier     notify.Notifier
+	events       *events.Bus
+	payments     payment.Provider
+
+	trustedProxies []*net.IPNet
 }
 
-func newServer(store store.Store, sessionsStore sessions.Store) *server {
+func newServer(store store.Store, sessionsStore sessions.Store, config *Config) (*server, error) {
+	payments, err := newPaymentProvider(config)
+	if err != nil {
+		return nil, err
+	}
+
+	hours, err := service.ParseOpeningHours(config.OpeningTime, config.ClosingTime)
+	if err != nil {
+		return nil, err
+	}
+
+	notifier, err := newNotifier(config)
+	if err != nil {
+		return nil, err
+	}
+
+	proxies, err := parseProxies(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := auth.NewSigner(config.JWTSecret, time.Duration(config.AccessTokenTTLMinutes)*time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("jwt_secret: %w, set JWT_SECRET", err)
+	}
+
+	loyalty := service.LoyaltyProgram{
+		EarnRate:   config.LoyaltyEarnRate,
+		PointValue: config.LoyaltyPointValue,
+		Expiry:     time.Duration(config.LoyaltyExpiryDays) * 24 * time.Hour,
+	}
+
+	tokens := service.NewAuthService(store, signer, time.Duration(config.RefreshTokenTTLDays)*24*time.Hour)
+	resetTTL := time.Duration(config.PasswordResetTTLMinutes) * time.Minute
+	verifyTTL := time.Duration(config.EmailVerificationTTLHours) * time.Hour
+
+	logins := service.LoginPolicy{
+		MaxFailures:   config.LoginMaxFailures,
+		MaxIPFailures: config.LoginMaxIPFailures,
+		Window:        time.Duration(config.LoginFailureWindowMinutes) * time.Minute,
+		Lockout:       time.Duration(config.LoginLockoutMinutes) * time.Minute,
+		Delay:         time.Duration(config.LoginDelaySeconds) * time.Second,
+		MaxDelay:      time.Duration(config.LoginMaxDelaySeconds) * time.Second,
+	}
+
 	s := &server{
 		router:       mux.NewRouter(),
 		logger:       logrus.New(),
 		store:        store,
 		sessionStore: sessionsStore,
+		orders:       service.NewOrderService(store, payments, hours, loyalty),
+		wallets:      service.NewWalletService(store),
+		loyalty:      service.NewLoyaltyService(store),
+		slots:        service.NewSlotService(store, hours),
+		tokens:       tokens,
+		passwords:    service.NewPasswordService(store, tokens, notifier, resetTTL, config.PasswordResetURL),
+		verifier:     service.NewVerificationService(store, notifier, verifyTTL, config.EmailVerificationURL),
+		logins:       service.NewLoginGuard(store, logins),
+		notifier:     notifier,
+		events:       events.NewBus(kitchenHistorySize),
+		payments:     payments,
+
+		trustedProxies: proxies,
 	}
 
 	s.configureRouter()
 
 	s.logger.Info("Server started successfully!")
-	return s
+	return s, nil
 }
 
 func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -57,11 +137,25 @@ func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (s *server) configureRouter() {
 	s.router.Use(s.setRequestId)
 	s.router.Use(s.logRequest)
-	s.router.Use(handlers.CORS(handlers.AllowedOrigins([]string{"*"})))
+	s.router.Use(handlers.CORS(
+		handlers.AllowedOrigins([]string{"*"}),
+		handlers.AllowedHeaders([]string{"Authorization", "Content-Type"}),
+	))
 
 	s.router.HandleFunc("/users", s.handleUsersCreate()).Methods("POST")
 	s.router.HandleFunc("/sessions", s.handleSessionsCreate()).Methods("POST")
+	s.router.HandleFunc("/sessions", s.handleSessionsDelete()).Methods("DELETE")
+	s.router.HandleFunc("/sessions/refresh", s.handleSessionsRefresh()).Methods("POST")
+	s.router.HandleFunc("/password/forgot", s.handlePasswordForgot()).Methods("POST")
+	s.router.HandleFunc("/password/reset", s.handlePasswordReset()).Methods("POST")
+	s.router.HandleFunc("/users/verify", s.handleUsersVerify()).Methods("POST")
+	s.router.HandleFunc("/users/verify/resend", s.handleUsersVerifyResend()).Methods("POST")
 	s.router.HandleFunc("/category", s.handleCategoriesGet()).Methods("GET")
+	s.router.HandleFunc("/payments/webhook", s.handlePaymentWebhook()).Methods("POST")
+	s.router.HandleFunc("/slots", s.handleSlotsGet()).Methods("GET")
+	s.router.HandleFunc("/dietary-tags", s.handleDietaryTagsGet()).Methods("GET")
+	s.router.HandleFunc("/menu", s.handleMenuGet()).Methods("GET")
+	s.router.HandleFunc("/menu/today", s.handleMenuGet()).Methods("GET")
 
 	private := s.router.PathPrefix("/private").Subrouter()
 	private.Use(s.authenticateUser)
@@ -69,18 +163,79 @@ func (s *server) configureRouter() {
 	private.HandleFunc("/orders/{id}", s.handleDeleteOrder()).Methods("DELETE")
 	private.HandleFunc("/allMyOrders", s.handleGetAllOrders()).Methods("GET")
 	private.HandleFunc("/updateOrder/{id}", s.handleUpdateOrder()).Methods("PATCH")
+	private.HandleFunc("/orders/{id}/history", s.handleOrderHistory()).Methods("GET")
 	private.HandleFunc("/whoami", s.handleWhoAmI()).Methods("GET")
 	private.HandleFunc("/users/{id}", s.handleUserUpdate()).Methods("PATCH")
+	private.HandleFunc("/wallet", s.handleWalletGet()).Methods("GET")
+	private.HandleFunc("/loyalty", s.handleLoyaltyGet()).Methods("GET")
+	private.HandleFunc("/nutrition", s.handleNutritionGet()).Methods("GET")
+	private.HandleFunc("/sessions", s.handleSessionsGet()).Methods("GET")
+	private.HandleFunc("/sessions", s.handleSessionsRevokeAll()).Methods("DELETE")
+	private.HandleFunc("/sessions/{id}", s.handleSessionRevoke()).Methods("DELETE")
+	private.HandleFunc("/password", s.handlePasswordChange()).Methods("POST")
+
+	staff := s.router.PathPrefix("/staff").Subrouter()
+	staff.Use(s.authenticateUser)
+	staff.Use(s.checkStaff)
+	staff.HandleFunc("/orders/{id}/status", s.handleOrderStatusChange()).Methods("PATCH")
+	staff.HandleFunc("/orders/{id}/history", s.handleOrderHistory()).Methods("GET")
+
+	kitchen := s.router.PathPrefix("/kitchen").Subrouter()
+	kitchen.Use(s.authenticateUser)
+	kitchen.Use(s.checkStaff)
+	kitchen.HandleFunc("/stream", s.handleKitchenStream()).Methods("GET")
+	kitchen.HandleFunc("/ws", s.handleKitchenSocket()).Methods("GET")
 
 	admin := s.router.PathPrefix("/admin").Subrouter()
 	admin.Use(s.authenticateUser)
 	admin.Use(s.checkAdmin)
 	admin.HandleFunc("/users/{id}/role", s.handleRoleChange()).Methods("PATCH")
+	admin.HandleFunc("/users/{id}/verify", s.handleUserVerify()).Methods("POST")
+	admin.HandleFunc("/users/{id}/unlock", s.handleUserUnlock()).Methods("POST")
+	admin.HandleFunc("/audit", s.handleAuditGet()).Methods("GET")
 	admin.HandleFunc("/menu-item/{id}", s.handleMenuItemUpdate()).Methods("PATCH")
 	admin.HandleFunc("/menu-item/{id}", s.handleMenuItemDelete()).Methods("DELETE")
 	admin.HandleFunc("/users/{id}", s.handleDeleteUser()).Methods("DELETE")
 	admin.HandleFunc("/menu-item", s.handleMenuItemCreate()).Methods("POST")
+	admin.HandleFunc("/menu-item/{id}/restock", s.handleMenuItemRestock()).Methods("POST")
+	admin.HandleFunc("/menu-item/{id}/stock", s.handleMenuItemStockSet()).Methods("PUT")
+	admin.HandleFunc("/menu-item/{id}/tags", s.handleMenuItemTagsSet()).Methods("PUT")
+	admin.HandleFunc("/menu-item/{id}/nutrition", s.handleMenuItemNutritionSet()).Methods("PUT")
+	admin.HandleFunc("/menu-item/{id}/nutrition", s.handleMenuItemNutritionDelete()).Methods("DELETE")
+	admin.HandleFunc("/menu-item/{id}/modifier-groups", s.handleModifierGroupCreate()).Methods("POST")
+	admin.HandleFunc("/modifier-groups/{id}", s.handleModifierGroupDelete()).Methods("DELETE")
+	admin.HandleFunc("/modifier-groups/{id}/modifiers", s.handleModifierCreate()).Methods("POST")
+	admin.HandleFunc("/modifiers/{id}", s.handleModifierDelete()).Methods("DELETE")
+	admin.HandleFunc("/menu-schedules", s.handleMenuScheduleCreate()).Methods("POST")
+	admin.HandleFunc("/menu-schedules", s.handleMenuSchedulesGet()).Methods("GET")
+	admin.HandleFunc("/menu-schedules/{id}", s.handleMenuScheduleDelete()).Methods("DELETE")
+	admin.HandleFunc("/combos", s.handleComboCreate()).Methods("POST")
+	admin.HandleFunc("/combos/{id}", s.handleComboDelete()).Methods("DELETE")
+	admin.HandleFunc("/promotions", s.handlePromotionCreate()).Methods("POST")
+	admin.HandleFunc("/promotions", s.handlePromotionsGet()).Methods("GET")
+	admin.HandleFunc("/promotions/{id}", s.handlePromotionDelete()).Methods("DELETE")
+	admin.HandleFunc("/inventory/low-stock", s.handleLowStockGet()).Methods("GET")
+	admin.HandleFunc("/users/{id}/wallet", s.handleAdminWalletGet()).Methods("GET")
+	admin.HandleFunc("/slots", s.handleSlotsCreate()).Methods("POST")
+	admin.HandleFunc("/slots/{id}", s.handleSlotDelete()).Methods("DELETE")
+	admin.HandleFunc("/users/{id}/wallet/top-up", s.handleWalletEntryCreate(s.wallets.TopUp)).Methods("POST")
+	admin.HandleFunc("/users/{id}/wallet/adjustments", s.handleWalletEntryCreate(s.wallets.Adjust)).Methods("POST")
+	admin.HandleFunc("/users/{id}/loyalty", s.handleAdminLoyaltyGet()).Methods("GET")
+	admin.HandleFunc("/users/{id}/loyalty/adjustments", s.handleLoyaltyAdjust()).Methods("POST")
+	admin.HandleFunc("/loyalty/balances", s.handleLoyaltyBalancesGet()).Methods("GET")
 	admin.HandleFunc("/category", s.handleCategoryCreate()).Methods("POST")
+	admin.HandleFunc("/orders", s.handleAdminOrdersGet()).Methods("GET")
+	admin.HandleFunc("/orders/status", s.handleAdminOrdersStatus()).Methods("POST")
+	admin.HandleFunc("/orders/{id}", s.handleAdminOrderGet()).Methods("GET")
+	admin.HandleFunc("/orders/{id}/cancel", s.handleAdminOrderCancel()).Methods("POST")
+	admin.HandleFunc("/orders/{id}/refund", s.handleAdminOrderRefund()).Methods("POST")
+	admin.HandleFunc("/refunds", s.handleRefundsGet()).Methods("GET")
+	admin.HandleFunc("/refunds/retry", s.handleRefundsRetry()).Methods("POST")
+	admin.HandleFunc("/reports/revenue", s.handleRevenueReport()).Methods("GET")
+	admin.HandleFunc("/reports/top-items", s.handleTopItemsReport()).Methods("GET")
+	admin.HandleFunc("/reports/categories", s.handleCategorySalesReport()).Methods("GET")
+	admin.HandleFunc("/reports/order-value", s.handleOrderValueReport()).Methods("GET")
+	admin.HandleFunc("/reports/peak-hours", s.handlePeakHoursReport()).Methods("GET")
 }
 
 func (s *server) setRequestId(next http.Handler) http.Handler {
@@ -131,18 +286,44 @@ func (s *server) checkAdmin(next http.Handler) http.Handler {
 	})
 }
 
+func (s *server) checkStaff(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(ctxKeyUser).(*model.User)
+		if !ok {
+			s.error(w, r, http.StatusUnauthorized, errors.New("unauthorized access: missing user information"))
+			return
+		}
+
+		if !user.IsStaff() {
+			s.error(w, r, http.StatusForbidden, errors.New("insufficient privileges: requires staff role"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticateUser puts the user and the session of the request in the
+// context. Token clients authenticate with a bearer access token, browsers
+// with the session cookie.
 func (s *server) authenticateUser(next http.Handler) http.Handler {
 	return http.HandlerFunc(
 		func(writer http.ResponseWriter, request *http.Request) {
-			userId, _ := s.getUserId(writer, request)
+			sess, err := s.authenticate(request)
+			if err != nil {
+				s.authError(writer, request, err)
+				return
+			}
 
-			u, err := s.store.User().Find(userId)
+			u, err := s.store.User().Find(sess.UserId)
 			if err != nil {
 				s.error(writer, request, http.StatusUnauthorized, errNotAuthenticated)
 				return
 			}
 
-			next.ServeHTTP(writer, request.WithContext(context.WithValue(request.Context(), ctxKeyUser, u)))
+			ctx := context.WithValue(request.Context(), ctxKeyUser, u)
+			ctx = context.WithValue(ctx, ctxKeySession, sess)
+			next.ServeHTTP(writer, request.WithContext(ctx))
 		},
 	)
 }
@@ -209,7 +390,18 @@ func (s *server) handleRoleChange() http.HandlerFunc {
 		}
 
 		user.Role = req.Role
-		if err := s.store.User().UpdateRole(userID, req.Role); err != nil {
+		err = s.store.User().UpdateRole(userID, req.Role)
+		if errors.Is(err, store.ErrInvalidRole) {
+			s.error(w, r, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		if err != nil {
+			s.error(w, r, http.StatusInternalServerError, err)
+			return
+		}
+
+		if err := s.tokens.RevokeSessions(r.Context(), userID, 0); err != nil {
 			s.error(w, r, http.StatusInternalServerError, err)
 			return
 		}
@@ -238,6 +430,12 @@ func (s *server) handleUserUpdate() http.HandlerFunc {
 			return
 		}
 
+		actor := request.Context().Value(ctxKeyUser).(*model.User)
+		if err := policy.CanUpdateUser(actor, id); err != nil {
+			s.error(writer, request, http.StatusForbidden, err)
+			return
+		}
+
 		req := &requests{}
 		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
 			s.error(writer, request, http.StatusBadRequest, err)
@@ -246,10 +444,11 @@ func (s *server) handleUserUpdate() http.HandlerFunc {
 
 		u, err := s.store.User().Find(id)
 		if err != nil {
-			s.error(writer, request, http.StatusBadRequest, err)
+			s.error(writer, request, http.StatusNotFound, err)
 			return
 		}
 
+		emailChanged := u.Email != req.Email
 		u.Email = req.Email
 		u.Username = req.Username
 
@@ -258,6 +457,14 @@ func (s *server) handleUserUpdate() http.HandlerFunc {
 			return
 		}
 
+		// The new address is unverified; the link also voids the ones sent
+		// to the old address.
+		if emailChanged {
+			if err := s.verifier.Send(request.Context(), u); err != nil {
+				s.logger.Errorf("verification email for user %d: %v", u.ID, err)
+			}
+		}
+
 		s.respond(writer, request, http.StatusOK, "updated")
 	}
 }
@@ -266,57 +473,109 @@ type CategoryTree struct {
 	ID        int               `json:"id"`
 	Name      string            `json:"name"`
 	MenuItems []*model.MenuItem `json:"menu_items"`
+	Combos    []*model.Combo    `json:"combos,omitempty"`
 	Children  []*CategoryTree   `json:"children,omitempty"`
 }
 
 func (s *server) handleCategoriesGet() http.HandlerFunc {
 	return func(writer http.ResponseWriter, request *http.Request) {
+		filter, err := parseMenuFilter(request.URL.Query())
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
 
-		categories, err := s.store.Category().GetAllCategories()
+		roots, items, err := s.categoryTree()
 		if err != nil {
 			s.error(writer, request, http.StatusInternalServerError, err)
 			return
 		}
 
-		categoryMap := make(map[int]*CategoryTree)
+		if !filter.isEmpty() {
+			roots = pruneMenu(roots, items, filter.allows, nil)
+		}
 
-		for _, category := range categories {
-			items, err := s.store.MenuItem().FindByCategoryId(category.ID)
-			if err != nil {
-				s.error(writer, request, http.StatusInternalServerError, err)
-				return
-			}
-			categoryMap[category.ID] = &CategoryTree{
-				ID:        category.ID,
-				Name:      category.Name,
-				MenuItems: items,
-			}
+		s.respond(writer, request, http.StatusOK, roots)
+	}
+}
+
+// categoryTree loads the whole menu as a tree of categories. It also returns
+// the menu items of every category in a flat list.
+func (s *server) categoryTree() ([]*CategoryTree, []*model.MenuItem, error) {
+	categories, err := s.store.Category().GetAllCategories()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groups, err := s.store.Modifier().GetAllGroups()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	groupsByItem := make(map[int][]*model.ModifierGroup)
+	for _, g := range groups {
+		groupsByItem[g.MenuItemId] = append(groupsByItem[g.MenuItemId], g)
+	}
+
+	categoryMap := make(map[int]*CategoryTree)
+	var allItems []*model.MenuItem
+
+	for _, category := range categories {
+		items, err := s.store.MenuItem().FindByCategoryId(category.ID)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		var roots []*CategoryTree
-		s.logger.Info(categories)
-		s.logger.Info(categoryMap)
-		for _, category := range categories {
-			if category.ParentID == -1 {
-				roots = append(roots, categoryMap[category.ID])
-			} else {
-				parent := categoryMap[category.ParentID]
-				if parent != nil {
-					parent.Children = append(parent.Children, categoryMap[category.ID])
-				}
-			}
+		for _, item := range items {
+			item.ModifierGroups = groupsByItem[item.ID]
 		}
+		allItems = append(allItems, items...)
+		categoryMap[category.ID] = &CategoryTree{
+			ID:        category.ID,
+			Name:      category.Name,
+			MenuItems: items,
+		}
+	}
 
-		s.respond(writer, request, http.StatusOK, roots)
+	combos, err := s.store.Combo().GetAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, c := range combos {
+		if tree := categoryMap[c.CategoryID]; tree != nil {
+			tree.Combos = append(tree.Combos, c)
+		}
+	}
+
+	var roots []*CategoryTree
+	s.logger.Info(categories)
+	s.logger.Info(categoryMap)
+	for _, category := range categories {
+		if category.ParentID == -1 {
+			roots = append(roots, categoryMap[category.ID])
+		} else {
+			parent := categoryMap[category.ParentID]
+			if parent != nil {
+				parent.Children = append(parent.Children, categoryMap[category.ID])
+			}
+		}
 	}
+
+	return roots, allItems, nil
 }
 
 func (s *server) handleMenuItemCreate() http.HandlerFunc {
 	type requests struct {
-		Name        string `json:"name"`
-		CategoryId  int    `json:"categoryId"`
-		Price       int    `json:"price"`
-		Description string `json:"description"`
+		Name              string           `json:"name"`
+		CategoryId        int              `json:"categoryId"`
+		Price             int              `json:"price"`
+		Description       string           `json:"description"`
+		Stock             *int             `json:"stock"`
+		LowStockThreshold int              `json:"low_stock_threshold"`
+		Allergens         []string         `json:"allergens"`
+		Diets             []string         `json:"diets"`
+		Nutrition         *model.Nutrition `json:"nutrition"`
 	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
@@ -326,11 +585,21 @@ func (s *server) handleMenuItemCreate() http.HandlerFunc {
 			return
 		}
 
+		if (req.Stock != nil && *req.Stock < 0) || req.LowStockThreshold < 0 {
+			s.error(writer, request, http.StatusUnprocessableEntity, errNegativeStockValues)
+			return
+		}
+
 		mi := &model.MenuItem{
-			Name:        req.Name,
-			CategoryID:  req.CategoryId,
-			Price:       req.Price,
-			Description: req.Description,
+			Name:              req.Name,
+			CategoryID:        req.CategoryId,
+			Price:             req.Price,
+			Description:       req.Description,
+			Stock:             req.Stock,
+			LowStockThreshold: req.LowStockThreshold,
+			Allergens:         req.Allergens,
+			Diets:             req.Diets,
+			Nutrition:         req.Nutrition,
 		}
 
 		if err := s.store.MenuItem().Create(mi); err != nil {
@@ -454,69 +723,77 @@ func (s *server) handleWhoAmI() http.HandlerFunc {
 
 func (s *server) handleCreateOrder() http.HandlerFunc {
 	type respondOrder struct {
-		Id         int                `json:"id"`
-		OrderItems []*model.OrderItem `json:"order_item"`
-		CreatedAt  time.Time          `json:"created_At"`
-		TotalPrice int                `json:"total_price"`
+		Id            int                    `json:"id"`
+		OrderItems    []*model.OrderItem     `json:"order_item"`
+		CreatedAt     time.Time              `json:"created_At"`
+		Subtotal      int                    `json:"subtotal"`
+		Discounts     []*model.OrderDiscount `json:"discounts,omitempty"`
+		TotalPrice    int                    `json:"total_price"`
+		Status        string                 `json:"status"`
+		PaymentMethod string                 `json:"payment_method"`
+		PaymentStatus string                 `json:"payment_status"`
+		PickupSlotId  *int                   `json:"pickup_slot_id,omitempty"`
 	}
 
 	type requests struct {
-		MenuItemId []int `json:"menu_item_id"`
-		Quantity   []int `json:"quantity"`
+		Items         []orderLineRequest `json:"items"`
+		MenuItemId    []int              `json:"menu_item_id"`
+		Quantity      []int              `json:"quantity"`
+		PaymentMethod string             `json:"payment_method"`
+		PickupSlotId  *int               `json:"pickup_slot_id"`
+		CouponCode    string             `json:"coupon_code"`
+		RedeemPoints  int                `json:"redeem_points"`
 	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
 		userId, _ := s.getUserId(writer, request)
 
+		u := request.Context().Value(ctxKeyUser).(*model.User)
+		if err := policy.CanPlaceOrder(u); err != nil {
+			s.error(writer, request, http.StatusForbidden, err)
+			return
+		}
+
 		req := &requests{}
 		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
 			s.error(writer, request, http.StatusBadRequest, err)
 			return
 		}
 
-		var totalAmount int
-		for i := 0; i < len(req.MenuItemId); i++ {
-			price := s.store.MenuItem().GetPrice(req.MenuItemId[i])
-			totalAmount += price * req.Quantity[i]
-		}
-
-		o := &model.Order{
-			UserId:      userId,
-			TotalAmount: totalAmount,
+		lines, err := orderLines(req.Items, req.MenuItemId, req.Quantity)
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
 		}
 
-		exception := s.store.Order().Create(o)
-		if exception != nil {
-			s.error(writer, request, http.StatusUnprocessableEntity, exception)
+		o, orderItems, err := s.orders.Place(request.Context(), &service.PlaceOrder{
+			UserId:        userId,
+			Lines:         lines,
+			PaymentMethod: req.PaymentMethod,
+			PickupSlotId:  req.PickupSlotId,
+			CouponCode:    req.CouponCode,
+			RedeemPoints:  req.RedeemPoints,
+		})
+		if err != nil {
+			s.orderError(writer, request, err)
 			return
 		}
 
-		var orderItems []*model.OrderItem
-
-		for i := 0; i < len(req.MenuItemId); i++ {
-			mi := &model.OrderItem{
-				OrderId:    o.ID,
-				MenuItemId: req.MenuItemId[i],
-				Quantity:   req.Quantity[i],
-			}
-
-			if err := s.store.OrderItem().Create(mi); err != nil {
-				if e := s.store.Order().Delete(o.ID); e != nil {
-					s.error(writer, request, http.StatusInternalServerError, e)
-					return
-				}
-				s.error(writer, request, http.StatusUnprocessableEntity, err)
-				return
-			}
-
-			orderItems = append(orderItems, mi)
+		if o.IsReleasedToKitchen() {
+			s.publishOrder(events.TypeOrderCreated, o, orderItems)
 		}
 
 		respondOrder := respondOrder{
-			Id:         o.ID,
-			CreatedAt:  o.CreatedAt,
-			TotalPrice: totalAmount,
-			OrderItems: orderItems,
+			Id:            o.ID,
+			CreatedAt:     o.CreatedAt,
+			Subtotal:      model.OrderTotal(orderItems),
+			Discounts:     o.Discounts,
+			TotalPrice:    o.TotalAmount,
+			OrderItems:    orderItems,
+			Status:        o.Status,
+			PaymentMethod: o.PaymentMethod,
+			PaymentStatus: o.PaymentStatus,
+			PickupSlotId:  o.PickupSlotId,
 		}
 
 		s.respond(writer, request, http.StatusCreated, respondOrder)
@@ -525,10 +802,17 @@ func (s *server) handleCreateOrder() http.HandlerFunc {
 
 func (s *server) handleGetAllOrders() http.HandlerFunc {
 	type respondOrder struct {
-		Id         int                `json:"id"`
-		OrderItems []*model.OrderItem `json:"order_item"`
-		CreatedAt  time.Time          `json:"created_At"`
-		TotalPrice int                `json:"total_price"`
+		Id         int                   `json:"id"`
+		OrderItems []*model.OrderItem    `json:"order_item"`
+		CreatedAt  time.Time             `json:"created_At"`
+		TotalPrice int                   `json:"total_price"`
+		Status     string                `json:"status"`
+		Nutrition  *model.NutritionTotal `json:"nutrition"`
+	}
+
+	type response struct {
+		Orders     []respondOrder `json:"orders"`
+		NextCursor string         `json:"next_cursor,omitempty"`
 	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
@@ -538,43 +822,53 @@ func (s *server) handleGetAllOrders() http.HandlerFunc {
 			return
 		}
 
-		orders, err := s.store.Order().GetOrders(userId)
+		filter, err := parseOrderFilter(request.URL.Query())
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+		filter.UserId = userId
+
+		orders, nextCursor, err := s.listOrders(filter)
 		if err != nil {
 			s.error(writer, request, http.StatusInternalServerError, err)
 			return
 		}
 
-		var respondOrders []respondOrder
+		respondOrders := []respondOrder{}
 		for _, order := range orders {
-			orderItems, err := s.store.OrderItem().GetOrderItems(order.ID)
-			if err != nil {
-				s.error(writer, request, http.StatusInternalServerError, err)
-				return
-			}
-
 			respondOrder := respondOrder{
 				Id:         order.ID,
 				CreatedAt:  order.CreatedAt,
-				TotalPrice: order.TotalAmount,
-				OrderItems: orderItems,
+				TotalPrice: model.OrderTotal(order.Items) - order.DiscountAmount,
+				OrderItems: order.Items,
+				Status:     order.Status,
+				Nutrition:  model.OrderNutrition(order.Items),
 			}
 			respondOrders = append(respondOrders, respondOrder)
 		}
 
-		s.respond(writer, request, http.StatusOK, respondOrders)
+		setNextLink(writer, request, nextCursor)
+		s.respond(writer, request, http.StatusOK, response{
+			Orders:     respondOrders,
+			NextCursor: nextCursor,
+		})
 	}
 }
 
 func (s *server) handleUpdateOrder() http.HandlerFunc {
 	type respondOrder struct {
-		Id         int                `json:"id"`
-		OrderItems []*model.OrderItem `json:"order_item"`
-		TotalPrice int                `json:"total_price"`
+		Id         int                    `json:"id"`
+		OrderItems []*model.OrderItem     `json:"order_item"`
+		Subtotal   int                    `json:"subtotal"`
+		Discounts  []*model.OrderDiscount `json:"discounts,omitempty"`
+		TotalPrice int                    `json:"total_price"`
 	}
 
 	type requests struct {
-		MenuItemId []int `json:"menu_item_id"`
-		Quantity   []int `json:"quantity"`
+		Items      []orderLineRequest `json:"items"`
+		MenuItemId []int              `json:"menu_item_id"`
+		Quantity   []int              `json:"quantity"`
 	}
 
 	return func(writer http.ResponseWriter, request *http.Request) {
@@ -593,31 +887,30 @@ func (s *server) handleUpdateOrder() http.HandlerFunc {
 			return
 		}
 
-		var totalAmount int
-		for i := 0; i < len(req.MenuItemId); i++ {
-			price := s.store.MenuItem().GetPrice(req.MenuItemId[i])
-			totalAmount += price * req.Quantity[i]
+		lines, err := orderLines(req.Items, req.MenuItemId, req.Quantity)
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
 
-			e := s.store.OrderItem().Update(req.MenuItemId[i], req.Quantity[i])
-			if e != nil {
-				s.error(writer, request, http.StatusBadRequest, err)
-				return
-			}
+		if !s.authorizeOrder(writer, request, orderId, policy.CanModifyOrder) {
+			return
 		}
 
-		exception := s.store.Order().Update(orderId, totalAmount)
-		if exception != nil {
-			s.error(writer, request, http.StatusBadRequest, err)
+		o, orderItems, err := s.orders.Update(request.Context(), orderId, lines)
+		if err != nil {
+			s.orderError(writer, request, err)
 			return
 		}
 
-		var respondOrders []*model.OrderItem
-		respondOrders, err = s.store.OrderItem().GetOrderItems(orderId)
+		s.publishOrder(events.TypeOrderUpdated, o, orderItems)
 
 		respondOrder := respondOrder{
-			Id:         orderId,
-			OrderItems: respondOrders,
-			TotalPrice: totalAmount,
+			Id:         o.ID,
+			OrderItems: orderItems,
+			Subtotal:   model.OrderTotal(orderItems),
+			Discounts:  o.Discounts,
+			TotalPrice: o.TotalAmount,
 		}
 
 		s.respond(writer, request, http.StatusOK, respondOrder)
@@ -635,22 +928,80 @@ func (s *server) handleDeleteOrder() http.HandlerFunc {
 			return
 		}
 
-		ex := s.store.OrderItem().DeleteAllOrder(id)
-		if ex != nil {
-			s.error(writer, request, http.StatusBadRequest, ex)
+		if !s.authorizeOrder(writer, request, id, policy.CanModifyOrder) {
 			return
 		}
 
-		e := s.store.Order().Delete(id)
-		if e != nil {
-			s.error(writer, request, http.StatusBadRequest, e)
+		if err := s.orders.Delete(request.Context(), id); err != nil {
+			s.orderError(writer, request, err)
 			return
 		}
 
+		s.events.Publish(events.TypeOrderDeleted, map[string]int{"order_id": id})
+
 		s.respond(writer, request, http.StatusOK, nil)
 	}
 }
 
+func (s *server) handleOrderStatusChange() http.HandlerFunc {
+	type requests struct {
+		Status string `json:"status"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid order ID"))
+			return
+		}
+
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		actor := request.Context().Value(ctxKeyUser).(*model.User)
+
+		o, err := s.orders.ChangeStatus(request.Context(), id, actor, req.Status)
+		if err != nil {
+			s.orderError(writer, request, err)
+			return
+		}
+		s.logPendingRefund(o)
+
+		s.events.Publish(events.TypeOrderStatusChanged, &kitchenStatusChange{
+			OrderId:   o.ID,
+			Status:    o.Status,
+			ChangedBy: actor.ID,
+		})
+
+		s.respond(writer, request, http.StatusOK, o)
+	}
+}
+
+func (s *server) handleOrderHistory() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid order ID"))
+			return
+		}
+
+		if !s.authorizeOrder(writer, request, id, policy.CanViewOrder) {
+			return
+		}
+
+		history, err := s.orders.History(id)
+		if err != nil {
+			s.orderError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, history)
+	}
+}
+
 func (s *server) handleUsersCreate() http.HandlerFunc {
 	type requests struct {
 		Email    string `json:"email"`
@@ -677,6 +1028,10 @@ func (s *server) handleUsersCreate() http.HandlerFunc {
 			return
 		}
 
+		if err := s.verifier.Send(request.Context(), u); err != nil {
+			s.logger.Errorf("verification email for user %d: %v", u.ID, err)
+		}
+
 		u.Sanitize()
 		s.respond(writer, request, http.StatusCreated, u)
 	}
@@ -695,25 +1050,43 @@ func (s *server) handleSessionsCreate() http.HandlerFunc {
 			return
 		}
 
+		ip := s.clientIP(request)
+		if wait, err := s.logins.Attempt(req.Email, ip); err != nil {
+			s.loginGuardError(writer, request, wait, err)
+			return
+		}
+
 		u, err := s.store.User().FindByEmail(req.Email)
 		if err != nil || !u.ComparePassword(req.Password) {
+			if err := s.logins.Fail(request.Context(), req.Email, ip); err != nil {
+				s.error(writer, request, http.StatusInternalServerError, err)
+				return
+			}
+
 			s.error(writer, request, http.StatusUnauthorized, errIncorrectEmailOrPassword)
 			return
 		}
 
-		session, err := s.sessionStore.Get(request, sessionName)
+		if err := s.logins.Succeed(req.Email); err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		login, err := s.tokens.Login(request.Context(), u, request.UserAgent(), ip)
 		if err != nil {
 			s.error(writer, request, http.StatusInternalServerError, err)
 			return
 		}
 
-		session.Values["user_id"] = u.ID
+		session, _ := s.sessionStore.Get(request, sessionName)
+		delete(session.Values, "user_id")
+		session.Values[sessionTokenKey] = login.SessionToken
 		if err := s.sessionStore.Save(request, writer, session); err != nil {
 			s.error(writer, request, http.StatusInternalServerError, err)
 			return
 		}
 
-		s.respond(writer, request, http.StatusOK, nil)
+		s.respond(writer, request, http.StatusOK, login.Tokens)
 	}
 }
 
@@ -728,20 +1101,18 @@ func (s *server) respond(writer http.ResponseWriter, request *http.Request, code
 	}
 }
 
+// getUserId returns the ID of the user authenticateUser put in the context,
+// or else the one of the session cookie or access token.
 func (s *server) getUserId(writer http.ResponseWriter, request *http.Request) (int, error) {
-	session, err := s.sessionStore.Get(request, sessionName)
+	if u, ok := request.Context().Value(ctxKeyUser).(*model.User); ok {
+		return u.ID, nil
+	}
 
+	sess, err := s.authenticate(request)
 	if err != nil {
-		s.error(writer, request, http.StatusInternalServerError, err)
+		s.authError(writer, request, err)
 		return 0, err
 	}
 
-	userId, authorized := session.Values["user_id"]
-
-	if !authorized {
-		s.error(writer, request, http.StatusUnauthorized, errNotAuthenticated)
-		return 0, nil
-	}
-
-	return userId.(int), nil
+	return sess.UserId, nil
 }
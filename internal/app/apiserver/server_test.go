@@ -0,0 +1,160 @@
+package apiserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gorilla/sessions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/auth"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSecret = "secret"
+
+// newTestServer returns a server on an empty teststore that is open all
+// day and keeps notifications in an outbox.
+func newTestServer(t *testing.T) (*server, *teststore.Store) {
+	t.Helper()
+
+	config := NewConfig()
+	config.JWTSecret = testSecret
+	config.Notifier = "outbox"
+	config.OpeningTime = "00:00"
+	config.ClosingTime = "23:59"
+
+	st := teststore.New()
+	s, err := newServer(st, sessions.NewCookieStore([]byte(testSecret)), config)
+	require.NoError(t, err)
+
+	s.logger.SetOutput(&bytes.Buffer{})
+
+	return s, st
+}
+
+var lastTestUser int
+
+// testUser creates a verified user with the role and the password
+// "password".
+func testUser(t *testing.T, st *teststore.Store, role string) *model.User {
+	t.Helper()
+
+	lastTestUser++
+	u := &model.User{
+		Email:         fmt.Sprintf("user%d@example.org", lastTestUser),
+		Username:      fmt.Sprintf("user%d", lastTestUser),
+		Password:      "password",
+		Role:          role,
+		EmailVerified: true,
+	}
+	require.NoError(t, st.User().Create(u))
+
+	return u
+}
+
+// jsonBody encodes body as JSON, or returns an empty body for nil.
+func jsonBody(t *testing.T, body interface{}) *bytes.Buffer {
+	t.Helper()
+
+	b := &bytes.Buffer{}
+	if body != nil {
+		require.NoError(t, json.NewEncoder(b).Encode(body))
+	}
+
+	return b
+}
+
+// serve sends a request with the JSON body to the server, authenticated
+// with the access token unless it is empty.
+func serve(t *testing.T, s *server, method string, path string, body interface{}, token string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequest(method, path, jsonBody(t, body))
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+// logIn logs the user in through /sessions and returns the tokens.
+func logIn(t *testing.T, s *server, u *model.User) *service.Tokens {
+	t.Helper()
+
+	recorder := serve(t, s, http.MethodPost, "/sessions", map[string]string{"email": u.Email, "password": "password"}, "")
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	tokens := &service.Tokens{}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(tokens))
+
+	return tokens
+}
+
+func TestServer_HandleSessionsCreate_RoleClaim(t *testing.T) {
+	s, st := newTestServer(t)
+	signer, err := auth.NewSigner(testSecret, time.Hour)
+	require.NoError(t, err)
+
+	for _, role := range []string{model.RoleUser, model.RoleStaff, model.RoleAdmin} {
+		u := testUser(t, st, role)
+
+		claims, err := signer.Verify(logIn(t, s, u).AccessToken, time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, role, claims.Role)
+
+		id, err := claims.UserId()
+		require.NoError(t, err)
+		assert.Equal(t, u.ID, id)
+	}
+}
+
+func TestServer_HandleSessionsCreate_WrongPassword(t *testing.T) {
+	s, st := newTestServer(t)
+	u := testUser(t, st, model.RoleUser)
+
+	recorder := serve(t, s, http.MethodPost, "/sessions", map[string]string{"email": u.Email, "password": "wrong"}, "")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serve(t, s, http.MethodPost, "/sessions", map[string]string{"email": "nobody@example.org", "password": "password"}, "")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
+
+func TestServer_HandleRoleChange(t *testing.T) {
+	s, st := newTestServer(t)
+	admin := testUser(t, st, model.RoleAdmin)
+	u := testUser(t, st, model.RoleUser)
+	token := logIn(t, s, admin).AccessToken
+	userToken := logIn(t, s, u).AccessToken
+
+	path := fmt.Sprintf("/admin/users/%d/role", u.ID)
+
+	recorder := serve(t, s, http.MethodPatch, path, map[string]string{"role": "owner"}, token)
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+	recorder = serve(t, s, http.MethodPatch, path, map[string]string{"role": model.RoleStaff}, userToken)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	recorder = serve(t, s, http.MethodPatch, fmt.Sprintf("/admin/users/%d/role", u.ID+100), map[string]string{"role": model.RoleStaff}, token)
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = serve(t, s, http.MethodPatch, path, map[string]string{"role": model.RoleStaff}, token)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	stored, err := st.User().Find(u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, model.RoleStaff, stored.Role)
+
+	// The old token still says "user"; its session was revoked.
+	recorder = serve(t, s, http.MethodGet, "/private/whoami", nil, userToken)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+}
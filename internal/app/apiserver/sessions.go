@@ -0,0 +1,299 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/auth"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// sessionTokenKey is the key of the session token in the session cookie.
+const sessionTokenKey = "session_token"
+
+var (
+	errMissingRefreshToken = errors.New("refresh_token is required")
+	errInvalidSessionID    = errors.New("invalid session ID")
+	errSessionNotFound     = errors.New("session not found")
+)
+
+// sessionInfo is a session as its user sees it.
+type sessionInfo struct {
+	*model.Session
+	Current bool `json:"current"`
+}
+
+// bearerToken returns the token of an Authorization: Bearer header.
+func bearerToken(request *http.Request) (string, bool) {
+	const prefix = "bearer "
+
+	header := request.Header.Get("Authorization")
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+
+	return strings.TrimSpace(header[len(prefix):]), true
+}
+
+// parseProxies parses the addresses and CIDR ranges of trusted proxies.
+func parseProxies(proxies []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(proxies))
+	for _, p := range proxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted_proxies: invalid address %q", p)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("trusted_proxies: %w", err)
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+// trustedProxy reports whether ip is one of the trusted proxies.
+func (s *server) trustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, n := range s.trustedProxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clientIP returns the address the request came from, without its port.
+// When the request comes through a trusted proxy it is the last address in
+// X-Forwarded-For that is not a trusted proxy, or else X-Real-IP. Headers
+// sent by other peers are ignored, as anyone can set them.
+func (s *server) clientIP(request *http.Request) string {
+	peer, _, err := net.SplitHostPort(request.RemoteAddr)
+	if err != nil {
+		peer = request.RemoteAddr
+	}
+
+	if !s.trustedProxy(peer) {
+		return peer
+	}
+
+	if header := request.Header.Values("X-Forwarded-For"); len(header) > 0 {
+		hops := strings.Split(strings.Join(header, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break
+			}
+
+			if i == 0 || !s.trustedProxy(hop) {
+				return hop
+			}
+		}
+	}
+
+	if ip := strings.TrimSpace(request.Header.Get("X-Real-IP")); net.ParseIP(ip) != nil {
+		return ip
+	}
+
+	return peer
+}
+
+// authenticate returns the session of the request, from the bearer access
+// token or else from the session cookie.
+func (s *server) authenticate(request *http.Request) (*model.Session, error) {
+	if token, ok := bearerToken(request); ok {
+		return s.tokens.Authenticate(token)
+	}
+
+	session, err := s.sessionStore.Get(request, sessionName)
+	if err != nil {
+		return nil, errNotAuthenticated
+	}
+
+	token, ok := session.Values[sessionTokenKey].(string)
+	if !ok || token == "" {
+		return nil, errNotAuthenticated
+	}
+
+	return s.tokens.AuthenticateSession(token)
+}
+
+// authError answers a request authenticate rejected.
+func (s *server) authError(writer http.ResponseWriter, request *http.Request, err error) {
+	switch {
+	case errors.Is(err, errNotAuthenticated),
+		errors.Is(err, auth.ErrInvalidToken),
+		errors.Is(err, auth.ErrTokenExpired),
+		errors.Is(err, service.ErrSessionExpired):
+		s.error(writer, request, http.StatusUnauthorized, err)
+	default:
+		s.error(writer, request, http.StatusInternalServerError, err)
+	}
+}
+
+// handleSessionsRefresh trades a refresh token for a new access token and a
+// new refresh token.
+func (s *server) handleSessionsRefresh() http.HandlerFunc {
+	type requests struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.RefreshToken == "" {
+			s.error(writer, request, http.StatusBadRequest, errMissingRefreshToken)
+			return
+		}
+
+		tokens, err := s.tokens.Refresh(request.Context(), req.RefreshToken)
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			s.error(writer, request, http.StatusUnauthorized, err)
+			return
+		}
+
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, tokens)
+	}
+}
+
+// handleSessionsDelete logs out: it revokes the session of the refresh token
+// sent in the body, or else the session the request is authenticated with,
+// and expires the session cookie.
+func (s *server) handleSessionsDelete() http.HandlerFunc {
+	type requests struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil && err != io.EOF {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.RefreshToken != "" {
+			if err := s.tokens.LogoutRefreshToken(request.Context(), req.RefreshToken); err != nil {
+				s.error(writer, request, http.StatusInternalServerError, err)
+				return
+			}
+		} else if sess, err := s.authenticate(request); err == nil {
+			if err := s.tokens.Logout(request.Context(), sess.ID); err != nil {
+				s.error(writer, request, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		session, _ := s.sessionStore.Get(request, sessionName)
+		session.Options.MaxAge = -1
+		if err := s.sessionStore.Save(request, writer, session); err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, nil)
+	}
+}
+
+// handleSessionsGet lists the active sessions of the user and marks the one
+// the request is made with.
+func (s *server) handleSessionsGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		u := request.Context().Value(ctxKeyUser).(*model.User)
+		current := request.Context().Value(ctxKeySession).(*model.Session)
+
+		sessions, err := s.tokens.Sessions(request.Context(), u.ID)
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		res := make([]sessionInfo, 0, len(sessions))
+		for _, sess := range sessions {
+			res = append(res, sessionInfo{Session: sess, Current: sess.ID == current.ID})
+		}
+
+		s.respond(writer, request, http.StatusOK, res)
+	}
+}
+
+// handleSessionRevoke signs one of the user's sessions out, the current one
+// included.
+func (s *server) handleSessionRevoke() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidSessionID)
+			return
+		}
+
+		u := request.Context().Value(ctxKeyUser).(*model.User)
+
+		err = s.tokens.RevokeSession(request.Context(), u.ID, id)
+		if errors.Is(err, store.ErrRecordNotFound) {
+			s.error(writer, request, http.StatusNotFound, errSessionNotFound)
+			return
+		}
+
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, map[string]string{"message": "Session revoked"})
+	}
+}
+
+// handleSessionsRevokeAll signs the user out everywhere. With
+// keep_current=true the session the request is made with stays active.
+func (s *server) handleSessionsRevokeAll() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		u := request.Context().Value(ctxKeyUser).(*model.User)
+
+		keepId := 0
+		if request.URL.Query().Get("keep_current") == "true" {
+			keepId = request.Context().Value(ctxKeySession).(*model.Session).ID
+		}
+
+		if err := s.tokens.RevokeSessions(request.Context(), u.ID, keepId); err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, map[string]string{"message": "Sessions revoked"})
+	}
+}
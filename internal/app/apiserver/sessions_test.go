@@ -0,0 +1,200 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestParseProxies(t *testing.T) {
+	nets, err := parseProxies([]string{"10.0.0.1", "192.168.0.0/16", "::1"})
+	require.NoError(t, err)
+	assert.Len(t, nets, 3)
+
+	_, err = parseProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+
+	_, err = parseProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestServer_ClientIP(t *testing.T) {
+	proxies, err := parseProxies([]string{"10.0.0.1", "192.168.0.0/16"})
+	require.NoError(t, err)
+	s := &server{trustedProxies: proxies}
+
+	for _, tc := range []struct {
+		name         string
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		expected     string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:4000", expected: "203.0.113.7"},
+		{name: "headers from untrusted peer", remoteAddr: "203.0.113.7:4000", forwardedFor: []string{"198.51.100.1"}, realIP: "198.51.100.2", expected: "203.0.113.7"},
+		{name: "forwarded for", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "spoofed first hop", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"1.2.3.4, 198.51.100.1"}, expected: "198.51.100.1"},
+		{name: "proxy chain", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"198.51.100.1, 192.168.1.1", "192.168.2.2"}, expected: "198.51.100.1"},
+		{name: "only proxies", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"192.168.1.1, 192.168.2.2"}, expected: "192.168.1.1"},
+		{name: "real ip", remoteAddr: "10.0.0.1:4000", realIP: "198.51.100.2", expected: "198.51.100.2"},
+		{name: "garbage", remoteAddr: "10.0.0.1:4000", forwardedFor: []string{"unknown"}, realIP: "nope", expected: "10.0.0.1"},
+	} {
+		request := httptest.NewRequest(http.MethodPost, "/sessions", nil)
+		request.RemoteAddr = tc.remoteAddr
+		for _, v := range tc.forwardedFor {
+			request.Header.Add("X-Forwarded-For", v)
+		}
+		if tc.realIP != "" {
+			request.Header.Set("X-Real-IP", tc.realIP)
+		}
+
+		assert.Equal(t, tc.expected, s.clientIP(request), tc.name)
+	}
+}
+
+// failLogin sends a login with a wrong password from the client address,
+// coming through the trusted proxy 10.0.0.1.
+func failLogin(t *testing.T, s *server, email string, client string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	request := httptest.NewRequest(http.MethodPost, "/sessions", jsonBody(t, map[string]string{"email": email, "password": "wrong"}))
+	request.RemoteAddr = "10.0.0.1:4000"
+	request.Header.Set("X-Forwarded-For", client)
+	recorder := httptest.NewRecorder()
+	s.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func TestServer_HandleSessionsCreate_Throttle(t *testing.T) {
+	s, st := newTestServer(t)
+	s.trustedProxies, _ = parseProxies([]string{"10.0.0.1"})
+	u := testUser(t, st, model.RoleUser)
+
+	recorder := failLogin(t, s, u.Email, "198.51.100.1")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = failLogin(t, s, u.Email, "198.51.100.2")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	retryAfter, err := strconv.Atoi(recorder.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, retryAfter)
+
+	_, err = st.LoginThrottle().Find(model.ThrottleIP, "198.51.100.1")
+	assert.NoError(t, err)
+	_, err = st.LoginThrottle().Find(model.ThrottleIP, "10.0.0.1")
+	assert.Error(t, err, "the proxy itself is not counted")
+}
+
+func TestServer_HandleSessionsCreate_Lockout(t *testing.T) {
+	s, st := newTestServer(t)
+	s.trustedProxies, _ = parseProxies([]string{"10.0.0.1"})
+	s.logins = service.NewLoginGuard(st, service.LoginPolicy{MaxFailures: 3, MaxIPFailures: 2, Window: time.Hour, Lockout: time.Hour})
+	u := testUser(t, st, model.RoleUser)
+	admin := testUser(t, st, model.RoleAdmin)
+
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusUnauthorized, failLogin(t, s, u.Email, fmt.Sprintf("198.51.100.%d", i)).Code)
+	}
+
+	recorder := serve(t, s, http.MethodPost, "/sessions", map[string]string{"email": u.Email, "password": "password"}, "")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.NotEmpty(t, recorder.Header().Get("Retry-After"))
+
+	// Two addresses are locked out after two failures each, whoever's.
+	for _, email := range []string{"a@example.org", "b@example.org"} {
+		assert.Equal(t, http.StatusUnauthorized, failLogin(t, s, email, "198.51.100.9").Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, failLogin(t, s, "c@example.org", "198.51.100.9").Code)
+	assert.Equal(t, http.StatusUnauthorized, failLogin(t, s, "c@example.org", "198.51.100.10").Code)
+
+	token := logIn(t, s, admin).AccessToken
+	recorder = serve(t, s, http.MethodPost, fmt.Sprintf("/admin/users/%d/unlock", u.ID), nil, token)
+	require.Equal(t, http.StatusOK, recorder.Code, recorder.Body.String())
+
+	logIn(t, s, u)
+}
+
+func TestServer_SessionFlow(t *testing.T) {
+	s, st := newTestServer(t)
+	u := testUser(t, st, model.RoleUser)
+
+	assert.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodGet, "/private/whoami", nil, "").Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodGet, "/private/whoami", nil, "garbage").Code)
+
+	// The cookie of a login authenticates like its access token.
+	recorder := serve(t, s, http.MethodPost, "/sessions", map[string]string{"email": u.Email, "password": "password"}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	cookies := recorder.Result().Cookies()
+	require.NotEmpty(t, cookies)
+
+	request := httptest.NewRequest(http.MethodGet, "/private/whoami", nil)
+	for _, c := range cookies {
+		request.AddCookie(c)
+	}
+	recorder = httptest.NewRecorder()
+	s.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var me model.User
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&me))
+	assert.Equal(t, u.ID, me.ID)
+
+	first := logIn(t, s, u)
+	second := logIn(t, s, u)
+
+	// A refresh token is good for one refresh; using it again revokes the
+	// session.
+	recorder = serve(t, s, http.MethodPost, "/sessions/refresh", map[string]string{"refresh_token": second.RefreshToken}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	refreshed := &service.Tokens{}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(refreshed))
+	assert.Equal(t, http.StatusOK, serve(t, s, http.MethodGet, "/private/whoami", nil, refreshed.AccessToken).Code)
+
+	recorder = serve(t, s, http.MethodPost, "/sessions/refresh", map[string]string{"refresh_token": second.RefreshToken}, "")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodGet, "/private/whoami", nil, refreshed.AccessToken).Code)
+
+	recorder = serve(t, s, http.MethodGet, "/private/sessions", nil, first.AccessToken)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var sessions []struct {
+		ID      int  `json:"id"`
+		Current bool `json:"current"`
+	}
+	require.NoError(t, json.NewDecoder(recorder.Body).Decode(&sessions))
+	require.Len(t, sessions, 2)
+
+	var current, cookieSession int
+	for _, sess := range sessions {
+		if sess.Current {
+			current = sess.ID
+		} else {
+			cookieSession = sess.ID
+		}
+	}
+	require.NotZero(t, current)
+	require.NotZero(t, cookieSession)
+
+	assert.Equal(t, http.StatusNotFound, serve(t, s, http.MethodDelete, fmt.Sprintf("/private/sessions/%d", current+100), nil, first.AccessToken).Code)
+	assert.Equal(t, http.StatusOK, serve(t, s, http.MethodDelete, fmt.Sprintf("/private/sessions/%d", cookieSession), nil, first.AccessToken).Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/private/whoami", nil)
+	for _, c := range cookies {
+		request.AddCookie(c)
+	}
+	recorder = httptest.NewRecorder()
+	s.ServeHTTP(recorder, request)
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	recorder = serve(t, s, http.MethodDelete, "/sessions", map[string]string{"refresh_token": first.RefreshToken}, "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(t, s, http.MethodGet, "/private/whoami", nil, first.AccessToken).Code)
+}
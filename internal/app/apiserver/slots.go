@@ -0,0 +1,118 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+var errInvalidDate = errors.New("invalid date, expected YYYY-MM-DD")
+
+type respondSlot struct {
+	*model.PickupSlot
+	RemainingOrders int `json:"remaining_orders"`
+	RemainingItems  int `json:"remaining_items"`
+}
+
+func newRespondSlots(slots []*model.PickupSlot) []*respondSlot {
+	respondSlots := make([]*respondSlot, 0, len(slots))
+	for _, slot := range slots {
+		respondSlots = append(respondSlots, &respondSlot{
+			PickupSlot:      slot,
+			RemainingOrders: slot.RemainingOrders(),
+			RemainingItems:  slot.RemainingItems(),
+		})
+	}
+
+	return respondSlots
+}
+
+func (s *server) handleSlotsGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		date := time.Now()
+		if raw := request.URL.Query().Get("date"); raw != "" {
+			d, err := time.ParseInLocation(dateLayout, raw, time.Local)
+			if err != nil {
+				s.error(writer, request, http.StatusBadRequest, errInvalidDate)
+				return
+			}
+			date = d
+		}
+
+		slots, err := s.slots.ForDay(date)
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, newRespondSlots(slots))
+	}
+}
+
+func (s *server) handleSlotsCreate() http.HandlerFunc {
+	type requests struct {
+		Date            string `json:"date"`
+		IntervalMinutes int    `json:"interval_minutes"`
+		MaxOrders       int    `json:"max_orders"`
+		MaxItems        int    `json:"max_items"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		date, err := time.ParseInLocation(dateLayout, req.Date, time.Local)
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errInvalidDate)
+			return
+		}
+
+		slots, err := s.slots.Generate(request.Context(), &service.GenerateSlots{
+			Date:      date,
+			Interval:  time.Duration(req.IntervalMinutes) * time.Minute,
+			MaxOrders: req.MaxOrders,
+			MaxItems:  req.MaxItems,
+		})
+		if err != nil {
+			s.error(writer, request, http.StatusUnprocessableEntity, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusCreated, newRespondSlots(slots))
+	}
+}
+
+func (s *server) handleSlotDelete() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid slot ID"))
+			return
+		}
+
+		if err := s.slots.Delete(request.Context(), id); err != nil {
+			switch {
+			case errors.Is(err, store.ErrRecordNotFound):
+				s.error(writer, request, http.StatusNotFound, err)
+			case errors.Is(err, service.ErrSlotInUse):
+				s.error(writer, request, http.StatusConflict, err)
+			default:
+				s.error(writer, request, http.StatusInternalServerError, err)
+			}
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, id)
+	}
+}
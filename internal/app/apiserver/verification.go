@@ -0,0 +1,101 @@
+package apiserver
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"strconv"
+)
+
+var errMissingVerifyToken = errors.New("token is required")
+
+// handleUsersVerify verifies the email address of an account with the token
+// of a verification link.
+func (s *server) handleUsersVerify() http.HandlerFunc {
+	type requests struct {
+		Token string `json:"token"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Token == "" {
+			s.error(writer, request, http.StatusBadRequest, errMissingVerifyToken)
+			return
+		}
+
+		u, err := s.verifier.Verify(request.Context(), req.Token)
+		if errors.Is(err, service.ErrInvalidVerifyToken) {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, u)
+	}
+}
+
+// handleUsersVerifyResend mails a new verification link. It answers the
+// same whether or not the address has an unverified account.
+func (s *server) handleUsersVerifyResend() http.HandlerFunc {
+	type requests struct {
+		Email string `json:"email"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		if req.Email == "" {
+			s.error(writer, request, http.StatusBadRequest, errMissingEmail)
+			return
+		}
+
+		if err := s.verifier.Resend(request.Context(), req.Email); err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusAccepted, map[string]string{
+			"message": "If the address has an unverified account, a verification link is on its way",
+		})
+	}
+}
+
+// handleUserVerify lets admins verify an account by hand.
+func (s *server) handleUserVerify() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		id, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid user ID"))
+			return
+		}
+
+		u, err := s.verifier.MarkVerified(request.Context(), id)
+		if errors.Is(err, store.ErrRecordNotFound) {
+			s.error(writer, request, http.StatusNotFound, err)
+			return
+		}
+
+		if err != nil {
+			s.error(writer, request, http.StatusInternalServerError, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, u)
+	}
+}
@@ -0,0 +1,93 @@
+package apiserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/http"
+	"strconv"
+)
+
+func (s *server) handleWalletGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		u := request.Context().Value(ctxKeyUser).(*model.User)
+
+		wallet, err := s.wallets.Get(u.ID)
+		if err != nil {
+			s.walletError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, wallet)
+	}
+}
+
+func (s *server) handleAdminWalletGet() http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userId, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid user ID"))
+			return
+		}
+
+		wallet, err := s.wallets.Get(userId)
+		if err != nil {
+			s.walletError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusOK, wallet)
+	}
+}
+
+type walletOperation func(ctx context.Context, userId int, amount int, actor *model.User, note string) (*model.WalletEntry, error)
+
+// handleWalletEntryCreate serves the admin top-up and adjustment endpoints,
+// which only differ in the wallet operation they apply.
+func (s *server) handleWalletEntryCreate(op walletOperation) http.HandlerFunc {
+	type requests struct {
+		Amount int    `json:"amount"`
+		Note   string `json:"note"`
+	}
+
+	return func(writer http.ResponseWriter, request *http.Request) {
+		userId, err := strconv.Atoi(mux.Vars(request)["id"])
+		if err != nil {
+			s.error(writer, request, http.StatusBadRequest, errors.New("invalid user ID"))
+			return
+		}
+
+		req := &requests{}
+		if err := json.NewDecoder(request.Body).Decode(req); err != nil {
+			s.error(writer, request, http.StatusBadRequest, err)
+			return
+		}
+
+		actor := request.Context().Value(ctxKeyUser).(*model.User)
+
+		entry, err := op(request.Context(), userId, req.Amount, actor, req.Note)
+		if err != nil {
+			s.walletError(writer, request, err)
+			return
+		}
+
+		s.respond(writer, request, http.StatusCreated, entry)
+	}
+}
+
+func (s *server) walletError(writer http.ResponseWriter, request *http.Request, err error) {
+	switch {
+	case errors.Is(err, store.ErrRecordNotFound):
+		s.error(writer, request, http.StatusNotFound, err)
+	case errors.Is(err, service.ErrInsufficientFunds):
+		s.error(writer, request, http.StatusConflict, err)
+	case errors.Is(err, service.ErrNonPositiveAmount), errors.Is(err, service.ErrZeroAmount):
+		s.error(writer, request, http.StatusUnprocessableEntity, err)
+	default:
+		s.error(writer, request, http.StatusInternalServerError, err)
+	}
+}
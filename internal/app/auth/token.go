@@ -0,0 +1,140 @@
+// Package auth issues and verifies credentials: short-lived signed access
+// tokens for API clients that cannot keep a session cookie, and the opaque
+// tokens behind refresh tokens and session cookies.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid access token")
+	ErrTokenExpired = errors.New("access token expired")
+	ErrNoSecret     = errors.New("token signing secret must not be empty")
+)
+
+// header is the fixed JOSE header of every access token.
+var header = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Claims are the claims of an access token. Subject is the user ID and
+// SessionId the login the token was issued for.
+type Claims struct {
+	Subject   string `json:"sub"`
+	SessionId int    `json:"sid"`
+	Role      string `json:"role"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// UserId ...
+func (c *Claims) UserId() (int, error) {
+	id, err := strconv.Atoi(c.Subject)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidToken
+	}
+
+	return id, nil
+}
+
+// Signer signs and verifies access tokens: JSON Web Tokens signed with
+// HMAC-SHA256 over a shared secret.
+type Signer struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+// NewSigner ...
+func NewSigner(secret string, ttl time.Duration) (*Signer, error) {
+	if secret == "" {
+		return nil, ErrNoSecret
+	}
+
+	return &Signer{secret: []byte(secret), ttl: ttl}, nil
+}
+
+// TTL is how long the access tokens of the signer are valid.
+func (s *Signer) TTL() time.Duration {
+	return s.ttl
+}
+
+// Sign returns an access token for the user's session that is valid from
+// now on.
+func (s *Signer) Sign(userId int, sessionId int, role string, now time.Time) (string, error) {
+	payload, err := json.Marshal(&Claims{
+		Subject:   strconv.Itoa(userId),
+		SessionId: sessionId,
+		Role:      role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(s.ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(s.mac(unsigned)), nil
+}
+
+// Verify checks the signature and expiry of the token and returns its
+// claims.
+func (s *Signer) Verify(token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] != header {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, s.mac(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	c := &Claims{}
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if now.Unix() >= c.ExpiresAt {
+		return nil, ErrTokenExpired
+	}
+
+	return c, nil
+}
+
+func (s *Signer) mac(unsigned string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(unsigned))
+	return h.Sum(nil)
+}
+
+// NewToken returns a random opaque token, as used for refresh tokens and
+// session cookies. Only its hash is stored, so a leaked database does not
+// leak usable tokens.
+func NewToken() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// HashToken returns the hash an opaque token is stored and looked up by.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,109 @@
+// Package events provides the in-process event bus that feeds the kitchen
+// display with order activity.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Event types published by the API server.
+const (
+	TypeOrderCreated       = "order.created"
+	TypeOrderUpdated       = "order.updated"
+	TypeOrderDeleted       = "order.deleted"
+	TypeOrderStatusChanged = "order.status_changed"
+)
+
+const subscriberBuffer = 64
+
+// Event ...
+type Event struct {
+	ID   int64       `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Bus fans published events out to every subscriber and keeps the most
+// recent ones so that reconnecting clients can catch up.
+type Bus struct {
+	mu          sync.Mutex
+	nextID      int64
+	history     []Event
+	historySize int
+	subscribers map[chan Event]struct{}
+}
+
+// NewBus returns a bus remembering the last historySize events.
+func NewBus(historySize int) *Bus {
+	return &Bus{
+		historySize: historySize,
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
+
+// Publish assigns the next event ID and delivers the event to all current
+// subscribers. Subscribers that cannot keep up are disconnected; they are
+// expected to reconnect and replay from their last seen event ID.
+func (b *Bus) Publish(eventType string, data interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	e := Event{
+		ID:   b.nextID,
+		Type: eventType,
+		Time: time.Now(),
+		Data: data,
+	}
+
+	b.history = append(b.history, e)
+	if len(b.history) > b.historySize {
+		b.history = b.history[len(b.history)-b.historySize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return e
+}
+
+// Subscribe registers a new subscriber. Events newer than lastEventID that
+// are still in the history are returned for replay; pass 0 to skip replay.
+// The returned channel is closed when cancel is called or when the
+// subscriber falls too far behind.
+func (b *Bus) Subscribe(lastEventID int64) ([]Event, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var replay []Event
+	if lastEventID > 0 {
+		for _, e := range b.history {
+			if e.ID > lastEventID {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return replay, ch, cancel
+}
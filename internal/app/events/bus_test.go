@@ -0,0 +1,88 @@
+package events_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/events"
+	"testing"
+)
+
+func TestBus_Publish(t *testing.T) {
+	b := events.NewBus(10)
+	replay, ch, cancel := b.Subscribe(0)
+	defer cancel()
+	assert.Empty(t, replay)
+
+	first := b.Publish(events.TypeOrderCreated, 1)
+	second := b.Publish(events.TypeOrderDeleted, 1)
+	assert.Equal(t, first.ID+1, second.ID)
+
+	e := <-ch
+	assert.Equal(t, first.ID, e.ID)
+	assert.Equal(t, events.TypeOrderCreated, e.Type)
+	e = <-ch
+	assert.Equal(t, second.ID, e.ID)
+}
+
+func TestBus_Subscribe_Replay(t *testing.T) {
+	b := events.NewBus(3)
+	for i := 0; i < 5; i++ {
+		b.Publish(events.TypeOrderUpdated, i)
+	}
+
+	// Only the last three events are kept.
+	replay, _, cancel := b.Subscribe(1)
+	defer cancel()
+	require.Len(t, replay, 3)
+	assert.Equal(t, []int64{3, 4, 5}, []int64{replay[0].ID, replay[1].ID, replay[2].ID})
+
+	replay, _, cancel = b.Subscribe(4)
+	defer cancel()
+	require.Len(t, replay, 1)
+	assert.Equal(t, int64(5), replay[0].ID)
+
+	replay, _, cancel = b.Subscribe(5)
+	defer cancel()
+	assert.Empty(t, replay)
+}
+
+func TestBus_SlowSubscriberDropped(t *testing.T) {
+	b := events.NewBus(1000)
+	_, slow, cancelSlow := b.Subscribe(0)
+	defer cancelSlow()
+	_, fast, cancelFast := b.Subscribe(0)
+	defer cancelFast()
+
+	for i := 0; i < 100; i++ {
+		e := b.Publish(events.TypeOrderCreated, i)
+
+		got, ok := <-fast
+		require.True(t, ok)
+		assert.Equal(t, e.ID, got.ID)
+	}
+
+	// The slow subscriber got what fit into its buffer and was then
+	// disconnected, to replay the rest after reconnecting.
+	var last int64
+	for e := range slow {
+		last = e.ID
+	}
+	assert.Less(t, last, int64(100))
+
+	replay, _, cancel := b.Subscribe(last)
+	defer cancel()
+	assert.Len(t, replay, 100-int(last))
+}
+
+func TestBus_Cancel(t *testing.T) {
+	b := events.NewBus(10)
+	_, ch, cancel := b.Subscribe(0)
+
+	cancel()
+	cancel()
+
+	_, ok := <-ch
+	assert.False(t, ok)
+
+	b.Publish(events.TypeOrderCreated, 1)
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Audit actions.
+const (
+	AuditLoginLockout = "login.lockout"
+	AuditLoginUnlock  = "login.unlock"
+)
+
+// AuditEntry records a security relevant event. UserId is the account the
+// event concerns and ActorId the admin who caused it, when there are any.
+type AuditEntry struct {
+	ID        int       `json:"id"`
+	Action    string    `json:"action"`
+	UserId    *int      `json:"user_id,omitempty"`
+	ActorId   *int      `json:"actor_id,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,70 @@
+package model
+
+import validation "github.com/go-ozzo/ozzo-validation"
+
+// Combo is a set meal sold at a bundle price, such as "soup + main + drink".
+// The customer picks one dish for each of its slots.
+type Combo struct {
+	ID          int          `json:"id"`
+	CategoryID  int          `json:"category_id"`
+	Name        string       `json:"name"`
+	Price       int          `json:"price"`
+	Description string       `json:"description"`
+	Slots       []*ComboSlot `json:"slots"`
+}
+
+// ComboSlot is a course of a combo. It allows any item of its category, the
+// items listed in MenuItemIds, or both.
+type ComboSlot struct {
+	ID          int    `json:"id"`
+	ComboId     int    `json:"combo_id"`
+	Name        string `json:"name"`
+	CategoryID  *int   `json:"category_id,omitempty"`
+	MenuItemIds []int  `json:"menu_item_ids,omitempty"`
+}
+
+// OrderItemComponent is the dish picked for a combo slot on an order line,
+// with the names it had at the time of purchase. ComboSlotId and MenuItemId
+// are zero once the slot or the menu item has been deleted.
+type OrderItemComponent struct {
+	ID          int    `json:"id"`
+	OrderItemId int    `json:"order_item_id"`
+	ComboSlotId int    `json:"combo_slot_id"`
+	SlotName    string `json:"slot_name"`
+	MenuItemId  int    `json:"menu_item_id"`
+	ItemName    string `json:"item_name"`
+}
+
+// Validate ...
+func (c *Combo) Validate() error {
+	return validation.ValidateStruct(
+		c,
+		validation.Field(&c.Name, validation.Required, validation.Length(1, 45)),
+		validation.Field(&c.Price, validation.Min(0)),
+		validation.Field(&c.Slots, validation.Required),
+	)
+}
+
+// Validate ...
+func (s *ComboSlot) Validate() error {
+	return validation.ValidateStruct(
+		s,
+		validation.Field(&s.Name, validation.Required, validation.Length(1, 45)),
+		validation.Field(&s.MenuItemIds, validation.By(requiredIf(s.CategoryID == nil))),
+	)
+}
+
+// Allows reports whether the menu item may be picked for the slot.
+func (s *ComboSlot) Allows(mi *MenuItem) bool {
+	if s.CategoryID != nil && *s.CategoryID == mi.CategoryID {
+		return true
+	}
+
+	for _, id := range s.MenuItemIds {
+		if id == mi.ID {
+			return true
+		}
+	}
+
+	return false
+}
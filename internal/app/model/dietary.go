@@ -0,0 +1,63 @@
+package model
+
+// The 14 allergens that must be declared under EU food labelling rules.
+const (
+	AllergenCelery      = "celery"
+	AllergenGluten      = "gluten"
+	AllergenCrustaceans = "crustaceans"
+	AllergenEggs        = "eggs"
+	AllergenFish        = "fish"
+	AllergenLupin       = "lupin"
+	AllergenMilk        = "milk"
+	AllergenMolluscs    = "molluscs"
+	AllergenMustard     = "mustard"
+	AllergenNuts        = "nuts"
+	AllergenPeanuts     = "peanuts"
+	AllergenSesame      = "sesame"
+	AllergenSoy         = "soy"
+	AllergenSulphites   = "sulphites"
+)
+
+// Dietary tags.
+const (
+	DietVegan      = "vegan"
+	DietVegetarian = "vegetarian"
+	DietHalal      = "halal"
+	DietGlutenFree = "gluten_free"
+)
+
+// Allergens ...
+var Allergens = []string{
+	AllergenCelery,
+	AllergenGluten,
+	AllergenCrustaceans,
+	AllergenEggs,
+	AllergenFish,
+	AllergenLupin,
+	AllergenMilk,
+	AllergenMolluscs,
+	AllergenMustard,
+	AllergenNuts,
+	AllergenPeanuts,
+	AllergenSesame,
+	AllergenSoy,
+	AllergenSulphites,
+}
+
+// Diets ...
+var Diets = []string{
+	DietVegan,
+	DietVegetarian,
+	DietHalal,
+	DietGlutenFree,
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
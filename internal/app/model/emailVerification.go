@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// EmailVerification is a single-use token sent to the email address of a
+// new account to prove the address belongs to its owner. Only the hash of
+// the token is kept.
+type EmailVerification struct {
+	ID        int
+	UserId    int
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// IsActiveAt reports whether the token can still be used at now.
+func (v *EmailVerification) IsActiveAt(now time.Time) bool {
+	return v.UsedAt == nil && now.Before(v.ExpiresAt)
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// Login throttle kinds: failed logins are counted per email address and per
+// client IP address.
+const (
+	ThrottleEmail = "email"
+	ThrottleIP    = "ip"
+)
+
+// LoginThrottle counts the recent failed logins for an email address or an
+// IP address, and holds the lockout they led to, if any. It is created by
+// the first failure. NextAttemptAt is when the next login may be attempted;
+// every later attempt moves it on as if it were going to fail.
+type LoginThrottle struct {
+	ID            int        `json:"-"`
+	Kind          string     `json:"kind"`
+	Key           string     `json:"key"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
+
+// IsLockedAt reports whether logins are locked out at now.
+func (t *LoginThrottle) IsLockedAt(now time.Time) bool {
+	return t.LockedUntil != nil && now.Before(*t.LockedUntil)
+}
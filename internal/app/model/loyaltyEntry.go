@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// Loyalty ledger entry kinds.
+const (
+	LoyaltyEntryEarn       = "earn"
+	LoyaltyEntryRedeem     = "redeem"
+	LoyaltyEntryRefund     = "refund"
+	LoyaltyEntryExpire     = "expire"
+	LoyaltyEntryAdjustment = "adjustment"
+)
+
+// LoyaltyEntry is a row of the append-only loyalty points ledger. Credits are
+// positive and debits negative; a user's balance is their sum. Earned points
+// stop counting at ExpiresAt, when an expire entry writes off what is left.
+type LoyaltyEntry struct {
+	ID        int        `json:"id"`
+	UserId    int        `json:"user_id"`
+	Kind      string     `json:"kind"`
+	Points    int        `json:"points"`
+	OrderId   *int       `json:"order_id,omitempty"`
+	CreatedBy int        `json:"created_by"`
+	Note      string     `json:"note,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// LoyaltyBalance ...
+type LoyaltyBalance struct {
+	UserId int `json:"user_id"`
+	Points int `json:"points"`
+}
@@ -1,9 +1,65 @@
 package model
 
+import validation "github.com/go-ozzo/ozzo-validation"
+
 type MenuItem struct {
-	ID          int    `json:"id"`
-	CategoryID  int    `json:"category_id"`
-	Name        string `json:"name"`
-	Price       int    `json:"price"`
-	Description string `json:"description"`
+	ID                int    `json:"id"`
+	CategoryID        int    `json:"category_id"`
+	Name              string `json:"name"`
+	Price             int    `json:"price"`
+	Description       string `json:"description"`
+	Stock             *int   `json:"stock"`
+	LowStockThreshold int    `json:"low_stock_threshold"`
+	Available         bool   `json:"available"`
+
+	Allergens []string `json:"allergens"`
+	Diets     []string `json:"diets"`
+
+	Nutrition *Nutrition `json:"nutrition"`
+
+	ModifierGroups []*ModifierGroup `json:"modifier_groups,omitempty"`
+}
+
+// IsAvailable reports whether the item can be ordered. Items without a stock
+// count are not inventory-tracked and are always available.
+func (m *MenuItem) IsAvailable() bool {
+	return m.Stock == nil || *m.Stock > 0
+}
+
+// IsLowStock reports whether a tracked item has fallen to its threshold.
+func (m *MenuItem) IsLowStock() bool {
+	return m.Stock != nil && *m.Stock <= m.LowStockThreshold
+}
+
+// Validate checks that allergens and diets only use the known tags and that
+// the nutrition facts, if any, are not negative.
+func (m *MenuItem) Validate() error {
+	return validation.ValidateStruct(
+		m,
+		validation.Field(&m.Allergens, validation.Each(validation.In(stringsIn(Allergens)...))),
+		validation.Field(&m.Diets, validation.Each(validation.In(stringsIn(Diets)...))),
+		validation.Field(&m.Nutrition),
+	)
+}
+
+// ContainsAnyAllergen reports whether the item declares any of the allergens.
+func (m *MenuItem) ContainsAnyAllergen(allergens []string) bool {
+	for _, a := range allergens {
+		if contains(m.Allergens, a) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SuitsDiets reports whether the item is tagged with every one of the diets.
+func (m *MenuItem) SuitsDiets(diets []string) bool {
+	for _, d := range diets {
+		if !contains(m.Diets, d) {
+			return false
+		}
+	}
+
+	return true
 }
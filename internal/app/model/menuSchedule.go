@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+const dateLayout = "2006-01-02"
+
+// MenuSchedule is a window in which a menu item, or every item of a category
+// and its subcategories, is on the menu. Weekdays holds time.Weekday values
+// (0 is Sunday); DailyFrom and DailyTo are "15:04" clock times and StartsOn
+// and EndsOn inclusive "2006-01-02" dates, e.g. for seasonal specials. Empty
+// fields do not restrict the window.
+type MenuSchedule struct {
+	ID         int    `json:"id"`
+	MenuItemId int    `json:"menu_item_id,omitempty"`
+	CategoryID int    `json:"category_id,omitempty"`
+	Weekdays   []int  `json:"weekdays,omitempty"`
+	DailyFrom  string `json:"daily_from,omitempty"`
+	DailyTo    string `json:"daily_to,omitempty"`
+	StartsOn   string `json:"starts_on,omitempty"`
+	EndsOn     string `json:"ends_on,omitempty"`
+}
+
+// Validate ...
+func (s *MenuSchedule) Validate() error {
+	return validation.ValidateStruct(
+		s,
+		validation.Field(&s.MenuItemId, validation.By(requiredIf(s.CategoryID == 0))),
+		validation.Field(&s.CategoryID, validation.By(emptyIf(s.MenuItemId != 0))),
+		validation.Field(&s.Weekdays, validation.Each(validation.Min(0), validation.Max(6))),
+		validation.Field(&s.DailyFrom, validation.By(requiredIf(s.DailyTo != "")), validation.Date("15:04")),
+		validation.Field(&s.DailyTo, validation.By(requiredIf(s.DailyFrom != "")), validation.Date("15:04")),
+		validation.Field(&s.StartsOn, validation.Date(dateLayout)),
+		validation.Field(&s.EndsOn, validation.Date(dateLayout), validation.By(func(interface{}) error {
+			if s.StartsOn != "" && s.EndsOn != "" && s.EndsOn < s.StartsOn {
+				return errors.New("must not be before starts_on")
+			}
+
+			return nil
+		})),
+	)
+}
+
+// IsOpenOn reports whether the schedule applies on the day of t, whatever
+// its daily hours.
+func (s *MenuSchedule) IsOpenOn(t time.Time) bool {
+	date := t.Format(dateLayout)
+	if s.StartsOn != "" && date < s.StartsOn {
+		return false
+	}
+
+	if s.EndsOn != "" && date > s.EndsOn {
+		return false
+	}
+
+	if len(s.Weekdays) == 0 {
+		return true
+	}
+
+	for _, d := range s.Weekdays {
+		if time.Weekday(d) == t.Weekday() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsOpenAt reports whether the schedule is open at t. The part of a daily
+// window that wraps past midnight belongs to the day the window started on.
+func (s *MenuSchedule) IsOpenAt(t time.Time) bool {
+	if s.DailyFrom == "" {
+		return s.IsOpenOn(t)
+	}
+
+	if !withinDailyHours(t, s.DailyFrom, s.DailyTo) {
+		return false
+	}
+
+	if s.DailyFrom > s.DailyTo && t.Format("15:04") < s.DailyTo {
+		return s.IsOpenOn(t.AddDate(0, 0, -1))
+	}
+
+	return s.IsOpenOn(t)
+}
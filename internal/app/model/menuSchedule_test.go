@@ -0,0 +1,89 @@
+package model_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yeboka/final-project/internal/app/model"
+	"testing"
+	"time"
+)
+
+// at returns the given time in June 2024; the 3rd is a Monday.
+func at(day int, hour int, min int) time.Time {
+	return time.Date(2024, 6, day, hour, min, 0, 0, time.Local)
+}
+
+func TestMenuSchedule_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		s     *model.MenuSchedule
+		valid bool
+	}{
+		{"menu item", &model.MenuSchedule{MenuItemId: 1, DailyFrom: "08:00", DailyTo: "11:00"}, true},
+		{"category", &model.MenuSchedule{CategoryID: 1, Weekdays: []int{0, 6}}, true},
+		{"neither", &model.MenuSchedule{DailyFrom: "08:00", DailyTo: "11:00"}, false},
+		{"both", &model.MenuSchedule{MenuItemId: 1, CategoryID: 1}, false},
+		{"bad weekday", &model.MenuSchedule{MenuItemId: 1, Weekdays: []int{7}}, false},
+		{"from without to", &model.MenuSchedule{MenuItemId: 1, DailyFrom: "08:00"}, false},
+		{"bad clock", &model.MenuSchedule{MenuItemId: 1, DailyFrom: "8am", DailyTo: "11:00"}, false},
+		{"ends before start", &model.MenuSchedule{MenuItemId: 1, StartsOn: "2024-06-10", EndsOn: "2024-06-09"}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.valid {
+				assert.NoError(t, tc.s.Validate())
+			} else {
+				assert.Error(t, tc.s.Validate())
+			}
+		})
+	}
+}
+
+func TestMenuSchedule_IsOpenAt(t *testing.T) {
+	breakfast := &model.MenuSchedule{MenuItemId: 1, DailyFrom: "08:00", DailyTo: "11:00"}
+	assert.False(t, breakfast.IsOpenAt(at(3, 7, 59)))
+	assert.True(t, breakfast.IsOpenAt(at(3, 8, 0)))
+	assert.True(t, breakfast.IsOpenAt(at(3, 10, 59)))
+	assert.False(t, breakfast.IsOpenAt(at(3, 11, 0)))
+
+	weekends := &model.MenuSchedule{CategoryID: 1, Weekdays: []int{int(time.Saturday), int(time.Sunday)}}
+	assert.False(t, weekends.IsOpenAt(at(7, 12, 0)))
+	assert.True(t, weekends.IsOpenAt(at(8, 0, 0)))
+	assert.True(t, weekends.IsOpenAt(at(9, 23, 59)))
+
+	season := &model.MenuSchedule{MenuItemId: 1, StartsOn: "2024-06-04", EndsOn: "2024-06-05"}
+	assert.False(t, season.IsOpenAt(at(3, 23, 59)))
+	assert.True(t, season.IsOpenAt(at(4, 0, 0)))
+	assert.True(t, season.IsOpenAt(at(5, 23, 59)))
+	assert.False(t, season.IsOpenAt(at(6, 0, 0)))
+}
+
+func TestMenuSchedule_IsOpenAt_WrapsPastMidnight(t *testing.T) {
+	// Friday and Saturday late nights, 22:00 to 02:00.
+	lateNight := &model.MenuSchedule{
+		MenuItemId: 1,
+		Weekdays:   []int{int(time.Friday), int(time.Saturday)},
+		DailyFrom:  "22:00",
+		DailyTo:    "02:00",
+	}
+
+	for _, tc := range []struct {
+		at   time.Time
+		open bool
+	}{
+		{at(6, 23, 0), false}, // Thursday evening
+		{at(7, 1, 0), false},  // Thursday's window, on Friday morning
+		{at(7, 21, 59), false},
+		{at(7, 22, 0), true},
+		{at(8, 1, 59), true}, // Friday's window, on Saturday morning
+		{at(8, 2, 0), false},
+		{at(8, 23, 30), true},
+		{at(9, 0, 30), true}, // Saturday's window, on Sunday morning
+		{at(9, 22, 30), false},
+	} {
+		assert.Equal(t, tc.open, lateNight.IsOpenAt(tc.at), tc.at.Format("Mon 15:04"))
+	}
+
+	// The window of the last day of a season still runs past midnight.
+	lastNight := &model.MenuSchedule{MenuItemId: 1, EndsOn: "2024-06-05", DailyFrom: "22:00", DailyTo: "02:00"}
+	assert.True(t, lastNight.IsOpenAt(at(6, 1, 0)))
+	assert.False(t, lastNight.IsOpenAt(at(6, 22, 0)))
+}
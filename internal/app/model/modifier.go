@@ -0,0 +1,55 @@
+package model
+
+import validation "github.com/go-ozzo/ozzo-validation"
+
+// ModifierGroup is a set of options offered with a menu item, such as a
+// size choice (single-select) or extras (multi-select).
+type ModifierGroup struct {
+	ID         int         `json:"id"`
+	MenuItemId int         `json:"menu_item_id"`
+	Name       string      `json:"name"`
+	MinSelect  int         `json:"min_select"`
+	MaxSelect  int         `json:"max_select"`
+	Modifiers  []*Modifier `json:"modifiers"`
+}
+
+// Modifier is a single option of a group and its price change.
+type Modifier struct {
+	ID         int    `json:"id"`
+	GroupId    int    `json:"group_id"`
+	Name       string `json:"name"`
+	PriceDelta int    `json:"price_delta"`
+}
+
+// OrderItemModifier is an option chosen on an order line, with the name and
+// price it had at the time of purchase.
+type OrderItemModifier struct {
+	ID          int    `json:"id"`
+	OrderItemId int    `json:"order_item_id"`
+	ModifierId  int    `json:"modifier_id"`
+	Name        string `json:"name"`
+	PriceDelta  int    `json:"price_delta"`
+}
+
+// Validate ...
+func (g *ModifierGroup) Validate() error {
+	return validation.ValidateStruct(
+		g,
+		validation.Field(&g.Name, validation.Required, validation.Length(1, 45)),
+		validation.Field(&g.MinSelect, validation.Min(0)),
+		validation.Field(&g.MaxSelect, validation.Required, validation.Min(g.MinSelect)),
+	)
+}
+
+// IsSingleSelect ...
+func (g *ModifierGroup) IsSingleSelect() bool {
+	return g.MaxSelect == 1
+}
+
+// Validate ...
+func (m *Modifier) Validate() error {
+	return validation.ValidateStruct(
+		m,
+		validation.Field(&m.Name, validation.Required, validation.Length(1, 45)),
+	)
+}
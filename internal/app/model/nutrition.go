@@ -0,0 +1,94 @@
+package model
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"math"
+)
+
+// Nutrition holds the nutrition facts of one portion: energy in kcal and
+// protein, carbohydrates, fat and salt in grams.
+type Nutrition struct {
+	Kcal    int     `json:"kcal"`
+	Protein float64 `json:"protein"`
+	Carbs   float64 `json:"carbs"`
+	Fat     float64 `json:"fat"`
+	Salt    float64 `json:"salt"`
+}
+
+// Validate ...
+func (n *Nutrition) Validate() error {
+	return validation.ValidateStruct(
+		n,
+		validation.Field(&n.Kcal, validation.Min(0)),
+		validation.Field(&n.Protein, validation.Min(0.0)),
+		validation.Field(&n.Carbs, validation.Min(0.0)),
+		validation.Field(&n.Fat, validation.Min(0.0)),
+		validation.Field(&n.Salt, validation.Min(0.0)),
+	)
+}
+
+// add adds times portions of other. Grams are rounded to hundredths so that
+// sums do not pick up floating point noise.
+func (n *Nutrition) add(other *Nutrition, times int) {
+	round := func(v float64) float64 {
+		return math.Round(v*100) / 100
+	}
+
+	n.Kcal += other.Kcal * times
+	n.Protein = round(n.Protein + other.Protein*float64(times))
+	n.Carbs = round(n.Carbs + other.Carbs*float64(times))
+	n.Fat = round(n.Fat + other.Fat*float64(times))
+	n.Salt = round(n.Salt + other.Salt*float64(times))
+}
+
+// SumNutrition returns the nutrition of one portion of each of the menu
+// items, or nil when any of them has no nutrition facts.
+func SumNutrition(items []*MenuItem) *Nutrition {
+	sum := &Nutrition{}
+	for _, mi := range items {
+		if mi.Nutrition == nil {
+			return nil
+		}
+
+		sum.add(mi.Nutrition, 1)
+	}
+
+	return sum
+}
+
+// NutritionTotal is the nutrition of a number of portions. Portions without
+// nutrition facts cannot be counted; UnknownPortions says how many of them
+// were left out, so that a total of zero is not mistaken for a complete one.
+type NutritionTotal struct {
+	Nutrition
+	Portions        int `json:"portions"`
+	UnknownPortions int `json:"unknown_portions"`
+}
+
+// AddItems adds the portions of the order items.
+func (t *NutritionTotal) AddItems(items []*OrderItem) {
+	for _, item := range items {
+		t.Portions += item.Quantity
+		if item.Nutrition == nil {
+			t.UnknownPortions += item.Quantity
+			continue
+		}
+
+		t.add(item.Nutrition, item.Quantity)
+	}
+}
+
+// Add adds another total.
+func (t *NutritionTotal) Add(other *NutritionTotal) {
+	t.add(&other.Nutrition, 1)
+	t.Portions += other.Portions
+	t.UnknownPortions += other.UnknownPortions
+}
+
+// OrderNutrition sums the nutrition of the order items.
+func OrderNutrition(items []*OrderItem) *NutritionTotal {
+	t := &NutritionTotal{}
+	t.AddItems(items)
+
+	return t
+}
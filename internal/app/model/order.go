@@ -2,10 +2,109 @@ package model
 
 import "time"
 
-// Order ...
+// Order statuses. An order starts as placed and moves forward through the
+// kitchen until it is picked up, or ends early as cancelled or rejected.
+const (
+	OrderStatusPlaced    = "placed"
+	OrderStatusAccepted  = "accepted"
+	OrderStatusPreparing = "preparing"
+	OrderStatusReady     = "ready"
+	OrderStatusPickedUp  = "picked_up"
+	OrderStatusCancelled = "cancelled"
+	OrderStatusRejected  = "rejected"
+)
+
+// Payment methods.
+const (
+	PaymentMethodWallet = "wallet"
+	PaymentMethodCash   = "cash"
+	PaymentMethodCard   = "card"
+)
+
+// Payment statuses. They mirror the statuses reported by payment providers,
+// plus refund_pending for a card refund that is recorded but not yet
+// accepted by the provider.
+const (
+	PaymentStatusPending       = "pending"
+	PaymentStatusAuthorized    = "authorized"
+	PaymentStatusCaptured      = "captured"
+	PaymentStatusRefundPending = "refund_pending"
+	PaymentStatusRefunded      = "refunded"
+	PaymentStatusFailed        = "failed"
+)
+
+var orderTransitions = map[string][]string{
+	OrderStatusPlaced:    {OrderStatusAccepted, OrderStatusCancelled, OrderStatusRejected},
+	OrderStatusAccepted:  {OrderStatusPreparing, OrderStatusCancelled},
+	OrderStatusPreparing: {OrderStatusReady, OrderStatusCancelled},
+	OrderStatusReady:     {OrderStatusPickedUp},
+}
+
+// Order ... Items holds the order lines only when the order was loaded by an
+// order listing; elsewhere they are read through the order item repository.
 type Order struct {
-	ID          int       `json:"id"`
-	UserId      int       `json:"user_id"`
-	CreatedAt   time.Time `json:"-"`
-	TotalAmount int       `json:"-"`
+	ID            int       `json:"id"`
+	UserId        int       `json:"user_id"`
+	CreatedAt     time.Time `json:"-"`
+	TotalAmount   int       `json:"-"`
+	Status        string    `json:"status"`
+	PaymentMethod string    `json:"payment_method"`
+	PaymentStatus string    `json:"payment_status"`
+	PaymentRef    string    `json:"-"`
+	PickupSlotId  *int      `json:"pickup_slot_id,omitempty"`
+
+	CouponCode     string           `json:"coupon_code,omitempty"`
+	DiscountAmount int              `json:"-"`
+	Discounts      []*OrderDiscount `json:"discounts,omitempty"`
+
+	Items []*OrderItem `json:"items,omitempty"`
+}
+
+// CanTransitionTo reports whether the order may move to the given status.
+func (o *Order) CanTransitionTo(status string) bool {
+	for _, next := range orderTransitions[o.Status] {
+		if next == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsEditable reports whether the customer may still change or delete the order.
+func (o *Order) IsEditable() bool {
+	return o.Status == OrderStatusPlaced
+}
+
+// IsFinished reports whether the order has reached a final status.
+func (o *Order) IsFinished() bool {
+	return o.Status == OrderStatusPickedUp || o.Status == OrderStatusCancelled || o.Status == OrderStatusRejected
+}
+
+// IsReleasedToKitchen reports whether the kitchen may start on the order:
+// card payments must be captured first, wallet and cash orders go straight in.
+func (o *Order) IsReleasedToKitchen() bool {
+	return o.PaymentMethod != PaymentMethodCard || o.PaymentStatus == PaymentStatusCaptured
+}
+
+// IsRefunded reports whether the payment was given back or is on its way
+// back to the customer.
+func (o *Order) IsRefunded() bool {
+	return o.PaymentStatus == PaymentStatusRefunded || o.PaymentStatus == PaymentStatusRefundPending
+}
+
+// IsValidPaymentMethod ...
+func IsValidPaymentMethod(method string) bool {
+	return method == PaymentMethodWallet || method == PaymentMethodCash || method == PaymentMethodCard
+}
+
+// IsValidOrderStatus ...
+func IsValidOrderStatus(status string) bool {
+	switch status {
+	case OrderStatusPlaced, OrderStatusAccepted, OrderStatusPreparing, OrderStatusReady,
+		OrderStatusPickedUp, OrderStatusCancelled, OrderStatusRejected:
+		return true
+	}
+
+	return false
 }
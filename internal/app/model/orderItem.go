@@ -1,9 +1,58 @@
 package model
 
-// OrderItem ...
+// OrderItem is a line of an order, either a single menu item or a combo. The
+// item name and prices are snapshots taken when the order was placed, so they
+// stay correct after the menu is changed. MenuItemId and ComboId are zero
+// once the referenced item has been deleted. Nutrition is the snapshot of one
+// portion, nil when the menu did not have nutrition facts for it.
 type OrderItem struct {
-	ID         int `json:"id"`
-	OrderId    int `json:"order_id"`
-	MenuItemId int `json:"menu_item_id"`
-	Quantity   int `json:"quantity"`
+	ID         int    `json:"id"`
+	OrderId    int    `json:"order_id"`
+	MenuItemId int    `json:"menu_item_id,omitempty"`
+	ComboId    int    `json:"combo_id,omitempty"`
+	ItemName   string `json:"item_name"`
+	Quantity   int    `json:"quantity"`
+	UnitPrice  int    `json:"unit_price"`
+	LineTotal  int    `json:"line_total"`
+
+	Modifiers  []*OrderItemModifier  `json:"modifiers,omitempty"`
+	Components []*OrderItemComponent `json:"components,omitempty"`
+	Nutrition  *Nutrition            `json:"nutrition,omitempty"`
+}
+
+// IsCombo ...
+func (i *OrderItem) IsCombo() bool {
+	return len(i.Components) > 0
+}
+
+// DishIds returns the menu items the kitchen prepares for one portion of the
+// line: the picked components of a combo, or the item itself. Deleted menu
+// items are skipped.
+func (i *OrderItem) DishIds() []int {
+	var ids []int
+	if i.IsCombo() {
+		for _, c := range i.Components {
+			if c.MenuItemId != 0 {
+				ids = append(ids, c.MenuItemId)
+			}
+		}
+
+		return ids
+	}
+
+	if i.MenuItemId != 0 {
+		ids = append(ids, i.MenuItemId)
+	}
+
+	return ids
+}
+
+// OrderTotal sums the line totals of the given order items.
+func OrderTotal(items []*OrderItem) int {
+	var total int
+	for _, item := range items {
+		total += item.LineTotal
+	}
+
+	return total
 }
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// OrderStatusChange is a history row recording who moved an order between statuses.
+type OrderStatusChange struct {
+	ID         int       `json:"id"`
+	OrderId    int       `json:"order_id"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  int       `json:"changed_by"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
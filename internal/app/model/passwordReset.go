@@ -0,0 +1,19 @@
+package model
+
+import "time"
+
+// PasswordReset is a single-use token that lets a user who forgot their
+// password set a new one. Only the hash of the token is kept.
+type PasswordReset struct {
+	ID        int
+	UserId    int
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// IsActiveAt reports whether the token can still be used at now.
+func (r *PasswordReset) IsActiveAt(now time.Time) bool {
+	return r.UsedAt == nil && now.Before(r.ExpiresAt)
+}
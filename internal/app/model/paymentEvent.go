@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// PaymentEvent records a provider webhook that has been applied, so that a
+// redelivered or replayed event is applied only once.
+type PaymentEvent struct {
+	ID         int       `json:"id"`
+	EventId    string    `json:"event_id"`
+	Type       string    `json:"type"`
+	Ref        string    `json:"ref"`
+	ReceivedAt time.Time `json:"received_at"`
+}
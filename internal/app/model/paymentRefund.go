@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Card refund statuses. A refund is pending until the payment provider has
+// been asked for it; processing while a request is in flight; done once the
+// provider has accepted it.
+const (
+	RefundStatusPending    = "pending"
+	RefundStatusProcessing = "processing"
+	RefundStatusDone       = "done"
+)
+
+// PaymentRefund is a card refund owed to a customer. It is recorded in the
+// same transaction that cancels or refunds the order and is sent to the
+// payment provider after the commit, so a failed request can be retried.
+type PaymentRefund struct {
+	ID         int        `json:"id"`
+	OrderId    *int       `json:"order_id,omitempty"`
+	PaymentRef string     `json:"payment_ref"`
+	Amount     int        `json:"amount"`
+	Status     string     `json:"status"`
+	Attempts   int        `json:"attempts"`
+	LastError  string     `json:"last_error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	SettledAt  *time.Time `json:"settled_at,omitempty"`
+}
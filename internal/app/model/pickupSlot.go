@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// PickupSlot is a window in which customers collect their orders. Capacity
+// is limited both by the number of orders and the number of items.
+type PickupSlot struct {
+	ID             int       `json:"id"`
+	StartsAt       time.Time `json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	MaxOrders      int       `json:"max_orders"`
+	MaxItems       int       `json:"max_items"`
+	ReservedOrders int       `json:"reserved_orders"`
+	ReservedItems  int       `json:"reserved_items"`
+}
+
+// RemainingOrders ...
+func (p *PickupSlot) RemainingOrders() int {
+	return p.MaxOrders - p.ReservedOrders
+}
+
+// RemainingItems ...
+func (p *PickupSlot) RemainingItems() int {
+	return p.MaxItems - p.ReservedItems
+}
+
+// CanFit reports whether one more order with the given number of items fits.
+func (p *PickupSlot) CanFit(items int) bool {
+	return p.RemainingOrders() >= 1 && p.RemainingItems() >= items
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	validation "github.com/go-ozzo/ozzo-validation"
+	"time"
+)
+
+// Promotion kinds.
+const (
+	// PromotionPercentage takes Percent off the order, or off the lines of
+	// CategoryID when it is set.
+	PromotionPercentage = "percentage"
+	// PromotionFixed takes Amount off the order.
+	PromotionFixed = "fixed"
+	// PromotionFreeItem makes one portion of MenuItemId free.
+	PromotionFreeItem = "free_item"
+	// PromotionBuyXGetY makes FreeQuantity of every BuyQuantity+FreeQuantity
+	// portions of MenuItemId, or of the items of CategoryID, free.
+	PromotionBuyXGetY = "buy_x_get_y"
+)
+
+// Promotion is a discount rule. Promotions with a code are coupons the
+// customer enters at checkout; promotions without one apply automatically.
+type Promotion struct {
+	ID             int        `json:"id"`
+	Name           string     `json:"name"`
+	Code           string     `json:"code,omitempty"`
+	Kind           string     `json:"kind"`
+	Percent        int        `json:"percent,omitempty"`
+	Amount         int        `json:"amount,omitempty"`
+	MenuItemId     int        `json:"menu_item_id,omitempty"`
+	CategoryID     int        `json:"category_id,omitempty"`
+	BuyQuantity    int        `json:"buy_quantity,omitempty"`
+	FreeQuantity   int        `json:"free_quantity,omitempty"`
+	StartsAt       *time.Time `json:"starts_at,omitempty"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+	DailyFrom      string     `json:"daily_from,omitempty"`
+	DailyTo        string     `json:"daily_to,omitempty"`
+	MaxUses        int        `json:"max_uses,omitempty"`
+	MaxUsesPerUser int        `json:"max_uses_per_user,omitempty"`
+}
+
+// OrderDiscount is a promotion applied to an order, with the name, code and
+// amount it had at the time of purchase. PromotionId is zero once the
+// promotion has been deleted.
+type OrderDiscount struct {
+	ID          int       `json:"id"`
+	OrderId     int       `json:"order_id"`
+	PromotionId int       `json:"promotion_id,omitempty"`
+	UserId      int       `json:"-"`
+	Name        string    `json:"name"`
+	Code        string    `json:"code,omitempty"`
+	Amount      int       `json:"amount"`
+	CreatedAt   time.Time `json:"-"`
+}
+
+// Validate ...
+func (p *Promotion) Validate() error {
+	return validation.ValidateStruct(
+		p,
+		validation.Field(&p.Name, validation.Required, validation.Length(1, 45)),
+		validation.Field(&p.Code, validation.Length(1, 32)),
+		validation.Field(&p.Kind, validation.Required, validation.In(
+			PromotionPercentage, PromotionFixed, PromotionFreeItem, PromotionBuyXGetY,
+		)),
+		validation.Field(&p.Percent, validation.By(requiredIf(p.Kind == PromotionPercentage)), validation.Min(0), validation.Max(100)),
+		validation.Field(&p.Amount, validation.By(requiredIf(p.Kind == PromotionFixed)), validation.Min(0)),
+		validation.Field(&p.MenuItemId, validation.By(requiredIf(
+			p.Kind == PromotionFreeItem || (p.Kind == PromotionBuyXGetY && p.CategoryID == 0),
+		))),
+		validation.Field(&p.BuyQuantity, validation.By(requiredIf(p.Kind == PromotionBuyXGetY)), validation.Min(0)),
+		validation.Field(&p.FreeQuantity, validation.By(requiredIf(p.Kind == PromotionBuyXGetY)), validation.Min(0)),
+		validation.Field(&p.DailyFrom, validation.By(requiredIf(p.DailyTo != "")), validation.Date("15:04")),
+		validation.Field(&p.DailyTo, validation.By(requiredIf(p.DailyFrom != "")), validation.Date("15:04")),
+		validation.Field(&p.MaxUses, validation.Min(0)),
+		validation.Field(&p.MaxUsesPerUser, validation.Min(0)),
+	)
+}
+
+// IsCoupon ...
+func (p *Promotion) IsCoupon() bool {
+	return p.Code != ""
+}
+
+// IsActiveAt reports whether the promotion may be applied at t: within its
+// validity window and, for time-of-day promotions such as happy hours,
+// within its daily hours.
+func (p *Promotion) IsActiveAt(t time.Time) bool {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+
+	if p.EndsAt != nil && !t.Before(*p.EndsAt) {
+		return false
+	}
+
+	return p.DailyFrom == "" || withinDailyHours(t, p.DailyFrom, p.DailyTo)
+}
+
+// withinDailyHours reports whether the clock time of t falls in the daily
+// window [from, to), both given as "15:04". Windows may wrap past midnight.
+func withinDailyHours(t time.Time, from string, to string) bool {
+	clock := t.Format("15:04")
+	if from <= to {
+		return clock >= from && clock < to
+	}
+
+	return clock >= from || clock < to
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// RefreshToken is a stored refresh token of a session. Only the hash of the
+// token is kept. Every refresh replaces the token with a new one; presenting
+// a revoked token again revokes the whole session, as it means the token was
+// stolen or replayed.
+type RefreshToken struct {
+	ID        int
+	UserId    int
+	SessionId int
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	RevokedAt *time.Time
+}
+
+// IsActiveAt reports whether the token can still be used at t.
+func (t *RefreshToken) IsActiveAt(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,63 @@
+package model
+
+import "time"
+
+// Reports only count sales: orders that were picked up and not refunded.
+// Amounts are in the same unit as menu prices. Discounts apply to whole
+// orders, so the per item and per category revenues are gross, summed from
+// line prices, while the revenue of periods, order values and hours is net,
+// what was actually charged.
+
+// RevenuePeriod is the revenue of one day, week or month. Subtotal is what
+// the order lines came to before discounts, Revenue what was charged.
+type RevenuePeriod struct {
+	PeriodStart time.Time `json:"period_start"`
+	Orders      int       `json:"orders"`
+	Subtotal    int       `json:"subtotal"`
+	Discounts   int       `json:"discounts"`
+	Revenue     int       `json:"revenue"`
+}
+
+// ItemSales is how often a menu item was sold. Quantity counts the portions
+// sold on their own and ComboQuantity those sold as part of a combo. Revenue
+// only covers the portions sold on their own, as a combo has a single price.
+type ItemSales struct {
+	MenuItemId    int    `json:"menu_item_id"`
+	Name          string `json:"name"`
+	CategoryID    int    `json:"category_id,omitempty"`
+	Quantity      int    `json:"quantity"`
+	ComboQuantity int    `json:"combo_quantity"`
+	Revenue       int    `json:"revenue"`
+}
+
+// Total is the number of portions sold, alone or in combos.
+func (s *ItemSales) Total() int {
+	return s.Quantity + s.ComboQuantity
+}
+
+// CategorySales is what the menu items and combos of a category and all of
+// its subcategories sold, before order discounts, so its Revenue
+// compares to the Subtotal of RevenuePeriod. ParentID is -1 for top-level
+// categories, as in Category.
+type CategorySales struct {
+	CategoryID int    `json:"category_id"`
+	ParentID   int    `json:"parent_id"`
+	Name       string `json:"name"`
+	Quantity   int    `json:"quantity"`
+	Revenue    int    `json:"revenue"`
+}
+
+// OrderValue is the average revenue per order.
+type OrderValue struct {
+	Orders  int `json:"orders"`
+	Revenue int `json:"revenue"`
+	Average int `json:"average"`
+}
+
+// HourSales is the sales of an hour of the day, summed over every day of the
+// report.
+type HourSales struct {
+	Hour    int `json:"hour"`
+	Orders  int `json:"orders"`
+	Revenue int `json:"revenue"`
+}
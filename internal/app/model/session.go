@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Session is a login of a user on one device. The session cookie holds a
+// random token of which only the hash is stored; access and refresh tokens
+// are bound to the session, so revoking it logs the device out for good.
+type Session struct {
+	ID         int        `json:"id"`
+	UserId     int        `json:"user_id"`
+	TokenHash  string     `json:"-"`
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+// IsActiveAt reports whether the session can still be used at now. Sessions
+// not seen for idleTimeout expire; a zero timeout keeps them forever.
+func (s *Session) IsActiveAt(now time.Time, idleTimeout time.Duration) bool {
+	if s.RevokedAt != nil {
+		return false
+	}
+
+	return idleTimeout <= 0 || now.Sub(s.LastSeenAt) < idleTimeout
+}
@@ -6,12 +6,20 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// User roles.
+const (
+	RoleUser  = "user"
+	RoleStaff = "staff"
+	RoleAdmin = "admin"
+)
+
 // User ...
 type User struct {
 	ID                int    `json:"id"`
 	Email             string `json:"email"`
 	Username          string `json:"username"`
 	Role              string `json:"role"`
+	EmailVerified     bool   `json:"email_verified"`
 	Password          string `json:"password,omitempty"`
 	EncryptedPassword string `json:"-"`
 }
@@ -22,11 +30,25 @@ func (u *User) Validate() error {
 		u,
 		validation.Field(&u.Email, validation.Required, is.Email),
 		validation.Field(&u.Username, validation.NilOrNotEmpty, validation.Length(1, 32)),
-		validation.Field(&u.Role, validation.In("user", "admin")),
+		validation.Field(&u.Role, validation.In(RoleUser, RoleStaff, RoleAdmin)),
 		validation.Field(&u.Password, validation.By(requiredIf(u.EncryptedPassword == "")), validation.Length(6, 32)),
 	)
 }
 
+// ValidatePassword checks a new password of an existing user. Unlike
+// Validate it always requires one.
+func (u *User) ValidatePassword() error {
+	return validation.ValidateStruct(
+		u,
+		validation.Field(&u.Password, validation.Required, validation.Length(6, 32)),
+	)
+}
+
+// IsStaff reports whether the user may work the kitchen side of the canteen.
+func (u *User) IsStaff() bool {
+	return u.Role == RoleStaff || u.Role == RoleAdmin
+}
+
 // Sanitize ...
 func (u *User) Sanitize() {
 	u.Password = ""
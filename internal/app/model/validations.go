@@ -1,6 +1,9 @@
 package model
 
-import validation "github.com/go-ozzo/ozzo-validation"
+import (
+	"errors"
+	validation "github.com/go-ozzo/ozzo-validation"
+)
 
 func requiredIf(cond bool) validation.RuleFunc {
 	return func(value interface{}) error {
@@ -11,3 +14,23 @@ func requiredIf(cond bool) validation.RuleFunc {
 		return nil
 	}
 }
+
+// stringsIn turns values into the arguments of validation.In.
+func stringsIn(values []string) []interface{} {
+	in := make([]interface{}, len(values))
+	for i, v := range values {
+		in[i] = v
+	}
+
+	return in
+}
+
+func emptyIf(cond bool) validation.RuleFunc {
+	return func(value interface{}) error {
+		if cond && !validation.IsEmpty(value) {
+			return errors.New("must be blank")
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Wallet ledger entry kinds.
+const (
+	WalletEntryTopUp       = "top_up"
+	WalletEntryOrderCharge = "order_charge"
+	WalletEntryRefund      = "refund"
+	WalletEntryAdjustment  = "adjustment"
+)
+
+// WalletEntry is a row of the append-only wallet ledger. Credits are
+// positive amounts and debits negative; a user's balance is their sum.
+type WalletEntry struct {
+	ID        int       `json:"id"`
+	UserId    int       `json:"user_id"`
+	Kind      string    `json:"kind"`
+	Amount    int       `json:"amount"`
+	OrderId   *int      `json:"order_id,omitempty"`
+	CreatedBy int       `json:"created_by"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
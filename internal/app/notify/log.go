@@ -0,0 +1,42 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// LogNotifier writes every message to a writer instead of delivering it,
+// so that developers can pick reset links out of a file or the console.
+type LogNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewLogNotifier ...
+func NewLogNotifier(w io.Writer) *LogNotifier {
+	return &LogNotifier{w: w}
+}
+
+// Name ...
+func (n *LogNotifier) Name() string {
+	return "log"
+}
+
+// Send ...
+func (n *LogNotifier) Send(ctx context.Context, m *Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	_, err := fmt.Fprintf(
+		n.w,
+		"--- %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339),
+		m.To,
+		m.Subject,
+		m.Body,
+	)
+	return err
+}
@@ -0,0 +1,23 @@
+// Package notify defines the interface through which the application sends
+// messages to users, such as password reset and email verification links.
+// It ships an SMTP notifier, a notifier that writes messages to a log for
+// local development and an in-memory outbox for tests.
+package notify
+
+import "context"
+
+// Message is a message for a user, addressed to their email address.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users.
+type Notifier interface {
+	// Name identifies the notifier in logs and configuration.
+	Name() string
+	// Send delivers the message. It returns once the message is handed
+	// over; delivery itself may happen later.
+	Send(ctx context.Context, m *Message) error
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"sync"
+)
+
+// Outbox keeps every message in memory instead of delivering it, for tests
+// that need to read the messages a request sent.
+type Outbox struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+// NewOutbox ...
+func NewOutbox() *Outbox {
+	return &Outbox{}
+}
+
+// Name ...
+func (o *Outbox) Name() string {
+	return "outbox"
+}
+
+// Send ...
+func (o *Outbox) Send(ctx context.Context, m *Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	sent := *m
+	o.messages = append(o.messages, &sent)
+
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (o *Outbox) Messages() []*Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	messages := make([]*Message, len(o.messages))
+	for i, m := range o.messages {
+		sent := *m
+		messages[i] = &sent
+	}
+
+	return messages
+}
+
+// Last returns the last message sent to the address, or nil.
+func (o *Outbox) Last(to string) *Message {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if o.messages[i].To == to {
+			sent := *o.messages[i]
+			return &sent
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+)
+
+// SMTPNotifier delivers messages as plain text emails through an SMTP
+// server. It authenticates with PLAIN auth when a username is set.
+type SMTPNotifier struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPNotifier ...
+func NewSMTPNotifier(host string, port int, username string, password string, from string) *SMTPNotifier {
+	n := &SMTPNotifier{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+	}
+
+	if username != "" {
+		n.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return n
+}
+
+// Name ...
+func (n *SMTPNotifier) Name() string {
+	return "smtp"
+}
+
+// Send ...
+func (n *SMTPNotifier) Send(ctx context.Context, m *Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return smtp.SendMail(n.addr, n.auth, n.from, []string{m.To}, n.compose(m))
+}
+
+// compose renders the message with the headers mail clients expect.
+func (n *SMTPNotifier) compose(m *Message) []byte {
+	var b strings.Builder
+
+	b.WriteString("From: " + n.from + "\r\n")
+	b.WriteString("To: " + m.To + "\r\n")
+	b.WriteString("Subject: " + m.Subject + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return []byte(b.String())
+}
@@ -0,0 +1,153 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+type fakePayment struct {
+	amount   int
+	captured int
+	refunded int
+	status   string
+}
+
+// FakeProvider is an in-memory gateway that approves every payment up to
+// DeclineAbove (when set). Webhooks are signed with HMAC-SHA256 over the raw
+// body using the shared secret; Sign produces valid signatures for demos.
+type FakeProvider struct {
+	DeclineAbove int
+
+	mu       sync.Mutex
+	secret   []byte
+	nextID   int
+	payments map[string]*fakePayment
+}
+
+// NewFakeProvider ...
+func NewFakeProvider(secret string) (*FakeProvider, error) {
+	if secret == "" {
+		return nil, ErrNoSecret
+	}
+
+	return &FakeProvider{
+		secret:   []byte(secret),
+		payments: make(map[string]*fakePayment),
+	}, nil
+}
+
+// Name ...
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+// Authorize ...
+func (p *FakeProvider) Authorize(ctx context.Context, req *Request) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.DeclineAbove > 0 && req.Amount > p.DeclineAbove {
+		return nil, ErrDeclined
+	}
+
+	p.nextID++
+	ref := fmt.Sprintf("fake_%d_%d", req.OrderId, p.nextID)
+	p.payments[ref] = &fakePayment{
+		amount: req.Amount,
+		status: StatusAuthorized,
+	}
+
+	return &Result{Ref: ref, Status: StatusAuthorized}, nil
+}
+
+// Capture ...
+func (p *FakeProvider) Capture(ctx context.Context, ref string, amount int) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fp, ok := p.payments[ref]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+
+	if fp.status != StatusAuthorized || amount > fp.amount {
+		return nil, ErrDeclined
+	}
+
+	fp.captured = amount
+	fp.status = StatusCaptured
+
+	return &Result{Ref: ref, Status: fp.status}, nil
+}
+
+// Void ...
+func (p *FakeProvider) Void(ctx context.Context, ref string) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fp, ok := p.payments[ref]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+
+	if fp.status != StatusAuthorized {
+		return nil, ErrDeclined
+	}
+
+	fp.status = StatusVoided
+
+	return &Result{Ref: ref, Status: fp.status}, nil
+}
+
+// Refund ...
+func (p *FakeProvider) Refund(ctx context.Context, ref string, amount int) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	fp, ok := p.payments[ref]
+	if !ok {
+		return nil, ErrUnknownPayment
+	}
+
+	if fp.refunded+amount > fp.captured {
+		return nil, ErrDeclined
+	}
+
+	fp.refunded += amount
+	if fp.refunded == fp.captured {
+		fp.status = StatusRefunded
+	}
+
+	return &Result{Ref: ref, Status: fp.status}, nil
+}
+
+// VerifyWebhook ...
+func (p *FakeProvider) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	expected, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, p.mac(payload)) {
+		return nil, ErrInvalidSignature
+	}
+
+	e := &WebhookEvent{}
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Sign returns the signature the fake gateway would send with payload.
+func (p *FakeProvider) Sign(payload []byte) string {
+	return hex.EncodeToString(p.mac(payload))
+}
+
+func (p *FakeProvider) mac(payload []byte) []byte {
+	h := hmac.New(sha256.New, p.secret)
+	h.Write(payload)
+	return h.Sum(nil)
+}
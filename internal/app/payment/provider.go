@@ -0,0 +1,70 @@
+// Package payment defines the interface card payment gateways implement and
+// ships a fake gateway for local development, tests and demos.
+package payment
+
+import (
+	"context"
+	"errors"
+)
+
+// Payment statuses reported by providers.
+const (
+	StatusPending    = "pending"
+	StatusAuthorized = "authorized"
+	StatusCaptured   = "captured"
+	StatusRefunded   = "refunded"
+	StatusVoided     = "voided"
+	StatusFailed     = "failed"
+)
+
+// Webhook event types.
+const (
+	EventCaptured = "payment.captured"
+	EventFailed   = "payment.failed"
+	EventRefunded = "payment.refunded"
+)
+
+var (
+	ErrDeclined         = errors.New("payment declined")
+	ErrUnknownPayment   = errors.New("unknown payment")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrNoSecret         = errors.New("webhook signing secret must not be empty")
+)
+
+// Request describes a payment for an order.
+type Request struct {
+	OrderId int
+	UserId  int
+	Amount  int
+}
+
+// Result is the provider's view of a payment after an operation.
+type Result struct {
+	Ref    string
+	Status string
+}
+
+// WebhookEvent is a verified notification sent by the provider.
+type WebhookEvent struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Ref    string `json:"ref"`
+	Amount int    `json:"amount"`
+}
+
+// Provider is a card payment gateway.
+type Provider interface {
+	// Name identifies the provider in logs and configuration.
+	Name() string
+	// Authorize reserves the amount on the customer's card.
+	Authorize(ctx context.Context, req *Request) (*Result, error)
+	// Capture collects a previously authorized amount. Asynchronous
+	// gateways may answer with StatusPending and confirm by webhook.
+	Capture(ctx context.Context, ref string, amount int) (*Result, error)
+	// Void releases an authorization that will not be captured.
+	Void(ctx context.Context, ref string) (*Result, error)
+	// Refund returns amount of a captured payment to the customer.
+	Refund(ctx context.Context, ref string, amount int) (*Result, error)
+	// VerifyWebhook checks the signature of a callback and decodes it.
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
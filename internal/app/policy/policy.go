@@ -0,0 +1,51 @@
+// Package policy holds the authorization rules consulted by the HTTP
+// handlers before they read or mutate orders and before they mutate user
+// accounts.
+package policy
+
+import (
+	"errors"
+	"github.com/yeboka/final-project/internal/app/model"
+)
+
+var (
+	ErrForbidden        = errors.New("forbidden: not allowed to access this resource")
+	ErrEmailNotVerified = errors.New("forbidden: verify your email address before ordering")
+)
+
+// CanViewOrder allows the owner of the order and kitchen staff.
+func CanViewOrder(u *model.User, o *model.Order) error {
+	if u.ID == o.UserId || u.IsStaff() {
+		return nil
+	}
+
+	return ErrForbidden
+}
+
+// CanPlaceOrder allows users whose email address is verified to order.
+func CanPlaceOrder(u *model.User) error {
+	if u.EmailVerified {
+		return nil
+	}
+
+	return ErrEmailNotVerified
+}
+
+// CanModifyOrder allows the owner of the order and admins to edit or delete it.
+func CanModifyOrder(u *model.User, o *model.Order) error {
+	if u.ID == o.UserId || u.Role == model.RoleAdmin {
+		return nil
+	}
+
+	return ErrForbidden
+}
+
+// CanUpdateUser allows users to update their own account and admins to
+// update any account.
+func CanUpdateUser(u *model.User, targetID int) error {
+	if u.ID == targetID || u.Role == model.RoleAdmin {
+		return nil
+	}
+
+	return ErrForbidden
+}
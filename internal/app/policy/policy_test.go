@@ -0,0 +1,46 @@
+package policy_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/policy"
+	"testing"
+)
+
+var (
+	owner    = &model.User{ID: 1, Role: model.RoleUser}
+	stranger = &model.User{ID: 2, Role: model.RoleUser}
+	staff    = &model.User{ID: 3, Role: model.RoleStaff}
+	admin    = &model.User{ID: 4, Role: model.RoleAdmin}
+)
+
+func TestCanViewOrder(t *testing.T) {
+	o := &model.Order{ID: 10, UserId: owner.ID}
+
+	assert.NoError(t, policy.CanViewOrder(owner, o))
+	assert.NoError(t, policy.CanViewOrder(staff, o))
+	assert.NoError(t, policy.CanViewOrder(admin, o))
+	assert.ErrorIs(t, policy.CanViewOrder(stranger, o), policy.ErrForbidden)
+}
+
+func TestCanModifyOrder(t *testing.T) {
+	o := &model.Order{ID: 10, UserId: owner.ID}
+
+	assert.NoError(t, policy.CanModifyOrder(owner, o))
+	assert.NoError(t, policy.CanModifyOrder(admin, o))
+	assert.ErrorIs(t, policy.CanModifyOrder(staff, o), policy.ErrForbidden)
+	assert.ErrorIs(t, policy.CanModifyOrder(stranger, o), policy.ErrForbidden)
+}
+
+func TestCanUpdateUser(t *testing.T) {
+	assert.NoError(t, policy.CanUpdateUser(owner, owner.ID))
+	assert.NoError(t, policy.CanUpdateUser(admin, owner.ID))
+	assert.ErrorIs(t, policy.CanUpdateUser(staff, owner.ID), policy.ErrForbidden)
+	assert.ErrorIs(t, policy.CanUpdateUser(stranger, owner.ID), policy.ErrForbidden)
+}
+
+func TestCanPlaceOrder(t *testing.T) {
+	assert.NoError(t, policy.CanPlaceOrder(&model.User{ID: 1, EmailVerified: true}))
+	assert.ErrorIs(t, policy.CanPlaceOrder(&model.User{ID: 1}), policy.ErrEmailNotVerified)
+	assert.ErrorIs(t, policy.CanPlaceOrder(&model.User{ID: 1, Role: model.RoleAdmin}), policy.ErrEmailNotVerified)
+}
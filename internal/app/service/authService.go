@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/yeboka/final-project/internal/app/auth"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// touchInterval is how stale the last seen time of a session may get before
+// a request updates it.
+const touchInterval = time.Minute
+
+// Tokens are the credentials handed to token clients at login and on every
+// refresh. ExpiresIn is the lifetime of the access token in seconds.
+type Tokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Login is the outcome of a login: the token for the session cookie and the
+// tokens for token clients, all bound to the same session.
+type Login struct {
+	Session      *model.Session
+	SessionToken string
+	Tokens       *Tokens
+}
+
+// AuthService manages sessions and the credentials bound to them. Every
+// login starts a session stored server-side; the session cookie, access
+// tokens and refresh tokens only work while their session is active.
+// Sessions expire when they have not been used for as long as a refresh
+// token lives.
+type AuthService struct {
+	store      store.Store
+	signer     *auth.Signer
+	refreshTTL time.Duration
+}
+
+// NewAuthService ...
+func NewAuthService(st store.Store, signer *auth.Signer, refreshTTL time.Duration) *AuthService {
+	return &AuthService{
+		store:      st,
+		signer:     signer,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Login starts a session for the user on the device with the given user
+// agent and IP address.
+func (s *AuthService) Login(ctx context.Context, u *model.User, device string, ip string) (*Login, error) {
+	sessionToken, err := auth.NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	l := &Login{
+		SessionToken: sessionToken,
+		Session: &model.Session{
+			UserId:     u.ID,
+			TokenHash:  auth.HashToken(sessionToken),
+			Device:     device,
+			IP:         ip,
+			CreatedAt:  now,
+			LastSeenAt: now,
+		},
+	}
+
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.Session().Create(l.Session); err != nil {
+			return err
+		}
+
+		l.Tokens, err = s.issue(tx, u, l.Session.ID, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Authenticate returns the session of an access token.
+func (s *AuthService) Authenticate(accessToken string) (*model.Session, error) {
+	now := time.Now()
+
+	c, err := s.signer.Verify(accessToken, now)
+	if err != nil {
+		return nil, err
+	}
+
+	userId, err := c.UserId()
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := s.store.Session().Find(c.SessionId)
+	if errors.Is(err, store.ErrRecordNotFound) || (err == nil && sess.UserId != userId) {
+		return nil, ErrSessionExpired
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.use(sess, now)
+}
+
+// AuthenticateSession returns the session of a session cookie token.
+func (s *AuthService) AuthenticateSession(sessionToken string) (*model.Session, error) {
+	sess, err := s.store.Session().FindByHash(auth.HashToken(sessionToken))
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil, ErrSessionExpired
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.use(sess, time.Now())
+}
+
+// Refresh trades a refresh token for new tokens of the same session. The
+// old refresh token is revoked; presenting it again revokes the session.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*Tokens, error) {
+	var tokens *Tokens
+	var reused int
+
+	now := time.Now()
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		t, err := tx.RefreshToken().FindByHash(auth.HashToken(refreshToken))
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return ErrInvalidRefreshToken
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !t.IsActiveAt(now) {
+			if t.RevokedAt != nil {
+				reused = t.SessionId
+			}
+
+			return ErrInvalidRefreshToken
+		}
+
+		sess, err := tx.Session().Find(t.SessionId)
+		if err != nil {
+			return err
+		}
+
+		if !sess.IsActiveAt(now, s.refreshTTL) {
+			return ErrInvalidRefreshToken
+		}
+
+		revoked, err := tx.RefreshToken().Revoke(t.ID, now)
+		if err != nil {
+			return err
+		}
+
+		if !revoked {
+			reused = t.SessionId
+			return ErrInvalidRefreshToken
+		}
+
+		if err := tx.Session().Touch(sess.ID, now); err != nil {
+			return err
+		}
+
+		u, err := tx.User().Find(t.UserId)
+		if err != nil {
+			return err
+		}
+
+		tokens, err = s.issue(tx, u, sess.ID, now)
+		return err
+	})
+
+	// The session is revoked after the transaction, which the error above
+	// rolls back.
+	if reused != 0 {
+		if err := s.store.Session().Revoke(reused, now); err != nil {
+			return nil, err
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Logout revokes the session.
+func (s *AuthService) Logout(ctx context.Context, sessionId int) error {
+	return s.store.Session().Revoke(sessionId, time.Now())
+}
+
+// LogoutRefreshToken revokes the session of a refresh token. Unknown tokens
+// are ignored.
+func (s *AuthService) LogoutRefreshToken(ctx context.Context, refreshToken string) error {
+	t, err := s.store.RefreshToken().FindByHash(auth.HashToken(refreshToken))
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return s.Logout(ctx, t.SessionId)
+}
+
+// Sessions returns the active sessions of the user, most recently seen
+// first.
+func (s *AuthService) Sessions(ctx context.Context, userId int) ([]*model.Session, error) {
+	all, err := s.store.Session().GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sessions := []*model.Session{}
+	for _, sess := range all {
+		if sess.IsActiveAt(now, s.refreshTTL) {
+			sessions = append(sessions, sess)
+		}
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the user's sessions.
+func (s *AuthService) RevokeSession(ctx context.Context, userId int, sessionId int) error {
+	sess, err := s.store.Session().Find(sessionId)
+	if err != nil {
+		return err
+	}
+
+	if sess.UserId != userId {
+		return store.ErrRecordNotFound
+	}
+
+	return s.store.Session().Revoke(sessionId, time.Now())
+}
+
+// RevokeSessions revokes every session of the user except keepId, which is
+// zero to revoke them all. It runs whenever the password or role of the
+// user changes.
+func (s *AuthService) RevokeSessions(ctx context.Context, userId int, keepId int) error {
+	return s.revokeSessions(s.store, userId, keepId)
+}
+
+// revokeSessions is RevokeSessions within the transaction st, so that the
+// revocation commits together with the change that calls for it.
+func (s *AuthService) revokeSessions(st store.Store, userId int, keepId int) error {
+	return st.Session().RevokeUser(userId, keepId, time.Now())
+}
+
+// use checks that the session is active and records that it was seen.
+func (s *AuthService) use(sess *model.Session, now time.Time) (*model.Session, error) {
+	if !sess.IsActiveAt(now, s.refreshTTL) {
+		return nil, ErrSessionExpired
+	}
+
+	if now.Sub(sess.LastSeenAt) >= touchInterval {
+		if err := s.store.Session().Touch(sess.ID, now); err != nil {
+			return nil, err
+		}
+		sess.LastSeenAt = now
+	}
+
+	return sess, nil
+}
+
+func (s *AuthService) issue(st store.Store, u *model.User, sessionId int, now time.Time) (*Tokens, error) {
+	access, err := s.signer.Sign(u.ID, sessionId, u.Role, now)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := auth.NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := st.RefreshToken().Create(&model.RefreshToken{
+		UserId:    u.ID,
+		SessionId: sessionId,
+		TokenHash: auth.HashToken(refresh),
+		ExpiresAt: now.Add(s.refreshTTL),
+		CreatedAt: now,
+	}); err != nil {
+		return nil, err
+	}
+
+	return &Tokens{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(s.signer.TTL().Seconds()),
+		RefreshToken: refresh,
+	}, nil
+}
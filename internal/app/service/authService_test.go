@@ -0,0 +1,158 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"testing"
+)
+
+func TestAuthService_Login(t *testing.T) {
+	st := teststore.New()
+	sessions := newAuthService(t, st)
+	u := testUser(t, st, model.RoleUser)
+
+	l := login(t, sessions, u)
+	assert.NotEmpty(t, l.SessionToken)
+	assert.NotEmpty(t, l.Tokens.AccessToken)
+	assert.NotEmpty(t, l.Tokens.RefreshToken)
+
+	sess, err := sessions.Authenticate(l.Tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, l.Session.ID, sess.ID)
+	assert.Equal(t, u.ID, sess.UserId)
+
+	sess, err = sessions.AuthenticateSession(l.SessionToken)
+	require.NoError(t, err)
+	assert.Equal(t, l.Session.ID, sess.ID)
+
+	_, err = sessions.AuthenticateSession("unknown")
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+}
+
+func TestAuthService_Refresh_Rotates(t *testing.T) {
+	st := teststore.New()
+	sessions := newAuthService(t, st)
+	u := testUser(t, st, model.RoleUser)
+	l := login(t, sessions, u)
+
+	tokens, err := sessions.Refresh(context.Background(), l.Tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, l.Tokens.RefreshToken, tokens.RefreshToken)
+
+	sess, err := sessions.Authenticate(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, l.Session.ID, sess.ID)
+
+	next, err := sessions.Refresh(context.Background(), tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, next.RefreshToken)
+
+	_, err = sessions.Refresh(context.Background(), "unknown")
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+}
+
+func TestAuthService_Refresh_ReuseRevokesSession(t *testing.T) {
+	st := teststore.New()
+	sessions := newAuthService(t, st)
+	u := testUser(t, st, model.RoleUser)
+	l := login(t, sessions, u)
+	other := login(t, sessions, u)
+
+	tokens, err := sessions.Refresh(context.Background(), l.Tokens.RefreshToken)
+	require.NoError(t, err)
+
+	_, err = sessions.Refresh(context.Background(), l.Tokens.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+
+	_, err = sessions.Refresh(context.Background(), tokens.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+
+	_, err = sessions.Authenticate(tokens.AccessToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+
+	_, err = sessions.AuthenticateSession(l.SessionToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+
+	_, err = sessions.Refresh(context.Background(), other.Tokens.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	st := teststore.New()
+	sessions := newAuthService(t, st)
+	u := testUser(t, st, model.RoleUser)
+	l := login(t, sessions, u)
+	kept := login(t, sessions, u)
+
+	require.NoError(t, sessions.Logout(context.Background(), l.Session.ID))
+
+	_, err := sessions.Authenticate(l.Tokens.AccessToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+
+	_, err = sessions.Refresh(context.Background(), l.Tokens.RefreshToken)
+	assert.ErrorIs(t, err, service.ErrInvalidRefreshToken)
+
+	require.NoError(t, sessions.LogoutRefreshToken(context.Background(), kept.Tokens.RefreshToken))
+
+	_, err = sessions.AuthenticateSession(kept.SessionToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+
+	assert.NoError(t, sessions.LogoutRefreshToken(context.Background(), "unknown"))
+}
+
+func TestAuthService_RevokeSession(t *testing.T) {
+	st := teststore.New()
+	sessions := newAuthService(t, st)
+	u := testUser(t, st, model.RoleUser)
+	stranger := testUser(t, st, model.RoleUser)
+	phone := login(t, sessions, u)
+	laptop := login(t, sessions, u)
+
+	active, err := sessions.Sessions(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Len(t, active, 2)
+
+	err = sessions.RevokeSession(context.Background(), stranger.ID, phone.Session.ID)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	require.NoError(t, sessions.RevokeSession(context.Background(), u.ID, phone.Session.ID))
+
+	_, err = sessions.AuthenticateSession(phone.SessionToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+
+	active, err = sessions.Sessions(context.Background(), u.ID)
+	require.NoError(t, err)
+	require.Len(t, active, 1)
+	assert.Equal(t, laptop.Session.ID, active[0].ID)
+}
+
+func TestAuthService_RevokeSessions(t *testing.T) {
+	st := teststore.New()
+	sessions := newAuthService(t, st)
+	u := testUser(t, st, model.RoleUser)
+	other := testUser(t, st, model.RoleUser)
+	kept := login(t, sessions, u)
+	revoked := login(t, sessions, u)
+	untouched := login(t, sessions, other)
+
+	require.NoError(t, sessions.RevokeSessions(context.Background(), u.ID, kept.Session.ID))
+
+	_, err := sessions.Authenticate(kept.Tokens.AccessToken)
+	assert.NoError(t, err)
+
+	_, err = sessions.Authenticate(revoked.Tokens.AccessToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+
+	_, err = sessions.Authenticate(untouched.Tokens.AccessToken)
+	assert.NoError(t, err)
+
+	require.NoError(t, sessions.RevokeSessions(context.Background(), u.ID, 0))
+
+	_, err = sessions.Authenticate(kept.Tokens.AccessToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// Availability resolves menu schedules. A menu item is on the menu when the
+// item itself and every category above it either have no schedules or have
+// at least one open schedule, so items nobody scheduled are always on.
+type Availability struct {
+	byItem     map[int][]*model.MenuSchedule
+	byCategory map[int][]*model.MenuSchedule
+	parents    map[int]int
+}
+
+// LoadAvailability reads the schedules and the category tree from st.
+func LoadAvailability(st store.Store) (*Availability, error) {
+	schedules, err := st.MenuSchedule().GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	categories, err := st.Category().GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Availability{
+		byItem:     make(map[int][]*model.MenuSchedule),
+		byCategory: make(map[int][]*model.MenuSchedule),
+		parents:    make(map[int]int, len(categories)),
+	}
+
+	for _, s := range schedules {
+		if s.MenuItemId != 0 {
+			a.byItem[s.MenuItemId] = append(a.byItem[s.MenuItemId], s)
+		} else {
+			a.byCategory[s.CategoryID] = append(a.byCategory[s.CategoryID], s)
+		}
+	}
+
+	for _, c := range categories {
+		a.parents[c.ID] = c.ParentID
+	}
+
+	return a, nil
+}
+
+// ItemAvailableAt reports whether the menu item can be ordered at t.
+func (a *Availability) ItemAvailableAt(mi *model.MenuItem, t time.Time) bool {
+	return a.item(mi, func(s *model.MenuSchedule) bool {
+		return s.IsOpenAt(t)
+	})
+}
+
+// ItemAvailableOn reports whether the menu item is on the menu at some time
+// of the day of t.
+func (a *Availability) ItemAvailableOn(mi *model.MenuItem, t time.Time) bool {
+	return a.item(mi, func(s *model.MenuSchedule) bool {
+		return s.IsOpenOn(t)
+	})
+}
+
+// CategoryAvailableAt reports whether the category, and with it its combos,
+// is on the menu at t.
+func (a *Availability) CategoryAvailableAt(categoryId int, t time.Time) bool {
+	return a.category(categoryId, func(s *model.MenuSchedule) bool {
+		return s.IsOpenAt(t)
+	})
+}
+
+// CategoryAvailableOn is CategoryAvailableAt for some time of the day of t.
+func (a *Availability) CategoryAvailableOn(categoryId int, t time.Time) bool {
+	return a.category(categoryId, func(s *model.MenuSchedule) bool {
+		return s.IsOpenOn(t)
+	})
+}
+
+func (a *Availability) item(mi *model.MenuItem, open func(*model.MenuSchedule) bool) bool {
+	return anyOpen(a.byItem[mi.ID], open) && a.category(mi.CategoryID, open)
+}
+
+func (a *Availability) category(categoryId int, open func(*model.MenuSchedule) bool) bool {
+	seen := make(map[int]bool)
+	for {
+		parent, ok := a.parents[categoryId]
+		if !ok || seen[categoryId] {
+			return true
+		}
+
+		if !anyOpen(a.byCategory[categoryId], open) {
+			return false
+		}
+
+		seen[categoryId] = true
+		categoryId = parent
+	}
+}
+
+func anyOpen(schedules []*model.MenuSchedule, open func(*model.MenuSchedule) bool) bool {
+	if len(schedules) == 0 {
+		return true
+	}
+
+	for _, s := range schedules {
+		if open(s) {
+			return true
+		}
+	}
+
+	return false
+}
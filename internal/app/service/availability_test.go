@@ -0,0 +1,99 @@
+package service_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"testing"
+	"time"
+)
+
+func testCategory(t *testing.T, st store.Store, name string, parentId int) *model.Category {
+	t.Helper()
+
+	c := &model.Category{Name: name, ParentID: parentId}
+	require.NoError(t, st.Category().Create(c))
+
+	return c
+}
+
+func testSchedule(t *testing.T, st store.Store, s *model.MenuSchedule) {
+	t.Helper()
+
+	require.NoError(t, st.MenuSchedule().Create(s))
+}
+
+func TestAvailability_CategoryAncestors(t *testing.T) {
+	st := teststore.New()
+	food := testCategory(t, st, "food", 0)
+	hot := testCategory(t, st, "hot", food.ID)
+	soups := testCategory(t, st, "soups", hot.ID)
+	drinks := testCategory(t, st, "drinks", 0)
+
+	soup := &model.MenuItem{Name: "soup", Price: 300, CategoryID: soups.ID}
+	require.NoError(t, st.MenuItem().Create(soup))
+	tea := &model.MenuItem{Name: "tea", Price: 100, CategoryID: drinks.ID}
+	require.NoError(t, st.MenuItem().Create(tea))
+
+	// Food is served 08:00-20:00, hot food only from 11:00 and soup until
+	// 15:00; each level narrows the one above.
+	testSchedule(t, st, &model.MenuSchedule{CategoryID: food.ID, DailyFrom: "08:00", DailyTo: "20:00"})
+	testSchedule(t, st, &model.MenuSchedule{CategoryID: hot.ID, DailyFrom: "11:00", DailyTo: "23:00"})
+	testSchedule(t, st, &model.MenuSchedule{MenuItemId: soup.ID, DailyFrom: "06:00", DailyTo: "15:00"})
+
+	a, err := service.LoadAvailability(st)
+	require.NoError(t, err)
+
+	day := time.Date(2024, 6, 3, 0, 0, 0, 0, time.Local)
+	clock := func(h int) time.Time { return day.Add(time.Duration(h) * time.Hour) }
+
+	for _, tc := range []struct {
+		hour            int
+		soup, hot, food bool
+	}{
+		{7, false, false, false},
+		{9, false, false, true},
+		{12, true, true, true},
+		{16, false, true, true},
+		{21, false, false, false},
+	} {
+		assert.Equal(t, tc.soup, a.ItemAvailableAt(soup, clock(tc.hour)), "soup at %d", tc.hour)
+		assert.Equal(t, tc.hot, a.CategoryAvailableAt(soups.ID, clock(tc.hour)), "soups at %d", tc.hour)
+		assert.Equal(t, tc.hot, a.CategoryAvailableAt(hot.ID, clock(tc.hour)), "hot at %d", tc.hour)
+		assert.Equal(t, tc.food, a.CategoryAvailableAt(food.ID, clock(tc.hour)), "food at %d", tc.hour)
+		assert.True(t, a.ItemAvailableAt(tea, clock(tc.hour)), "unscheduled tea at %d", tc.hour)
+	}
+
+	assert.True(t, a.ItemAvailableOn(soup, day))
+}
+
+func TestAvailability_AnyOpenScheduleOfALevel(t *testing.T) {
+	st := teststore.New()
+	specials := testCategory(t, st, "specials", 0)
+	pie := &model.MenuItem{Name: "pie", Price: 300, CategoryID: specials.ID}
+	require.NoError(t, st.MenuItem().Create(pie))
+
+	testSchedule(t, st, &model.MenuSchedule{CategoryID: specials.ID, Weekdays: []int{int(time.Monday)}})
+	testSchedule(t, st, &model.MenuSchedule{CategoryID: specials.ID, Weekdays: []int{int(time.Friday)}, DailyFrom: "18:00", DailyTo: "01:00"})
+
+	a, err := service.LoadAvailability(st)
+	require.NoError(t, err)
+
+	// June 3rd 2024 is a Monday.
+	date := func(day, hour, min int) time.Time {
+		return time.Date(2024, 6, day, hour, min, 0, 0, time.Local)
+	}
+
+	assert.True(t, a.ItemAvailableAt(pie, date(3, 12, 0)))
+	assert.False(t, a.ItemAvailableAt(pie, date(4, 12, 0)))
+	assert.False(t, a.ItemAvailableAt(pie, date(7, 12, 0)))
+	assert.True(t, a.ItemAvailableAt(pie, date(7, 19, 0)))
+	assert.True(t, a.ItemAvailableAt(pie, date(8, 0, 30)))
+	assert.False(t, a.ItemAvailableAt(pie, date(8, 1, 0)))
+
+	assert.False(t, a.ItemAvailableOn(pie, date(5, 0, 0)))
+	assert.True(t, a.ItemAvailableOn(pie, date(7, 0, 0)))
+}
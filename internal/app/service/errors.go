@@ -0,0 +1,54 @@
+package service
+
+import "errors"
+
+var (
+	ErrEmptyOrder           = errors.New("order must contain at least one item")
+	ErrNonPositiveQuantity  = errors.New("quantity must be greater than zero")
+	ErrUnknownMenuItem      = errors.New("unknown menu item")
+	ErrSoldOut              = errors.New("sold out")
+	ErrNotOnMenu            = errors.New("not on the menu at this time")
+	ErrUnknownStatus        = errors.New("unknown order status")
+	ErrOrderNotEditable     = errors.New("order can no longer be changed")
+	ErrInvalidTransition    = errors.New("order status transition not allowed")
+	ErrInsufficientFunds    = errors.New("insufficient funds")
+	ErrNonPositiveAmount    = errors.New("amount must be greater than zero")
+	ErrZeroAmount           = errors.New("amount must not be zero")
+	ErrUnknownPaymentMethod = errors.New("unknown payment method")
+	ErrPaymentDeclined      = errors.New("payment declined")
+	ErrCardPaymentsDisabled = errors.New("card payments are not enabled")
+	ErrPaymentPending       = errors.New("payment has not been captured yet")
+	ErrMissingEventID       = errors.New("payment event has no id")
+	ErrUnknownSlot          = errors.New("unknown pickup slot")
+	ErrSlotFull             = errors.New("pickup slot is full")
+	ErrSlotUnavailable      = errors.New("pickup slot is no longer available")
+	ErrSlotInUse            = errors.New("pickup slot has reservations")
+	ErrInvalidSlotSettings  = errors.New("interval, max_orders and max_items must be greater than zero")
+	ErrInvalidOpeningHours  = errors.New("closing time must be after opening time")
+	ErrCardOrderNotEditable = errors.New("orders paid by card cannot be changed, cancel and place a new order")
+	ErrInvalidModifier      = errors.New("modifier does not belong to the menu item")
+	ErrDuplicateModifier    = errors.New("modifier chosen more than once")
+	ErrModifierSelection    = errors.New("number of chosen modifiers is outside the group limits")
+	ErrNegativePrice        = errors.New("chosen modifiers take the price below zero")
+	ErrUnknownCombo         = errors.New("unknown combo")
+	ErrInvalidOrderLine     = errors.New("order line must name either a menu item or a combo")
+	ErrInvalidComboChoice   = errors.New("combo components must pick one allowed item for every slot")
+	ErrUnknownCoupon        = errors.New("unknown coupon code")
+	ErrCouponExpired        = errors.New("coupon is not valid at this time")
+	ErrCouponUsedUp         = errors.New("coupon usage limit reached")
+	ErrCouponNotApplicable  = errors.New("coupon does not apply to this order")
+	ErrInsufficientPoints   = errors.New("insufficient loyalty points")
+	ErrNonPositivePoints    = errors.New("points must be greater than zero")
+	ErrZeroPoints           = errors.New("points must not be zero")
+	ErrOrderNotRefundable   = errors.New("only picked up orders can be refunded, cancel the order instead")
+	ErrAlreadyRefunded      = errors.New("order has already been refunded")
+	ErrInvalidRefreshToken  = errors.New("invalid or expired refresh token")
+	ErrSessionExpired       = errors.New("session expired or revoked")
+	ErrWrongPassword        = errors.New("current password is incorrect")
+	ErrInvalidResetToken    = errors.New("invalid or expired password reset token")
+	ErrInvalidVerifyToken   = errors.New("invalid or expired email verification token")
+	ErrAlreadyVerified      = errors.New("email address is already verified")
+	ErrLoginThrottled       = errors.New("too many failed logins, wait before trying again")
+	ErrAccountLocked        = errors.New("account temporarily locked after too many failed logins")
+	ErrIPLocked             = errors.New("too many failed logins from this address, try again later")
+)
@@ -0,0 +1,150 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/auth"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/notify"
+	"github.com/yeboka/final-project/internal/app/payment"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testLoyalty = service.LoyaltyProgram{
+	EarnRate:   1,
+	PointValue: 1,
+	Expiry:     365 * 24 * time.Hour,
+}
+
+var testHours = service.OpeningHours{Close: 24 * time.Hour}
+
+func newOrderService(st store.Store, payments payment.Provider) *service.OrderService {
+	return service.NewOrderService(st, payments, testHours, testLoyalty)
+}
+
+func newFakeProvider(t *testing.T) *payment.FakeProvider {
+	t.Helper()
+
+	p, err := payment.NewFakeProvider("secret")
+	require.NoError(t, err)
+
+	return p
+}
+
+func newAuthService(t *testing.T, st store.Store) *service.AuthService {
+	t.Helper()
+
+	signer, err := auth.NewSigner("secret", 15*time.Minute)
+	require.NoError(t, err)
+
+	return service.NewAuthService(st, signer, 24*time.Hour)
+}
+
+func login(t *testing.T, sessions *service.AuthService, u *model.User) *service.Login {
+	t.Helper()
+
+	l, err := sessions.Login(context.Background(), u, "test", "10.0.0.1")
+	require.NoError(t, err)
+
+	return l
+}
+
+var lastTestUser int64
+
+func testUser(t *testing.T, st store.Store, role string) *model.User {
+	t.Helper()
+
+	n := atomic.AddInt64(&lastTestUser, 1)
+	u := &model.User{
+		Email:         fmt.Sprintf("user%d@example.org", n),
+		Username:      fmt.Sprintf("user%d", n),
+		Password:      "password",
+		Role:          role,
+		EmailVerified: true,
+	}
+	require.NoError(t, st.User().Create(u))
+
+	return u
+}
+
+var lastTestMenuItem int64
+
+func testMenuItem(t *testing.T, st store.Store, price int, stock int) *model.MenuItem {
+	t.Helper()
+
+	m := &model.MenuItem{
+		Name:  fmt.Sprintf("dish %d", atomic.AddInt64(&lastTestMenuItem, 1)),
+		Price: price,
+		Stock: &stock,
+	}
+	require.NoError(t, st.MenuItem().Create(m))
+
+	return m
+}
+
+func topUp(t *testing.T, st store.Store, userId int, amount int) {
+	t.Helper()
+
+	_, err := service.NewWalletService(st).TopUp(context.Background(), userId, amount, &model.User{ID: userId}, "")
+	require.NoError(t, err)
+}
+
+func stockOf(t *testing.T, st store.Store, menuItemId int) int {
+	t.Helper()
+
+	m, err := st.MenuItem().Find(menuItemId)
+	require.NoError(t, err)
+	require.NotNil(t, m.Stock)
+
+	return *m.Stock
+}
+
+func findOrder(t *testing.T, st store.Store, orderId int) *model.Order {
+	t.Helper()
+
+	o, err := st.Order().Find(orderId)
+	require.NoError(t, err)
+
+	return o
+}
+
+func place(t *testing.T, orders *service.OrderService, userId int, method string, lines ...service.OrderLine) *model.Order {
+	t.Helper()
+
+	o, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        userId,
+		Lines:         lines,
+		PaymentMethod: method,
+	})
+	require.NoError(t, err)
+
+	return o
+}
+
+func line(menuItemId int, quantity int) service.OrderLine {
+	return service.OrderLine{MenuItemId: menuItemId, Quantity: quantity}
+}
+
+// mailedToken returns the token of the link in the last message sent to the
+// address.
+func mailedToken(t *testing.T, outbox *notify.Outbox, to string) string {
+	t.Helper()
+
+	m := outbox.Last(to)
+	require.NotNil(t, m, "no message to %s", to)
+
+	_, raw, ok := strings.Cut(m.Body, "?token=")
+	require.True(t, ok, "no link in %q", m.Body)
+
+	token, err := url.QueryUnescape(strings.Fields(raw)[0])
+	require.NoError(t, err)
+
+	return token
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"strings"
+	"time"
+)
+
+// LoginPolicy holds the brute-force protection settings. Failed logins are
+// counted per email address and per IP address; failures older than Window
+// no longer count. After every failure the email address has to wait Delay,
+// doubled for each further failure up to MaxDelay, before the next attempt.
+// MaxFailures failures lock the email address, and MaxIPFailures the IP
+// address, out for Lockout. Zero limits turn the respective part off.
+// Addresses without recent failures are not tracked at all.
+type LoginPolicy struct {
+	MaxFailures   int
+	MaxIPFailures int
+	Window        time.Duration
+	Lockout       time.Duration
+	Delay         time.Duration
+	MaxDelay      time.Duration
+}
+
+// delay returns how long to wait after the given number of failures.
+func (p LoginPolicy) delay(failures int) time.Duration {
+	if p.Delay <= 0 || failures <= 0 {
+		return 0
+	}
+
+	d := p.Delay
+	for i := 1; i < failures; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+
+	return d
+}
+
+// LoginGuard keeps track of failed logins and turns attempts away while an
+// email or IP address has to wait or is locked out.
+type LoginGuard struct {
+	store  store.Store
+	policy LoginPolicy
+}
+
+// NewLoginGuard ...
+func NewLoginGuard(st store.Store, policy LoginPolicy) *LoginGuard {
+	return &LoginGuard{
+		store:  st,
+		policy: policy,
+	}
+}
+
+// normalizeEmail makes differently written forms of one address count as
+// the same.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Attempt tells whether a login for email from ip may be attempted now.
+// Once email has failed, an attempt also makes further attempts for email
+// wait as long as they would after it failed, until its outcome is known,
+// so concurrent logins cannot get past the delay. When the attempt may not
+// go ahead, Attempt returns how long to wait along with ErrAccountLocked,
+// ErrIPLocked or ErrLoginThrottled.
+func (g *LoginGuard) Attempt(email string, ip string) (time.Duration, error) {
+	now := time.Now()
+
+	t, err := g.find(model.ThrottleIP, ip)
+	if err != nil {
+		return 0, err
+	}
+
+	if t != nil && t.IsLockedAt(now) {
+		return t.LockedUntil.Sub(now), ErrIPLocked
+	}
+
+	key := normalizeEmail(email)
+
+	t, err = g.find(model.ThrottleEmail, key)
+	if err != nil || t == nil {
+		return 0, err
+	}
+
+	if t.IsLockedAt(now) {
+		return t.LockedUntil.Sub(now), ErrAccountLocked
+	}
+
+	failures := 0
+	if !t.LastFailureAt.Before(now.Add(-g.policy.Window)) {
+		failures = t.Failures
+	}
+
+	if failures == 0 {
+		return 0, nil
+	}
+
+	if due := t.LastFailureAt.Add(g.policy.delay(failures)); now.Before(due) {
+		return due.Sub(now), ErrLoginThrottled
+	}
+
+	ok, err := g.store.LoginThrottle().Attempt(model.ThrottleEmail, key, now, now.Add(g.policy.delay(failures+1)))
+	if err != nil || ok {
+		return 0, err
+	}
+
+	t, err = g.find(model.ThrottleEmail, key)
+	if err != nil || t == nil {
+		return 0, err
+	}
+
+	if t.IsLockedAt(now) {
+		return t.LockedUntil.Sub(now), ErrAccountLocked
+	}
+
+	var wait time.Duration
+	if t.NextAttemptAt != nil && now.Before(*t.NextAttemptAt) {
+		wait = t.NextAttemptAt.Sub(now)
+	}
+
+	return wait, ErrLoginThrottled
+}
+
+// Fail records a failed login for email from ip and locks either out when
+// it reached its limit.
+func (g *LoginGuard) Fail(ctx context.Context, email string, ip string) error {
+	if err := g.fail(model.ThrottleEmail, normalizeEmail(email), g.policy.MaxFailures, ip, email); err != nil {
+		return err
+	}
+
+	return g.fail(model.ThrottleIP, ip, g.policy.MaxIPFailures, ip, "")
+}
+
+// Succeed forgets the failed logins for email. Those of the IP address
+// count on, so that logging into an own account does not reset them.
+func (g *LoginGuard) Succeed(email string) error {
+	return g.store.LoginThrottle().Reset(model.ThrottleEmail, normalizeEmail(email))
+}
+
+// Unlock lifts the lockout of the user's email address and forgets its
+// failed logins.
+func (g *LoginGuard) Unlock(ctx context.Context, u *model.User, actor *model.User) error {
+	return g.store.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.LoginThrottle().Reset(model.ThrottleEmail, normalizeEmail(u.Email)); err != nil {
+			return err
+		}
+
+		return tx.Audit().Create(&model.AuditEntry{
+			Action:    model.AuditLoginUnlock,
+			UserId:    &u.ID,
+			ActorId:   &actor.ID,
+			Detail:    "email " + normalizeEmail(u.Email) + " unlocked",
+			CreatedAt: time.Now(),
+		})
+	})
+}
+
+// ForgetStale removes the throttles whose failures no longer count and
+// that neither lock out nor hold back logins any more. It returns how many
+// it removed.
+func (g *LoginGuard) ForgetStale() (int, error) {
+	now := time.Now()
+
+	return g.store.LoginThrottle().DeleteStale(now.Add(-g.policy.Window), now)
+}
+
+func (g *LoginGuard) find(kind string, key string) (*model.LoginThrottle, error) {
+	t, err := g.store.LoginThrottle().Find(kind, key)
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return t, err
+}
+
+// fail counts a failure for kind and key and locks it out once there are
+// max failures, writing an audit entry that names the account of email, if
+// there is one.
+func (g *LoginGuard) fail(kind string, key string, max int, ip string, email string) error {
+	now := time.Now()
+
+	t, err := g.store.LoginThrottle().RecordFailure(kind, key, now, now.Add(-g.policy.Window))
+	if err != nil {
+		return err
+	}
+
+	if max <= 0 || t.Failures < max || t.IsLockedAt(now) {
+		return nil
+	}
+
+	until := now.Add(g.policy.Lockout)
+	if err := g.store.LoginThrottle().Lock(t.ID, until); err != nil {
+		return err
+	}
+
+	e := &model.AuditEntry{
+		Action:    model.AuditLoginLockout,
+		IP:        ip,
+		Detail:    fmt.Sprintf("%s %s locked until %s after %d failed logins", kind, key, until.Format(time.RFC3339), t.Failures),
+		CreatedAt: now,
+	}
+
+	if email != "" {
+		u, err := g.findUser(email)
+		if err != nil {
+			return err
+		}
+
+		if u != nil {
+			e.UserId = &u.ID
+		}
+	}
+
+	return g.store.Audit().Create(e)
+}
+
+// findUser returns the account of email, looking it up normalized first and
+// as given second, or nil when there is none.
+func (g *LoginGuard) findUser(email string) (*model.User, error) {
+	for _, e := range []string{normalizeEmail(email), email} {
+		u, err := g.store.User().FindByEmail(e)
+		if err == nil {
+			return u, nil
+		}
+
+		if !errors.Is(err, store.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
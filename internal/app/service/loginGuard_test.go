@@ -0,0 +1,164 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLoginGuard_Attempt_DelayAfterFailure(t *testing.T) {
+	st := teststore.New()
+	g := service.NewLoginGuard(st, service.LoginPolicy{Window: time.Hour, Delay: time.Minute})
+
+	_, err := g.Attempt("a@example.org", "10.0.0.1")
+	require.NoError(t, err)
+	require.NoError(t, g.Fail(context.Background(), "a@example.org", "10.0.0.1"))
+
+	wait, err := g.Attempt("A@Example.org ", "10.0.0.2")
+	assert.ErrorIs(t, err, service.ErrLoginThrottled)
+	assert.Greater(t, wait, time.Duration(0))
+	assert.LessOrEqual(t, wait, time.Minute)
+
+	_, err = g.Attempt("b@example.org", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestLoginGuard_Attempt_TracksOnlyFailures(t *testing.T) {
+	st := teststore.New()
+	g := service.NewLoginGuard(st, service.LoginPolicy{Window: time.Hour, Delay: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		_, err := g.Attempt("a@example.org", "10.0.0.1")
+		require.NoError(t, err)
+	}
+
+	_, err := st.LoginThrottle().Find(model.ThrottleEmail, "a@example.org")
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+	_, err = st.LoginThrottle().Find(model.ThrottleIP, "10.0.0.1")
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestLoginGuard_ForgetStale(t *testing.T) {
+	st := teststore.New()
+	g := service.NewLoginGuard(st, service.LoginPolicy{MaxFailures: 1, Window: time.Hour, Lockout: 3 * time.Hour})
+	now := time.Now()
+
+	_, err := st.LoginThrottle().RecordFailure(model.ThrottleEmail, "old@example.org", now.Add(-2*time.Hour), time.Time{})
+	require.NoError(t, err)
+	_, err = st.LoginThrottle().RecordFailure(model.ThrottleIP, "10.0.0.1", now.Add(-2*time.Hour), time.Time{})
+	require.NoError(t, err)
+
+	locked, err := st.LoginThrottle().RecordFailure(model.ThrottleEmail, "locked@example.org", now.Add(-2*time.Hour), time.Time{})
+	require.NoError(t, err)
+	require.NoError(t, st.LoginThrottle().Lock(locked.ID, now.Add(time.Hour)))
+
+	require.NoError(t, g.Fail(context.Background(), "recent@example.org", "10.0.0.2"))
+
+	n, err := g.ForgetStale()
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	_, err = st.LoginThrottle().Find(model.ThrottleEmail, "old@example.org")
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+	_, err = st.LoginThrottle().Find(model.ThrottleEmail, "locked@example.org")
+	assert.NoError(t, err)
+	_, err = st.LoginThrottle().Find(model.ThrottleEmail, "recent@example.org")
+	assert.NoError(t, err)
+	_, err = st.LoginThrottle().Find(model.ThrottleIP, "10.0.0.2")
+	assert.NoError(t, err)
+}
+
+func TestLoginGuard_Attempt_SuccessResets(t *testing.T) {
+	st := teststore.New()
+	g := service.NewLoginGuard(st, service.LoginPolicy{Window: time.Hour, Delay: time.Minute})
+
+	_, err := g.Attempt("a@example.org", "10.0.0.1")
+	require.NoError(t, err)
+	require.NoError(t, g.Succeed("a@example.org"))
+
+	_, err = g.Attempt("a@example.org", "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestLoginGuard_Attempt_Concurrent(t *testing.T) {
+	st := teststore.New()
+	g := service.NewLoginGuard(st, service.LoginPolicy{Window: 2 * time.Hour, Delay: time.Minute})
+
+	// A failure whose delay is over: the next attempt is due, but only one.
+	_, err := st.LoginThrottle().RecordFailure(model.ThrottleEmail, "a@example.org", time.Now().Add(-time.Hour), time.Time{})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	allowed := 0
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := g.Attempt("a@example.org", "10.0.0.1"); err == nil {
+				mu.Lock()
+				allowed++
+				mu.Unlock()
+			} else {
+				assert.ErrorIs(t, err, service.ErrLoginThrottled)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, allowed)
+}
+
+func TestLoginGuard_Fail_LocksAccount(t *testing.T) {
+	st := teststore.New()
+	u := testUser(t, st, model.RoleUser)
+	g := service.NewLoginGuard(st, service.LoginPolicy{MaxFailures: 3, Window: time.Hour, Lockout: time.Hour})
+
+	for i := 0; i < 3; i++ {
+		_, err := g.Attempt(u.Email, "10.0.0.1")
+		require.NoError(t, err)
+		require.NoError(t, g.Fail(context.Background(), strings.ToUpper(u.Email), "10.0.0.1"))
+	}
+
+	wait, err := g.Attempt(u.Email, "10.0.0.2")
+	assert.ErrorIs(t, err, service.ErrAccountLocked)
+	assert.Greater(t, wait, 59*time.Minute)
+
+	entries, err := st.Audit().List(model.AuditLoginLockout, 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.NotNil(t, entries[0].UserId)
+	assert.Equal(t, u.ID, *entries[0].UserId)
+
+	require.NoError(t, g.Unlock(context.Background(), u, u))
+
+	_, err = g.Attempt(u.Email, "10.0.0.1")
+	assert.NoError(t, err)
+}
+
+func TestLoginGuard_Fail_LocksIP(t *testing.T) {
+	st := teststore.New()
+	g := service.NewLoginGuard(st, service.LoginPolicy{MaxIPFailures: 2, Window: time.Hour, Lockout: time.Hour})
+
+	for _, email := range []string{"a@example.org", "b@example.org"} {
+		_, err := g.Attempt(email, "10.0.0.1")
+		require.NoError(t, err)
+		require.NoError(t, g.Fail(context.Background(), email, "10.0.0.1"))
+	}
+
+	_, err := g.Attempt("c@example.org", "10.0.0.1")
+	assert.ErrorIs(t, err, service.ErrIPLocked)
+
+	_, err = g.Attempt("c@example.org", "10.0.0.2")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// LoyaltyProgram holds the loyalty settings: completed orders earn EarnRate
+// points per 100 spent, every redeemed point takes PointValue off an order,
+// and earned points expire after Expiry. Zero values turn the respective
+// part of the program off.
+type LoyaltyProgram struct {
+	EarnRate   int
+	PointValue int
+	Expiry     time.Duration
+}
+
+// Loyalty is a user's points balance together with the ledger it is derived
+// from.
+type Loyalty struct {
+	Balance int                   `json:"balance"`
+	Entries []*model.LoyaltyEntry `json:"entries"`
+}
+
+// LoyaltyService manages loyalty points balances. Points are earned and
+// redeemed by the order service.
+type LoyaltyService struct {
+	store store.Store
+}
+
+// NewLoyaltyService ...
+func NewLoyaltyService(st store.Store) *LoyaltyService {
+	return &LoyaltyService{
+		store: st,
+	}
+}
+
+// Get returns the user's points balance and ledger history, writing off
+// points that have expired first.
+func (s *LoyaltyService) Get(ctx context.Context, userId int) (*Loyalty, error) {
+	l := &Loyalty{}
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		// The lock keeps concurrent requests from writing the same points
+		// off twice.
+		if _, err := tx.Loyalty().BalanceForUpdate(userId); err != nil {
+			return err
+		}
+
+		if err := expirePoints(tx, userId, time.Now()); err != nil {
+			return err
+		}
+
+		var err error
+		l.Balance, err = tx.Loyalty().Balance(userId)
+		if err != nil {
+			return err
+		}
+
+		l.Entries, err = tx.Loyalty().GetEntries(userId)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// Adjust applies a manual correction on behalf of actor. It may be negative
+// but never lets the balance drop below zero.
+func (s *LoyaltyService) Adjust(ctx context.Context, userId int, points int, actor *model.User, note string) (*model.LoyaltyEntry, error) {
+	if points == 0 {
+		return nil, ErrZeroPoints
+	}
+
+	e := &model.LoyaltyEntry{
+		UserId:    userId,
+		Kind:      model.LoyaltyEntryAdjustment,
+		Points:    points,
+		CreatedBy: actor.ID,
+		Note:      note,
+		CreatedAt: time.Now(),
+	}
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		if _, err := tx.Loyalty().BalanceForUpdate(userId); err != nil {
+			return err
+		}
+
+		if err := expirePoints(tx, userId, e.CreatedAt); err != nil {
+			return err
+		}
+
+		balance, err := tx.Loyalty().Balance(userId)
+		if err != nil {
+			return err
+		}
+
+		if balance+points < 0 {
+			return ErrInsufficientPoints
+		}
+
+		return tx.Loyalty().Create(e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Balances returns the points balance of every user with loyalty history.
+func (s *LoyaltyService) Balances(ctx context.Context) ([]*model.LoyaltyBalance, error) {
+	var balances []*model.LoyaltyBalance
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		current, err := tx.Loyalty().GetBalances()
+		if err != nil {
+			return err
+		}
+
+		// Balances come ordered by user, so the users are locked in the
+		// same order by every caller.
+		now := time.Now()
+		for _, b := range current {
+			if _, err := tx.Loyalty().BalanceForUpdate(b.UserId); err != nil {
+				return err
+			}
+
+			if err := expirePoints(tx, b.UserId, now); err != nil {
+				return err
+			}
+		}
+
+		balances, err = tx.Loyalty().GetBalances()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
+
+// expirePoints writes off the earned points of a user that expired by now
+// and have not been spent. Spending consumes the oldest points first, so
+// debits count against the points that expire first. The caller holds the
+// lock of BalanceForUpdate on the user.
+func expirePoints(st store.Store, userId int, now time.Time) error {
+	expired, err := st.Loyalty().ExpiredPoints(userId, now)
+	if err != nil {
+		return err
+	}
+
+	if expired == 0 {
+		return nil
+	}
+
+	earned, err := st.Loyalty().SumByKind(userId, model.LoyaltyEntryEarn)
+	if err != nil {
+		return err
+	}
+
+	writtenOff, err := st.Loyalty().SumByKind(userId, model.LoyaltyEntryExpire)
+	if err != nil {
+		return err
+	}
+
+	balance, err := st.Loyalty().Balance(userId)
+	if err != nil {
+		return err
+	}
+
+	// Everything that is neither earned nor expired: redemptions, refunds
+	// and adjustments.
+	spent := -(balance - earned - writtenOff)
+	if spent < 0 {
+		spent = 0
+	}
+
+	due := expired - spent + writtenOff
+	if due > balance {
+		due = balance
+	}
+
+	if due <= 0 {
+		return nil
+	}
+
+	return st.Loyalty().Create(&model.LoyaltyEntry{
+		UserId:    userId,
+		Kind:      model.LoyaltyEntryExpire,
+		Points:    -due,
+		CreatedBy: userId,
+		CreatedAt: now,
+	})
+}
+
+// redeemPoints takes up to points off the order pricing and returns how many
+// points were used. It checks the user's balance under lock.
+func (p LoyaltyProgram) redeemPoints(st store.Store, userId int, pricing *Pricing, points int, now time.Time) (int, error) {
+	if points < 0 {
+		return 0, ErrNonPositivePoints
+	}
+
+	if points == 0 || p.PointValue <= 0 {
+		return 0, nil
+	}
+
+	if _, err := st.Loyalty().BalanceForUpdate(userId); err != nil {
+		return 0, err
+	}
+
+	if err := expirePoints(st, userId, now); err != nil {
+		return 0, err
+	}
+
+	balance, err := st.Loyalty().Balance(userId)
+	if err != nil {
+		return 0, err
+	}
+
+	if balance < points {
+		return 0, fmt.Errorf("balance %d points: %w", balance, ErrInsufficientPoints)
+	}
+
+	return pricing.applyPoints(userId, points, p.PointValue), nil
+}
+
+// earnPoints credits the points a completed order earns. An order earns
+// only once.
+func (p LoyaltyProgram) earnPoints(st store.Store, o *model.Order, now time.Time) error {
+	if p.EarnRate <= 0 {
+		return nil
+	}
+
+	earned, err := st.Loyalty().OrderPoints(o.ID, model.LoyaltyEntryEarn)
+	if err != nil {
+		return err
+	}
+
+	points := o.TotalAmount * p.EarnRate / 100
+	if earned != 0 || points <= 0 {
+		return nil
+	}
+
+	e := &model.LoyaltyEntry{
+		UserId:    o.UserId,
+		Kind:      model.LoyaltyEntryEarn,
+		Points:    points,
+		OrderId:   &o.ID,
+		CreatedBy: o.UserId,
+		CreatedAt: now,
+	}
+
+	if p.Expiry > 0 {
+		expiresAt := now.Add(p.Expiry)
+		e.ExpiresAt = &expiresAt
+	}
+
+	return st.Loyalty().Create(e)
+}
+
+// redeemedPoints returns the points currently redeemed on an order.
+func redeemedPoints(st store.Store, orderId int) (int, error) {
+	points, err := st.Loyalty().OrderPoints(orderId, model.LoyaltyEntryRedeem, model.LoyaltyEntryRefund)
+	if err != nil {
+		return 0, err
+	}
+
+	return -points, nil
+}
+
+// loyaltyEntry records a redemption (negative points) or refund (positive
+// points) of an order.
+func loyaltyEntry(st store.Store, o *model.Order, kind string, points int) error {
+	if points == 0 {
+		return nil
+	}
+
+	return st.Loyalty().Create(&model.LoyaltyEntry{
+		UserId:    o.UserId,
+		Kind:      kind,
+		Points:    points,
+		OrderId:   &o.ID,
+		CreatedBy: o.UserId,
+		CreatedAt: time.Now(),
+	})
+}
+
+// refundPoints gives back every point redeemed on the order.
+func refundPoints(st store.Store, o *model.Order) error {
+	redeemed, err := redeemedPoints(st, o.ID)
+	if err != nil {
+		return err
+	}
+
+	if redeemed <= 0 {
+		return nil
+	}
+
+	return loyaltyEntry(st, o, model.LoyaltyEntryRefund, redeemed)
+}
+
+// revokePoints takes back the points a refunded order earned, down to a
+// balance of zero.
+func revokePoints(st store.Store, o *model.Order, actor *model.User) error {
+	earned, err := st.Loyalty().OrderPoints(o.ID, model.LoyaltyEntryEarn)
+	if err != nil || earned <= 0 {
+		return err
+	}
+
+	if _, err := st.Loyalty().BalanceForUpdate(o.UserId); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if err := expirePoints(st, o.UserId, now); err != nil {
+		return err
+	}
+
+	balance, err := st.Loyalty().Balance(o.UserId)
+	if err != nil {
+		return err
+	}
+
+	if earned > balance {
+		earned = balance
+	}
+
+	if earned <= 0 {
+		return nil
+	}
+
+	return st.Loyalty().Create(&model.LoyaltyEntry{
+		UserId:    o.UserId,
+		Kind:      model.LoyaltyEntryAdjustment,
+		Points:    -earned,
+		OrderId:   &o.ID,
+		CreatedBy: actor.ID,
+		Note:      fmt.Sprintf("refund of order #%d", o.ID),
+		CreatedAt: now,
+	})
+}
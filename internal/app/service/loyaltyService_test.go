@@ -0,0 +1,117 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"sync"
+	"testing"
+	"time"
+)
+
+func earn(t *testing.T, st store.Store, userId int, points int, expiresAt time.Time) {
+	t.Helper()
+
+	require.NoError(t, st.Loyalty().Create(&model.LoyaltyEntry{
+		UserId:    userId,
+		Kind:      model.LoyaltyEntryEarn,
+		Points:    points,
+		CreatedBy: userId,
+		ExpiresAt: &expiresAt,
+		CreatedAt: expiresAt.Add(-24 * time.Hour),
+	}))
+}
+
+func expireEntries(t *testing.T, st store.Store, userId int) int {
+	t.Helper()
+
+	entries, err := st.Loyalty().GetEntries(userId)
+	require.NoError(t, err)
+
+	n := 0
+	for _, e := range entries {
+		if e.Kind == model.LoyaltyEntryExpire {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestLoyaltyService_Get_ExpiresPointsOnce(t *testing.T) {
+	st := teststore.New()
+	loyalty := service.NewLoyaltyService(st)
+	u := testUser(t, st, model.RoleUser)
+	earn(t, st, u.ID, 100, time.Now().Add(-time.Hour))
+	earn(t, st, u.ID, 30, time.Now().Add(time.Hour))
+
+	l, err := loyalty.Get(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 30, l.Balance)
+
+	l, err = loyalty.Get(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 30, l.Balance)
+	assert.Equal(t, 1, expireEntries(t, st, u.ID))
+}
+
+func TestLoyaltyService_Get_SpentPointsExpireFirst(t *testing.T) {
+	st := teststore.New()
+	loyalty := service.NewLoyaltyService(st)
+	u := testUser(t, st, model.RoleUser)
+	earn(t, st, u.ID, 100, time.Now().Add(-time.Hour))
+	earn(t, st, u.ID, 50, time.Now().Add(time.Hour))
+
+	// 80 points were spent before the first batch expired, so only the 20
+	// left of it expire.
+	require.NoError(t, st.Loyalty().Create(&model.LoyaltyEntry{
+		UserId:    u.ID,
+		Kind:      model.LoyaltyEntryRedeem,
+		Points:    -80,
+		CreatedBy: u.ID,
+		CreatedAt: time.Now().Add(-2 * time.Hour),
+	}))
+
+	l, err := loyalty.Get(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 50, l.Balance)
+}
+
+func TestLoyaltyService_Get_UnknownUser(t *testing.T) {
+	_, err := service.NewLoyaltyService(teststore.New()).Get(context.Background(), 42)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestLoyaltyService_ConcurrentReadsExpireOnce(t *testing.T) {
+	st := teststore.New()
+	loyalty := service.NewLoyaltyService(st)
+	u := testUser(t, st, model.RoleUser)
+	earn(t, st, u.ID, 100, time.Now().Add(-time.Hour))
+	earn(t, st, u.ID, 40, time.Now().Add(time.Hour))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := loyalty.Get(context.Background(), u.ID)
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := loyalty.Balances(context.Background())
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	balances, err := loyalty.Balances(context.Background())
+	require.NoError(t, err)
+	require.Len(t, balances, 1)
+	assert.Equal(t, 40, balances[0].Points)
+	assert.Equal(t, 1, expireEntries(t, st, u.ID))
+}
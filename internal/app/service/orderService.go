@@ -0,0 +1,767 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/payment"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// OrderLine is a single menu item or combo and quantity requested by a
+// customer, together with the modifiers or combo components chosen for it.
+type OrderLine struct {
+	MenuItemId  int
+	ComboId     int
+	Quantity    int
+	ModifierIds []int
+	Components  []ComboChoice
+}
+
+// ComboChoice is the menu item picked for a slot of a combo.
+type ComboChoice struct {
+	SlotId     int
+	MenuItemId int
+}
+
+// PlaceOrder is a customer's request to place an order.
+type PlaceOrder struct {
+	UserId        int
+	Lines         []OrderLine
+	PaymentMethod string
+	PickupSlotId  *int
+	CouponCode    string
+	RedeemPoints  int
+}
+
+// OrderService owns the order lifecycle: placement, customer edits and the
+// status state machine. Every mutation runs in a single store transaction.
+// Without a payment provider card payments are turned off.
+type OrderService struct {
+	store    store.Store
+	payments payment.Provider
+	hours    OpeningHours
+	loyalty  LoyaltyProgram
+}
+
+// NewOrderService ...
+func NewOrderService(st store.Store, payments payment.Provider, hours OpeningHours, loyalty LoyaltyProgram) *OrderService {
+	return &OrderService{
+		store:    st,
+		payments: payments,
+		hours:    hours,
+		loyalty:  loyalty,
+	}
+}
+
+// Place creates a new order in the placed status together with its items
+// and collects payment for it. Card payments are taken after the order is
+// committed.
+func (s *OrderService) Place(ctx context.Context, req *PlaceOrder) (*model.Order, []*model.OrderItem, error) {
+	if err := validateLines(req.Lines); err != nil {
+		return nil, nil, err
+	}
+
+	if req.PaymentMethod == "" {
+		req.PaymentMethod = model.PaymentMethodWallet
+	}
+
+	if !model.IsValidPaymentMethod(req.PaymentMethod) {
+		return nil, nil, ErrUnknownPaymentMethod
+	}
+
+	if req.PaymentMethod == model.PaymentMethodCard && s.payments == nil {
+		return nil, nil, ErrCardPaymentsDisabled
+	}
+
+	o := &model.Order{
+		UserId:        req.UserId,
+		Status:        model.OrderStatusPlaced,
+		PaymentMethod: req.PaymentMethod,
+		PaymentStatus: model.PaymentStatusPending,
+		PickupSlotId:  req.PickupSlotId,
+		CouponCode:    req.CouponCode,
+	}
+	var orderItems []*model.OrderItem
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		now := time.Now()
+		items, err := priceLines(tx, req.Lines, now)
+		if err != nil {
+			return err
+		}
+
+		if err := reserveStock(tx, items); err != nil {
+			return err
+		}
+
+		if err := reserveSlot(tx, o, itemCount(items), s.hours); err != nil {
+			return err
+		}
+
+		pricing, err := priceOrder(tx, req.UserId, items, req.CouponCode, now)
+		if err != nil {
+			return err
+		}
+
+		points, err := s.loyalty.redeemPoints(tx, req.UserId, pricing, req.RedeemPoints, now)
+		if err != nil {
+			return err
+		}
+
+		o.TotalAmount = pricing.Total
+		o.DiscountAmount = pricing.DiscountAmount()
+		if err := tx.Order().Create(o); err != nil {
+			return err
+		}
+
+		if err := loyaltyEntry(tx, o, model.LoyaltyEntryRedeem, -points); err != nil {
+			return err
+		}
+
+		if err := saveDiscounts(tx, o.ID, pricing.Discounts); err != nil {
+			return err
+		}
+		o.Discounts = pricing.Discounts
+
+		if err := recordStatusChange(tx, o.ID, "", model.OrderStatusPlaced, req.UserId); err != nil {
+			return err
+		}
+
+		if err := createOrderItems(tx, o.ID, items); err != nil {
+			return err
+		}
+		orderItems = items
+
+		return collectPayment(tx, o)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The card is charged outside the transaction so that a failed commit
+	// can never leave a charged card behind. An order the card does not pay
+	// for is cancelled again.
+	if o.PaymentMethod == model.PaymentMethodCard {
+		if err := s.chargeCard(ctx, o); err != nil {
+			if abandonErr := s.abandonOrder(ctx, o); abandonErr != nil {
+				return nil, nil, fmt.Errorf("%w (cancel order %d: %v)", err, o.ID, abandonErr)
+			}
+
+			return nil, nil, err
+		}
+
+		if err := s.recordCharge(ctx, o); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return o, orderItems, nil
+}
+
+// Update replaces the items of an order that is still placed.
+func (s *OrderService) Update(ctx context.Context, orderId int, lines []OrderLine) (*model.Order, []*model.OrderItem, error) {
+	if err := validateLines(lines); err != nil {
+		return nil, nil, err
+	}
+
+	var o *model.Order
+	var orderItems []*model.OrderItem
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		o, err = tx.Order().FindForUpdate(orderId)
+		if err != nil {
+			return err
+		}
+
+		if !o.IsEditable() {
+			return ErrOrderNotEditable
+		}
+
+		if o.PaymentMethod == model.PaymentMethodCard {
+			return ErrCardOrderNotEditable
+		}
+
+		now := time.Now()
+		items, err := priceLines(tx, lines, now)
+		if err != nil {
+			return err
+		}
+
+		// The order's own discounts must not count against usage limits
+		// while it is repriced.
+		if err := tx.Promotion().DeleteDiscountsByOrder(orderId); err != nil {
+			return err
+		}
+
+		pricing, err := priceOrder(tx, o.UserId, items, o.CouponCode, now)
+		if err != nil {
+			return err
+		}
+
+		// Points already redeemed on the order stay redeemed as far as the
+		// new total allows; the rest goes back to the customer.
+		redeemed, err := redeemedPoints(tx, orderId)
+		if err != nil {
+			return err
+		}
+
+		if redeemed > 0 && s.loyalty.PointValue > 0 {
+			used := pricing.applyPoints(o.UserId, redeemed, s.loyalty.PointValue)
+			if err := loyaltyEntry(tx, o, model.LoyaltyEntryRefund, redeemed-used); err != nil {
+				return err
+			}
+		}
+
+		o.TotalAmount = pricing.Total
+		o.DiscountAmount = pricing.DiscountAmount()
+		if err := saveDiscounts(tx, orderId, pricing.Discounts); err != nil {
+			return err
+		}
+		o.Discounts = pricing.Discounts
+
+		if err := releaseStock(tx, orderId); err != nil {
+			return err
+		}
+
+		if err := releaseSlot(tx, o); err != nil {
+			return err
+		}
+
+		if err := reserveStock(tx, items); err != nil {
+			return err
+		}
+
+		if err := reserveSlot(tx, o, itemCount(items), s.hours); err != nil {
+			return err
+		}
+
+		if err := tx.OrderItem().DeleteAllOrder(orderId); err != nil {
+			return err
+		}
+
+		if err := createOrderItems(tx, orderId, items); err != nil {
+			return err
+		}
+		orderItems = items
+
+		if err := tx.Order().Update(orderId, o.TotalAmount, o.DiscountAmount); err != nil {
+			return err
+		}
+
+		if o.PaymentMethod == model.PaymentMethodWallet {
+			return chargeOrder(tx, o)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return o, orderItems, nil
+}
+
+// Delete removes an order that is still placed.
+func (s *OrderService) Delete(ctx context.Context, orderId int) error {
+	var o *model.Order
+	var refund *model.PaymentRefund
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		o, err = tx.Order().FindForUpdate(orderId)
+		if err != nil {
+			return err
+		}
+
+		if !o.IsEditable() {
+			return ErrOrderNotEditable
+		}
+
+		refund, err = releaseOrder(tx, o)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.OrderItem().DeleteAllOrder(orderId); err != nil {
+			return err
+		}
+
+		return tx.Order().Delete(orderId)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.settle(ctx, o, refund)
+
+	return nil
+}
+
+// ChangeStatus moves the order to the given status on behalf of actor and
+// records the change in the order history.
+func (s *OrderService) ChangeStatus(ctx context.Context, orderId int, actor *model.User, status string) (*model.Order, error) {
+	if !model.IsValidOrderStatus(status) {
+		return nil, ErrUnknownStatus
+	}
+
+	return s.transition(ctx, orderId, actor, status, func(o *model.Order) error {
+		if !o.CanTransitionTo(status) {
+			return ErrInvalidTransition
+		}
+
+		if status == model.OrderStatusAccepted && !o.IsReleasedToKitchen() {
+			return ErrPaymentPending
+		}
+
+		return nil
+	})
+}
+
+// Cancel cancels the order on behalf of an admin. Unlike ChangeStatus it
+// also cancels orders that are ready for pickup; only finished orders are
+// out of reach. Payment, stock and the rest are given back as for any
+// cancellation.
+func (s *OrderService) Cancel(ctx context.Context, orderId int, actor *model.User) (*model.Order, error) {
+	return s.transition(ctx, orderId, actor, model.OrderStatusCancelled, func(o *model.Order) error {
+		if o.IsFinished() {
+			return ErrInvalidTransition
+		}
+
+		return nil
+	})
+}
+
+// Refund gives back the payment of a picked up order on behalf of an admin
+// and takes back the loyalty points the order earned, as far as they have
+// not been spent. Cash is assumed to be handed back at the counter.
+func (s *OrderService) Refund(ctx context.Context, orderId int, actor *model.User) (*model.Order, error) {
+	var o *model.Order
+	var refund *model.PaymentRefund
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		o, err = tx.Order().FindForUpdate(orderId)
+		if err != nil {
+			return err
+		}
+
+		if o.Status != model.OrderStatusPickedUp {
+			return ErrOrderNotRefundable
+		}
+
+		if o.IsRefunded() {
+			return ErrAlreadyRefunded
+		}
+
+		if o.PaymentMethod == model.PaymentMethodCash {
+			o.PaymentStatus = model.PaymentStatusRefunded
+			if err := tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef); err != nil {
+				return err
+			}
+		} else if refund, err = refundPayment(tx, o); err != nil {
+			return err
+		}
+
+		return revokePoints(tx, o, actor)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.settle(ctx, o, refund)
+
+	return o, nil
+}
+
+// transition moves the order to status once check accepts the order as it
+// is, applies the side effects of the new status and records the change.
+func (s *OrderService) transition(ctx context.Context, orderId int, actor *model.User, status string, check func(*model.Order) error) (*model.Order, error) {
+	var o *model.Order
+	var refund *model.PaymentRefund
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		var err error
+		o, err = tx.Order().FindForUpdate(orderId)
+		if err != nil {
+			return err
+		}
+
+		if err := check(o); err != nil {
+			return err
+		}
+
+		if err := tx.Order().UpdateStatus(orderId, status); err != nil {
+			return err
+		}
+
+		switch status {
+		case model.OrderStatusCancelled, model.OrderStatusRejected:
+			refund, err = releaseOrder(tx, o)
+			if err != nil {
+				return err
+			}
+		case model.OrderStatusPickedUp:
+			// Cash is handed over at the counter.
+			if o.PaymentMethod == model.PaymentMethodCash {
+				o.PaymentStatus = model.PaymentStatusCaptured
+				if err := tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef); err != nil {
+					return err
+				}
+			}
+
+			if err := s.loyalty.earnPoints(tx, o, time.Now()); err != nil {
+				return err
+			}
+		}
+
+		if err := recordStatusChange(tx, orderId, o.Status, status, actor.ID); err != nil {
+			return err
+		}
+
+		o.Status = status
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.settle(ctx, o, refund)
+
+	return o, nil
+}
+
+// settle sends the card refund recorded for o, if any, once its transaction
+// has committed and updates o with the result. A refund the provider turns
+// down stays pending with its error for RetryRefunds, and o keeps the
+// refund_pending payment status.
+func (s *OrderService) settle(ctx context.Context, o *model.Order, refund *model.PaymentRefund) {
+	if refund == nil {
+		return
+	}
+
+	if err := s.settleRefund(ctx, refund); err == nil {
+		o.PaymentStatus = model.PaymentStatusRefunded
+	}
+}
+
+// cancelOrder cancels the order on behalf of the user with actorId, gives
+// back everything it held and records the change. It returns the card
+// refund to settle after the commit, if any.
+func (s *OrderService) cancelOrder(tx store.Store, o *model.Order, actorId int) (*model.PaymentRefund, error) {
+	if err := tx.Order().UpdateStatus(o.ID, model.OrderStatusCancelled); err != nil {
+		return nil, err
+	}
+
+	refund, err := releaseOrder(tx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := recordStatusChange(tx, o.ID, o.Status, model.OrderStatusCancelled, actorId); err != nil {
+		return nil, err
+	}
+
+	o.Status = model.OrderStatusCancelled
+	return refund, nil
+}
+
+// releaseOrder gives back the stock, pickup slot, payment and points held by
+// an order that is cancelled, rejected or deleted.
+func releaseOrder(tx store.Store, o *model.Order) (*model.PaymentRefund, error) {
+	if err := releaseStock(tx, o.ID); err != nil {
+		return nil, err
+	}
+
+	if err := releaseSlot(tx, o); err != nil {
+		return nil, err
+	}
+
+	refund, err := refundPayment(tx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := refundPoints(tx, o); err != nil {
+		return nil, err
+	}
+
+	// The discounts stay on record; promotion uses are only counted for
+	// orders that are not cancelled or rejected.
+	return refund, nil
+}
+
+// History returns the status changes of an order, oldest first.
+func (s *OrderService) History(orderId int) ([]*model.OrderStatusChange, error) {
+	if _, err := s.store.Order().Find(orderId); err != nil {
+		return nil, err
+	}
+
+	return s.store.OrderStatusHistory().GetByOrder(orderId)
+}
+
+func validateLines(lines []OrderLine) error {
+	if len(lines) == 0 {
+		return ErrEmptyOrder
+	}
+
+	for _, l := range lines {
+		if l.Quantity <= 0 {
+			return ErrNonPositiveQuantity
+		}
+
+		if (l.MenuItemId == 0) == (l.ComboId == 0) {
+			return ErrInvalidOrderLine
+		}
+	}
+
+	return nil
+}
+
+// priceLines validates the chosen modifiers of every line, checks that the
+// items are on the menu at now and snapshots the item name and prices into
+// unsaved order items. Lookups use the given store so that they happen inside
+// the caller's transaction.
+func priceLines(st store.Store, lines []OrderLine, now time.Time) ([]*model.OrderItem, error) {
+	availability, err := LoadAvailability(st)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*model.OrderItem, 0, len(lines))
+	for _, l := range lines {
+		if l.ComboId != 0 {
+			oi, err := priceCombo(st, l, availability, now)
+			if err != nil {
+				return nil, err
+			}
+
+			items = append(items, oi)
+			continue
+		}
+
+		mi, err := st.MenuItem().Find(l.MenuItemId)
+		if err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				return nil, ErrUnknownMenuItem
+			}
+
+			return nil, err
+		}
+
+		if !availability.ItemAvailableAt(mi, now) {
+			return nil, fmt.Errorf("%s: %w", mi.Name, ErrNotOnMenu)
+		}
+
+		groups, err := st.Modifier().FindGroupsByMenuItem(l.MenuItemId)
+		if err != nil {
+			return nil, err
+		}
+
+		modifiers, err := chooseModifiers(groups, l.ModifierIds)
+		if err != nil {
+			return nil, fmt.Errorf("menu item %d: %w", l.MenuItemId, err)
+		}
+
+		oi := &model.OrderItem{
+			MenuItemId: l.MenuItemId,
+			ItemName:   mi.Name,
+			Quantity:   l.Quantity,
+			UnitPrice:  mi.Price,
+			Nutrition:  mi.Nutrition,
+		}
+
+		for _, m := range modifiers {
+			oi.UnitPrice += m.PriceDelta
+			oi.Modifiers = append(oi.Modifiers, &model.OrderItemModifier{
+				ModifierId: m.ID,
+				Name:       m.Name,
+				PriceDelta: m.PriceDelta,
+			})
+		}
+
+		// Discounts are the job of promotions; a line never pays out.
+		if oi.UnitPrice < 0 {
+			return nil, fmt.Errorf("menu item %d: %w", l.MenuItemId, ErrNegativePrice)
+		}
+		oi.LineTotal = oi.UnitPrice * oi.Quantity
+
+		items = append(items, oi)
+	}
+
+	return items, nil
+}
+
+// priceCombo checks that the line picks one allowed menu item for every slot
+// of the combo and snapshots the picked dishes into an unsaved order item.
+func priceCombo(st store.Store, l OrderLine, availability *Availability, now time.Time) (*model.OrderItem, error) {
+	c, err := st.Combo().Find(l.ComboId)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return nil, ErrUnknownCombo
+		}
+
+		return nil, err
+	}
+
+	if !availability.CategoryAvailableAt(c.CategoryID, now) {
+		return nil, fmt.Errorf("%s: %w", c.Name, ErrNotOnMenu)
+	}
+
+	if len(l.ModifierIds) > 0 {
+		return nil, fmt.Errorf("combo %d: %w", c.ID, ErrInvalidModifier)
+	}
+
+	picked := make(map[int]int, len(l.Components))
+	for _, choice := range l.Components {
+		if _, ok := picked[choice.SlotId]; ok {
+			return nil, fmt.Errorf("combo %d: %w", c.ID, ErrInvalidComboChoice)
+		}
+		picked[choice.SlotId] = choice.MenuItemId
+	}
+
+	if len(picked) != len(c.Slots) {
+		return nil, fmt.Errorf("combo %d: %w", c.ID, ErrInvalidComboChoice)
+	}
+
+	oi := &model.OrderItem{
+		ComboId:   c.ID,
+		ItemName:  c.Name,
+		Quantity:  l.Quantity,
+		UnitPrice: c.Price,
+		LineTotal: c.Price * l.Quantity,
+	}
+
+	var dishes []*model.MenuItem
+	for _, slot := range c.Slots {
+		menuItemId, ok := picked[slot.ID]
+		if !ok {
+			return nil, fmt.Errorf("combo %d: %w", c.ID, ErrInvalidComboChoice)
+		}
+
+		mi, err := st.MenuItem().Find(menuItemId)
+		if err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				return nil, ErrUnknownMenuItem
+			}
+
+			return nil, err
+		}
+
+		if !slot.Allows(mi) {
+			return nil, fmt.Errorf("combo %d, %s: %w", c.ID, slot.Name, ErrInvalidComboChoice)
+		}
+
+		if !availability.ItemAvailableAt(mi, now) {
+			return nil, fmt.Errorf("%s, %s: %w", c.Name, mi.Name, ErrNotOnMenu)
+		}
+
+		oi.Components = append(oi.Components, &model.OrderItemComponent{
+			ComboSlotId: slot.ID,
+			SlotName:    slot.Name,
+			MenuItemId:  mi.ID,
+			ItemName:    mi.Name,
+		})
+		dishes = append(dishes, mi)
+	}
+	oi.Nutrition = model.SumNutrition(dishes)
+
+	return oi, nil
+}
+
+// chooseModifiers resolves the chosen modifier ids against the groups of a
+// menu item and checks every group's selection limits.
+func chooseModifiers(groups []*model.ModifierGroup, ids []int) ([]*model.Modifier, error) {
+	byID := make(map[int]*model.Modifier)
+	for _, g := range groups {
+		for _, m := range g.Modifiers {
+			byID[m.ID] = m
+		}
+	}
+
+	chosen := make(map[int]bool, len(ids))
+	perGroup := make(map[int]int)
+	modifiers := make([]*model.Modifier, 0, len(ids))
+	for _, id := range ids {
+		m, ok := byID[id]
+		if !ok {
+			return nil, ErrInvalidModifier
+		}
+
+		if chosen[id] {
+			return nil, ErrDuplicateModifier
+		}
+
+		chosen[id] = true
+		perGroup[m.GroupId]++
+		modifiers = append(modifiers, m)
+	}
+
+	for _, g := range groups {
+		if n := perGroup[g.ID]; n < g.MinSelect || n > g.MaxSelect {
+			return nil, fmt.Errorf("%s: %w", g.Name, ErrModifierSelection)
+		}
+	}
+
+	return modifiers, nil
+}
+
+// reserveStock takes the ordered portions out of inventory. Combos take a
+// portion of every picked dish.
+func reserveStock(st store.Store, items []*model.OrderItem) error {
+	for _, item := range items {
+		for _, menuItemId := range item.DishIds() {
+			if err := st.MenuItem().DecrementStock(menuItemId, item.Quantity); err != nil {
+				if errors.Is(err, store.ErrOutOfStock) {
+					return fmt.Errorf("menu item %d: %w", menuItemId, ErrSoldOut)
+				}
+
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// releaseStock puts the portions of an existing order back into inventory.
+func releaseStock(st store.Store, orderId int) error {
+	items, err := st.OrderItem().GetOrderItems(orderId)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		for _, menuItemId := range item.DishIds() {
+			if err := st.MenuItem().IncrementStock(menuItemId, item.Quantity); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func createOrderItems(st store.Store, orderId int, items []*model.OrderItem) error {
+	for _, oi := range items {
+		oi.OrderId = orderId
+		if err := st.OrderItem().Create(oi); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func recordStatusChange(st store.Store, orderId int, from, to string, actorId int) error {
+	return st.OrderStatusHistory().Create(&model.OrderStatusChange{
+		OrderId:    orderId,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedBy:  actorId,
+		ChangedAt:  time.Now(),
+	})
+}
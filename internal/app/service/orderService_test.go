@@ -0,0 +1,568 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"sync"
+	"testing"
+)
+
+func TestOrderService_Place(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 300, 10)
+
+	_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	assert.ErrorIs(t, err, service.ErrEmptyOrder)
+
+	_, _, err = orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{line(m.ID, 0)},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	assert.ErrorIs(t, err, service.ErrNonPositiveQuantity)
+
+	_, _, err = orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{line(m.ID, 1), line(m.ID+100, 1)},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	assert.ErrorIs(t, err, service.ErrUnknownMenuItem)
+
+	o, items, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{line(m.ID, 2)},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPlaced, o.Status)
+	assert.Equal(t, 600, o.TotalAmount)
+	require.Len(t, items, 1)
+	assert.Equal(t, o.ID, items[0].OrderId)
+}
+
+func TestOrderService_ChangeStatus_Lifecycle(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	staff := testUser(t, st, model.RoleStaff)
+	m := testMenuItem(t, st, 300, 10)
+	o := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 1))
+
+	for _, status := range []string{
+		model.OrderStatusAccepted,
+		model.OrderStatusPreparing,
+		model.OrderStatusReady,
+		model.OrderStatusPickedUp,
+	} {
+		changed, err := orders.ChangeStatus(context.Background(), o.ID, staff, status)
+		require.NoError(t, err, status)
+		assert.Equal(t, status, changed.Status)
+	}
+
+	assert.Equal(t, model.OrderStatusPickedUp, findOrder(t, st, o.ID).Status)
+
+	history, err := orders.History(o.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 5)
+	assert.Equal(t, "", history[0].FromStatus)
+	assert.Equal(t, model.OrderStatusPlaced, history[0].ToStatus)
+	assert.Equal(t, u.ID, history[0].ChangedBy)
+	assert.Equal(t, model.OrderStatusReady, history[4].FromStatus)
+	assert.Equal(t, model.OrderStatusPickedUp, history[4].ToStatus)
+	assert.Equal(t, staff.ID, history[4].ChangedBy)
+}
+
+func TestOrderService_ChangeStatus_Invalid(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	staff := testUser(t, st, model.RoleStaff)
+	m := testMenuItem(t, st, 300, 10)
+	o := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 1))
+
+	_, err := orders.ChangeStatus(context.Background(), o.ID, staff, "eaten")
+	assert.ErrorIs(t, err, service.ErrUnknownStatus)
+
+	_, err = orders.ChangeStatus(context.Background(), o.ID, staff, model.OrderStatusReady)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+
+	_, err = orders.ChangeStatus(context.Background(), o.ID, staff, model.OrderStatusPlaced)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+
+	_, err = orders.ChangeStatus(context.Background(), o.ID+100, staff, model.OrderStatusAccepted)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+
+	assert.Equal(t, model.OrderStatusPlaced, findOrder(t, st, o.ID).Status)
+
+	history, err := orders.History(o.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestOrderService_ChangeStatus_Finished(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	staff := testUser(t, st, model.RoleStaff)
+	m := testMenuItem(t, st, 300, 10)
+	rejected := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 1))
+	ready := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 1))
+
+	_, err := orders.ChangeStatus(context.Background(), rejected.ID, staff, model.OrderStatusRejected)
+	require.NoError(t, err)
+
+	_, err = orders.ChangeStatus(context.Background(), rejected.ID, staff, model.OrderStatusAccepted)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+
+	for _, status := range []string{model.OrderStatusAccepted, model.OrderStatusPreparing, model.OrderStatusReady} {
+		_, err := orders.ChangeStatus(context.Background(), ready.ID, staff, status)
+		require.NoError(t, err)
+	}
+
+	_, err = orders.ChangeStatus(context.Background(), ready.ID, staff, model.OrderStatusCancelled)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+}
+
+func TestOrderService_Update_OnlyWhilePlaced(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	staff := testUser(t, st, model.RoleStaff)
+	m := testMenuItem(t, st, 300, 10)
+	o := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 1))
+
+	updated, _, err := orders.Update(context.Background(), o.ID, []service.OrderLine{line(m.ID, 4)})
+	require.NoError(t, err)
+	assert.Equal(t, 1200, updated.TotalAmount)
+	assert.Equal(t, 6, stockOf(t, st, m.ID))
+
+	_, err = orders.ChangeStatus(context.Background(), o.ID, staff, model.OrderStatusAccepted)
+	require.NoError(t, err)
+
+	_, _, err = orders.Update(context.Background(), o.ID, []service.OrderLine{line(m.ID, 1)})
+	assert.ErrorIs(t, err, service.ErrOrderNotEditable)
+
+	err = orders.Delete(context.Background(), o.ID)
+	assert.ErrorIs(t, err, service.ErrOrderNotEditable)
+	assert.Equal(t, 1200, findOrder(t, st, o.ID).TotalAmount)
+	assert.Equal(t, 6, stockOf(t, st, m.ID))
+}
+
+func TestOrderService_Delete(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 300, 10)
+	o := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 10))
+	assert.Equal(t, 0, stockOf(t, st, m.ID))
+
+	require.NoError(t, orders.Delete(context.Background(), o.ID))
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+
+	_, err := st.Order().Find(o.ID)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestOrderService_Place_SoldOut(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	soup := testMenuItem(t, st, 200, 5)
+	pie := testMenuItem(t, st, 150, 1)
+
+	_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{line(soup.ID, 2), line(pie.ID, 2)},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	assert.ErrorIs(t, err, service.ErrSoldOut)
+	assert.Equal(t, 5, stockOf(t, st, soup.ID))
+	assert.Equal(t, 1, stockOf(t, st, pie.ID))
+
+	place(t, orders, u.ID, model.PaymentMethodCash, line(soup.ID, 2), line(pie.ID, 1))
+	assert.Equal(t, 3, stockOf(t, st, soup.ID))
+	assert.Equal(t, 0, stockOf(t, st, pie.ID))
+}
+
+func TestOrderService_Place_UntrackedStock(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := &model.MenuItem{Name: "tea", Price: 50}
+	require.NoError(t, st.MenuItem().Create(m))
+
+	place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 100))
+
+	stored, err := st.MenuItem().Find(m.ID)
+	require.NoError(t, err)
+	assert.Nil(t, stored.Stock)
+}
+
+func TestOrderService_Place_ConcurrentStock(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 200, 5)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	placed := 0
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+				UserId:        u.ID,
+				Lines:         []service.OrderLine{line(m.ID, 1)},
+				PaymentMethod: model.PaymentMethodCash,
+			})
+			if err == nil {
+				mu.Lock()
+				placed++
+				mu.Unlock()
+			} else {
+				assert.ErrorIs(t, err, service.ErrSoldOut)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, placed)
+	assert.Equal(t, 0, stockOf(t, st, m.ID))
+}
+
+func TestOrderService_Place_Modifiers(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 500, 10)
+	other := testMenuItem(t, st, 200, 10)
+
+	size := &model.ModifierGroup{
+		MenuItemId: m.ID,
+		Name:       "size",
+		MinSelect:  1,
+		MaxSelect:  1,
+		Modifiers:  []*model.Modifier{{Name: "small"}, {Name: "large", PriceDelta: 150}},
+	}
+	extras := &model.ModifierGroup{
+		MenuItemId: m.ID,
+		Name:       "extras",
+		MaxSelect:  2,
+		Modifiers:  []*model.Modifier{{Name: "cheese", PriceDelta: 50}, {Name: "bacon", PriceDelta: 80}, {Name: "egg", PriceDelta: 40}},
+	}
+	sauce := &model.ModifierGroup{
+		MenuItemId: other.ID,
+		Name:       "sauce",
+		MaxSelect:  1,
+		Modifiers:  []*model.Modifier{{Name: "ketchup"}},
+	}
+	for _, g := range []*model.ModifierGroup{size, extras, sauce} {
+		require.NoError(t, st.Modifier().CreateGroup(g))
+	}
+
+	small, large := size.Modifiers[0].ID, size.Modifiers[1].ID
+	cheese, bacon, egg := extras.Modifiers[0].ID, extras.Modifiers[1].ID, extras.Modifiers[2].ID
+
+	for _, tc := range []struct {
+		name        string
+		modifierIds []int
+		err         error
+	}{
+		{"required group skipped", nil, service.ErrModifierSelection},
+		{"two sizes", []int{small, large}, service.ErrModifierSelection},
+		{"too many extras", []int{small, cheese, bacon, egg}, service.ErrModifierSelection},
+		{"duplicate", []int{small, cheese, cheese}, service.ErrDuplicateModifier},
+		{"other menu item", []int{small, sauce.Modifiers[0].ID}, service.ErrInvalidModifier},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+				UserId:        u.ID,
+				Lines:         []service.OrderLine{{MenuItemId: m.ID, Quantity: 1, ModifierIds: tc.modifierIds}},
+				PaymentMethod: model.PaymentMethodCash,
+			})
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+
+	o, items, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{{MenuItemId: m.ID, Quantity: 2, ModifierIds: []int{large, cheese, bacon}}},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2*(500+150+50+80), o.TotalAmount)
+	require.Len(t, items, 1)
+	require.Len(t, items[0].Modifiers, 3)
+	assert.Equal(t, "large", items[0].Modifiers[0].Name)
+	assert.Equal(t, 150, items[0].Modifiers[0].PriceDelta)
+
+	_, _, err = orders.Update(context.Background(), o.ID, []service.OrderLine{{MenuItemId: m.ID, Quantity: 1, ModifierIds: []int{egg}}})
+	assert.ErrorIs(t, err, service.ErrModifierSelection)
+
+	o, _, err = orders.Update(context.Background(), o.ID, []service.OrderLine{{MenuItemId: m.ID, Quantity: 1, ModifierIds: []int{small, egg}}})
+	require.NoError(t, err)
+	assert.Equal(t, 540, o.TotalAmount)
+}
+
+func TestOrderService_Place_Combo(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+
+	soups := &model.Category{Name: "soups"}
+	require.NoError(t, st.Category().Create(soups))
+
+	stock := 10
+	borscht := &model.MenuItem{Name: "borscht", Price: 400, CategoryID: soups.ID, Stock: &stock}
+	require.NoError(t, st.MenuItem().Create(borscht))
+	tea := testMenuItem(t, st, 100, 10)
+	juice := testMenuItem(t, st, 250, 10)
+
+	c := &model.Combo{
+		Name:  "lunch",
+		Price: 450,
+		Slots: []*model.ComboSlot{
+			{Name: "soup", CategoryID: &soups.ID},
+			{Name: "drink", MenuItemIds: []int{tea.ID}},
+		},
+	}
+	require.NoError(t, st.Combo().Create(c))
+	soup, drink := c.Slots[0].ID, c.Slots[1].ID
+
+	for _, tc := range []struct {
+		name string
+		line service.OrderLine
+		err  error
+	}{
+		{"slot skipped", service.OrderLine{ComboId: c.ID, Quantity: 1, Components: []service.ComboChoice{{soup, borscht.ID}}}, service.ErrInvalidComboChoice},
+		{"slot picked twice", service.OrderLine{ComboId: c.ID, Quantity: 1, Components: []service.ComboChoice{{soup, borscht.ID}, {soup, borscht.ID}}}, service.ErrInvalidComboChoice},
+		{"item not allowed", service.OrderLine{ComboId: c.ID, Quantity: 1, Components: []service.ComboChoice{{soup, borscht.ID}, {drink, juice.ID}}}, service.ErrInvalidComboChoice},
+		{"unknown slot", service.OrderLine{ComboId: c.ID, Quantity: 1, Components: []service.ComboChoice{{soup, borscht.ID}, {drink + 100, tea.ID}}}, service.ErrInvalidComboChoice},
+		{"modifiers", service.OrderLine{ComboId: c.ID, Quantity: 1, ModifierIds: []int{1}, Components: []service.ComboChoice{{soup, borscht.ID}, {drink, tea.ID}}}, service.ErrInvalidModifier},
+		{"unknown combo", service.OrderLine{ComboId: c.ID + 100, Quantity: 1}, service.ErrUnknownCombo},
+		{"item and combo", service.OrderLine{MenuItemId: tea.ID, ComboId: c.ID, Quantity: 1}, service.ErrInvalidOrderLine},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+				UserId:        u.ID,
+				Lines:         []service.OrderLine{tc.line},
+				PaymentMethod: model.PaymentMethodCash,
+			})
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+
+	o, items, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId: u.ID,
+		Lines: []service.OrderLine{
+			{ComboId: c.ID, Quantity: 2, Components: []service.ComboChoice{{drink, tea.ID}, {soup, borscht.ID}}},
+			line(juice.ID, 1),
+		},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2*450+250, o.TotalAmount)
+	require.Len(t, items, 2)
+	assert.Equal(t, "lunch", items[0].ItemName)
+	assert.Equal(t, 900, items[0].LineTotal)
+	require.Len(t, items[0].Components, 2)
+	assert.Equal(t, "borscht", items[0].Components[0].ItemName)
+	assert.Equal(t, "drink", items[0].Components[1].SlotName)
+
+	assert.Equal(t, 8, stockOf(t, st, borscht.ID))
+	assert.Equal(t, 8, stockOf(t, st, tea.ID))
+	assert.Equal(t, 9, stockOf(t, st, juice.ID))
+
+	require.NoError(t, orders.Delete(context.Background(), o.ID))
+	assert.Equal(t, 10, stockOf(t, st, borscht.ID))
+	assert.Equal(t, 10, stockOf(t, st, tea.ID))
+}
+
+func TestOrderService_Place_NutritionSummary(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+
+	soup := &model.MenuItem{Name: "soup", Price: 300, Nutrition: &model.Nutrition{Kcal: 250, Protein: 10.1, Carbs: 30.2, Fat: 5.5, Salt: 1.1}}
+	require.NoError(t, st.MenuItem().Create(soup))
+	tea := &model.MenuItem{Name: "tea", Price: 100, Nutrition: &model.Nutrition{Kcal: 40, Carbs: 10.1}}
+	require.NoError(t, st.MenuItem().Create(tea))
+	bread := &model.MenuItem{Name: "bread", Price: 50}
+	require.NoError(t, st.MenuItem().Create(bread))
+
+	lunch := &model.Combo{Name: "lunch", Price: 350, Slots: []*model.ComboSlot{
+		{Name: "soup", MenuItemIds: []int{soup.ID}},
+		{Name: "drink", MenuItemIds: []int{tea.ID}},
+	}}
+	require.NoError(t, st.Combo().Create(lunch))
+	snack := &model.Combo{Name: "snack", Price: 120, Slots: []*model.ComboSlot{
+		{Name: "bread", MenuItemIds: []int{bread.ID}},
+		{Name: "drink", MenuItemIds: []int{tea.ID}},
+	}}
+	require.NoError(t, st.Combo().Create(snack))
+
+	_, items, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId: u.ID,
+		Lines: []service.OrderLine{
+			line(soup.ID, 2),
+			{ComboId: lunch.ID, Quantity: 1, Components: []service.ComboChoice{{lunch.Slots[0].ID, soup.ID}, {lunch.Slots[1].ID, tea.ID}}},
+			{ComboId: snack.ID, Quantity: 1, Components: []service.ComboChoice{{snack.Slots[0].ID, bread.ID}, {snack.Slots[1].ID, tea.ID}}},
+			line(bread.ID, 3),
+		},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	require.NoError(t, err)
+	require.Len(t, items, 4)
+
+	assert.Equal(t, soup.Nutrition, items[0].Nutrition)
+	assert.Equal(t, &model.Nutrition{Kcal: 290, Protein: 10.1, Carbs: 40.3, Fat: 5.5, Salt: 1.1}, items[1].Nutrition)
+	assert.Nil(t, items[2].Nutrition, "a combo with an unknown dish has no nutrition")
+	assert.Nil(t, items[3].Nutrition)
+
+	// Editing the menu afterwards does not change what was eaten.
+	require.NoError(t, st.MenuItem().SetNutrition(soup.ID, &model.Nutrition{Kcal: 1}))
+
+	stored, err := st.OrderItem().GetOrderItems(items[0].OrderId)
+	require.NoError(t, err)
+
+	total := model.OrderNutrition(stored)
+	assert.Equal(t, 2*250+290, total.Kcal)
+	assert.Equal(t, 30.3, total.Protein)
+	assert.Equal(t, 100.7, total.Carbs)
+	assert.Equal(t, 7, total.Portions)
+	assert.Equal(t, 4, total.UnknownPortions)
+}
+
+func advance(t *testing.T, orders *service.OrderService, orderId int, actor *model.User, statuses ...string) {
+	t.Helper()
+
+	for _, status := range statuses {
+		_, err := orders.ChangeStatus(context.Background(), orderId, actor, status)
+		require.NoError(t, err)
+	}
+}
+
+func TestOrderService_Cancel(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	admin := testUser(t, st, model.RoleAdmin)
+	m := testMenuItem(t, st, 300, 10)
+
+	ready := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 2))
+	advance(t, orders, ready.ID, admin, model.OrderStatusAccepted, model.OrderStatusPreparing, model.OrderStatusReady)
+
+	_, err := orders.ChangeStatus(context.Background(), ready.ID, admin, model.OrderStatusCancelled)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+
+	o, err := orders.Cancel(context.Background(), ready.ID, admin)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCancelled, o.Status)
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+
+	_, err = orders.Cancel(context.Background(), ready.ID, admin)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+
+	done := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 1))
+	advance(t, orders, done.ID, admin, model.OrderStatusAccepted, model.OrderStatusPreparing, model.OrderStatusReady, model.OrderStatusPickedUp)
+
+	_, err = orders.Cancel(context.Background(), done.ID, admin)
+	assert.ErrorIs(t, err, service.ErrInvalidTransition)
+
+	_, err = orders.Cancel(context.Background(), done.ID+100, admin)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestOrderService_Refund(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	wallets := service.NewWalletService(st)
+	loyalty := service.NewLoyaltyService(st)
+	u := testUser(t, st, model.RoleUser)
+	admin := testUser(t, st, model.RoleAdmin)
+	m := testMenuItem(t, st, 1000, 10)
+	topUp(t, st, u.ID, 2000)
+
+	o := place(t, orders, u.ID, model.PaymentMethodWallet, line(m.ID, 2))
+	assert.Equal(t, 0, balanceOf(t, wallets, u.ID))
+
+	_, err := orders.Refund(context.Background(), o.ID, admin)
+	assert.ErrorIs(t, err, service.ErrOrderNotRefundable)
+
+	advance(t, orders, o.ID, admin, model.OrderStatusAccepted, model.OrderStatusPreparing, model.OrderStatusReady, model.OrderStatusPickedUp)
+
+	l, err := loyalty.Get(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 20, l.Balance)
+
+	// Points already spent stay spent; only the rest is taken back.
+	_, err = loyalty.Adjust(context.Background(), u.ID, -15, admin, "")
+	require.NoError(t, err)
+
+	o, err = orders.Refund(context.Background(), o.ID, admin)
+	require.NoError(t, err)
+	assert.Equal(t, model.PaymentStatusRefunded, o.PaymentStatus)
+	assert.Equal(t, 2000, balanceOf(t, wallets, u.ID))
+	assert.Equal(t, 8, stockOf(t, st, m.ID), "picked up food is not restocked")
+
+	l, err = loyalty.Get(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, l.Balance)
+
+	_, err = orders.Refund(context.Background(), o.ID, admin)
+	assert.ErrorIs(t, err, service.ErrAlreadyRefunded)
+
+	cash := place(t, orders, u.ID, model.PaymentMethodCash, line(m.ID, 1))
+	advance(t, orders, cash.ID, admin, model.OrderStatusAccepted, model.OrderStatusPreparing, model.OrderStatusReady, model.OrderStatusPickedUp)
+
+	cash, err = orders.Refund(context.Background(), cash.ID, admin)
+	require.NoError(t, err)
+	assert.Equal(t, model.PaymentStatusRefunded, findOrder(t, st, cash.ID).PaymentStatus)
+	assert.Equal(t, 2000, balanceOf(t, wallets, u.ID))
+}
+
+func TestOrderService_Place_ModifierPriceFloor(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 300, 10)
+
+	g := &model.ModifierGroup{MenuItemId: m.ID, Name: "Size", MinSelect: 0, MaxSelect: 1}
+	require.NoError(t, st.Modifier().CreateGroup(g))
+	free := &model.Modifier{GroupId: g.ID, Name: "Staff meal", PriceDelta: -300}
+	require.NoError(t, st.Modifier().CreateModifier(free))
+	tooMuch := &model.Modifier{GroupId: g.ID, Name: "Mistake", PriceDelta: -500}
+	require.NoError(t, st.Modifier().CreateModifier(tooMuch))
+
+	_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{{MenuItemId: m.ID, Quantity: 2, ModifierIds: []int{tooMuch.ID}}},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	assert.ErrorIs(t, err, service.ErrNegativePrice)
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+
+	o, items, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{{MenuItemId: m.ID, Quantity: 2, ModifierIds: []int{free.ID}}},
+		PaymentMethod: model.PaymentMethodCash,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, o.TotalAmount)
+	require.Len(t, items, 1)
+	assert.Equal(t, 0, items[0].UnitPrice)
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/yeboka/final-project/internal/app/auth"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/notify"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PasswordService changes and resets passwords. Either way every other
+// session of the user is revoked and outstanding reset tokens stop working.
+type PasswordService struct {
+	store    store.Store
+	sessions *AuthService
+	notifier notify.Notifier
+	resetTTL time.Duration
+	resetURL string
+}
+
+// NewPasswordService ...
+func NewPasswordService(st store.Store, sessions *AuthService, notifier notify.Notifier, resetTTL time.Duration, resetURL string) *PasswordService {
+	return &PasswordService{
+		store:    st,
+		sessions: sessions,
+		notifier: notifier,
+		resetTTL: resetTTL,
+		resetURL: resetURL,
+	}
+}
+
+// Change replaces the password of the user, who has to know the current
+// one. The session keepId the change is made from stays active.
+func (s *PasswordService) Change(ctx context.Context, u *model.User, current string, password string, keepId int) error {
+	if !u.ComparePassword(current) {
+		return ErrWrongPassword
+	}
+
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	return s.store.WithTx(ctx, func(tx store.Store) error {
+		if err := updatePassword(tx, u, password); err != nil {
+			return err
+		}
+
+		return s.sessions.revokeSessions(tx, u.ID, keepId)
+	})
+}
+
+// RequestReset sends a reset link to the user with the email address.
+// Unknown addresses are ignored so that the answer does not tell which
+// addresses have an account. Links sent before stop working.
+func (s *PasswordService) RequestReset(ctx context.Context, email string) error {
+	u, err := s.store.User().FindByEmail(email)
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	token, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	pr := &model.PasswordReset{
+		UserId:    u.ID,
+		TokenHash: auth.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.resetTTL),
+	}
+
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.PasswordReset().UseUser(u.ID, now); err != nil {
+			return err
+		}
+
+		return tx.PasswordReset().Create(pr)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(ctx, &notify.Message{
+		To:      u.Email,
+		Subject: "Reset your password",
+		Body: "Someone asked to reset the password of your account. If it was you, follow the link " +
+			"below within " + strconv.Itoa(int(s.resetTTL.Minutes())) + " minutes to choose a new one; otherwise ignore this message.\n\n" +
+			s.resetLink(token),
+	})
+}
+
+// Reset sets a new password with a token from a reset link and signs the
+// user out everywhere.
+func (s *PasswordService) Reset(ctx context.Context, token string, password string) error {
+	if err := validatePassword(password); err != nil {
+		return err
+	}
+
+	pr, err := s.store.PasswordReset().FindByHash(auth.HashToken(token))
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return ErrInvalidResetToken
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !pr.IsActiveAt(time.Now()) {
+		return ErrInvalidResetToken
+	}
+
+	u, err := s.store.User().Find(pr.UserId)
+	if err != nil {
+		return err
+	}
+
+	return s.store.WithTx(ctx, func(tx store.Store) error {
+		used, err := tx.PasswordReset().Use(pr.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !used {
+			return ErrInvalidResetToken
+		}
+
+		if err := updatePassword(tx, u, password); err != nil {
+			return err
+		}
+
+		return s.sessions.revokeSessions(tx, u.ID, 0)
+	})
+}
+
+// validatePassword checks a new password before anything is changed, so
+// that a rejected one does not revoke sessions or use up a reset token.
+func validatePassword(password string) error {
+	return (&model.User{Password: password}).ValidatePassword()
+}
+
+// updatePassword stores the new password of the user and invalidates their
+// reset tokens.
+func updatePassword(st store.Store, u *model.User, password string) error {
+	u.Password = password
+	if err := st.User().UpdatePassword(u); err != nil {
+		return err
+	}
+
+	return st.PasswordReset().UseUser(u.ID, time.Now())
+}
+
+// resetLink returns the link of a reset token, or the bare token when no
+// reset page is configured.
+func (s *PasswordService) resetLink(token string) string {
+	if s.resetURL == "" {
+		return "Reset token: " + token
+	}
+
+	return s.resetURL + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,131 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/notify"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"testing"
+	"time"
+)
+
+const resetURL = "http://localhost/reset"
+
+func TestPasswordService_Change(t *testing.T) {
+	st := teststore.New()
+	sessions := newAuthService(t, st)
+	passwords := service.NewPasswordService(st, sessions, notify.NewOutbox(), time.Hour, resetURL)
+	u := testUser(t, st, model.RoleUser)
+	current := login(t, sessions, u)
+	other := login(t, sessions, u)
+
+	err := passwords.Change(context.Background(), u, "wrong", "new-password", current.Session.ID)
+	assert.ErrorIs(t, err, service.ErrWrongPassword)
+
+	require.NoError(t, passwords.Change(context.Background(), u, "password", "new-password", current.Session.ID))
+
+	_, err = sessions.AuthenticateSession(current.SessionToken)
+	assert.NoError(t, err)
+	_, err = sessions.AuthenticateSession(other.SessionToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+
+	stored, err := st.User().Find(u.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.ComparePassword("new-password"))
+}
+
+func TestPasswordService_RejectsInvalidPassword(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	sessions := newAuthService(t, st)
+	passwords := service.NewPasswordService(st, sessions, outbox, time.Hour, resetURL)
+	u := testUser(t, st, model.RoleUser)
+	current := login(t, sessions, u)
+	other := login(t, sessions, u)
+
+	require.NoError(t, passwords.RequestReset(context.Background(), u.Email))
+	token := mailedToken(t, outbox, u.Email)
+
+	for _, password := range []string{"", "short"} {
+		err := passwords.Change(context.Background(), u, "password", password, current.Session.ID)
+		assert.Error(t, err)
+
+		err = passwords.Reset(context.Background(), token, password)
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, service.ErrInvalidResetToken)
+	}
+
+	_, err := sessions.AuthenticateSession(other.SessionToken)
+	assert.NoError(t, err)
+
+	stored, err := st.User().Find(u.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.ComparePassword("password"))
+
+	assert.NoError(t, passwords.Reset(context.Background(), token, "new-password"))
+}
+
+func TestPasswordService_Reset(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	sessions := newAuthService(t, st)
+	passwords := service.NewPasswordService(st, sessions, outbox, time.Hour, resetURL)
+	u := testUser(t, st, model.RoleUser)
+	l := login(t, sessions, u)
+
+	require.NoError(t, passwords.RequestReset(context.Background(), u.Email))
+	token := mailedToken(t, outbox, u.Email)
+
+	require.NoError(t, passwords.Reset(context.Background(), token, "new-password"))
+
+	_, err := sessions.AuthenticateSession(l.SessionToken)
+	assert.ErrorIs(t, err, service.ErrSessionExpired)
+
+	stored, err := st.User().Find(u.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.ComparePassword("new-password"))
+
+	err = passwords.Reset(context.Background(), token, "another-password")
+	assert.ErrorIs(t, err, service.ErrInvalidResetToken)
+}
+
+func TestPasswordService_Reset_OnlyLatestToken(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	passwords := service.NewPasswordService(st, newAuthService(t, st), outbox, time.Hour, resetURL)
+	u := testUser(t, st, model.RoleUser)
+
+	require.NoError(t, passwords.RequestReset(context.Background(), u.Email))
+	first := mailedToken(t, outbox, u.Email)
+	require.NoError(t, passwords.RequestReset(context.Background(), u.Email))
+	second := mailedToken(t, outbox, u.Email)
+
+	err := passwords.Reset(context.Background(), first, "new-password")
+	assert.ErrorIs(t, err, service.ErrInvalidResetToken)
+
+	assert.NoError(t, passwords.Reset(context.Background(), second, "new-password"))
+}
+
+func TestPasswordService_Reset_Expired(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	passwords := service.NewPasswordService(st, newAuthService(t, st), outbox, -time.Minute, resetURL)
+	u := testUser(t, st, model.RoleUser)
+
+	require.NoError(t, passwords.RequestReset(context.Background(), u.Email))
+
+	err := passwords.Reset(context.Background(), mailedToken(t, outbox, u.Email), "new-password")
+	assert.ErrorIs(t, err, service.ErrInvalidResetToken)
+}
+
+func TestPasswordService_RequestReset_UnknownEmail(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	passwords := service.NewPasswordService(st, newAuthService(t, st), outbox, time.Hour, resetURL)
+
+	require.NoError(t, passwords.RequestReset(context.Background(), "nobody@example.org"))
+	assert.Empty(t, outbox.Messages())
+}
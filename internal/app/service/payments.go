@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/payment"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// refundBatch is the number of pending refunds RetryRefunds sends at once.
+const refundBatch = 100
+
+// collectPayment takes payment for a freshly created order according to its
+// payment method and stores the resulting payment status. Card payments stay
+// pending here: the gateway is called by chargeCard once the order is
+// committed.
+func collectPayment(tx store.Store, o *model.Order) error {
+	if o.PaymentMethod != model.PaymentMethodWallet {
+		return nil
+	}
+
+	if err := chargeOrder(tx, o); err != nil {
+		return err
+	}
+
+	o.PaymentStatus = model.PaymentStatusCaptured
+	return tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef)
+}
+
+// chargeCard authorizes and captures the card payment of a committed order.
+// An authorization whose capture fails is voided.
+func (s *OrderService) chargeCard(ctx context.Context, o *model.Order) error {
+	res, err := s.payments.Authorize(ctx, &payment.Request{
+		OrderId: o.ID,
+		UserId:  o.UserId,
+		Amount:  o.TotalAmount,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %v: %w", s.payments.Name(), err, ErrPaymentDeclined)
+	}
+
+	o.PaymentRef = res.Ref
+	o.PaymentStatus = res.Status
+
+	res, err = s.payments.Capture(ctx, o.PaymentRef, o.TotalAmount)
+	if err != nil {
+		if _, voidErr := s.payments.Void(ctx, o.PaymentRef); voidErr != nil {
+			return fmt.Errorf("%s: %v (void: %v): %w", s.payments.Name(), err, voidErr, ErrPaymentDeclined)
+		}
+
+		return fmt.Errorf("%s: %v: %w", s.payments.Name(), err, ErrPaymentDeclined)
+	}
+
+	o.PaymentStatus = res.Status
+	return nil
+}
+
+// recordCharge stores the outcome of chargeCard. When the order was
+// cancelled or deleted while the gateway was being called, the captured
+// money is refunded instead.
+func (s *OrderService) recordCharge(ctx context.Context, o *model.Order) error {
+	var refund *model.PaymentRefund
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		current, err := tx.Order().FindForUpdate(o.ID)
+		if err != nil && !errors.Is(err, store.ErrRecordNotFound) {
+			return err
+		}
+
+		if current != nil && !current.IsFinished() {
+			return tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef)
+		}
+
+		if o.PaymentStatus != model.PaymentStatusCaptured {
+			return nil
+		}
+
+		refund = &model.PaymentRefund{
+			PaymentRef: o.PaymentRef,
+			Amount:     o.TotalAmount,
+			CreatedAt:  time.Now(),
+		}
+		if current == nil {
+			return tx.PaymentRefund().Create(refund)
+		}
+
+		refund.OrderId = &current.ID
+		if err := tx.PaymentRefund().Create(refund); err != nil {
+			return err
+		}
+
+		o.Status = current.Status
+		o.PaymentStatus = model.PaymentStatusRefundPending
+		return tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.settle(ctx, o, refund)
+
+	return nil
+}
+
+// abandonOrder cancels an order whose card payment did not go through and
+// gives back everything it held. The change is recorded as the customer's.
+func (s *OrderService) abandonOrder(ctx context.Context, charged *model.Order) error {
+	return s.store.WithTx(ctx, func(tx store.Store) error {
+		o, err := tx.Order().FindForUpdate(charged.ID)
+		if err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		if o.IsFinished() {
+			return nil
+		}
+
+		o.PaymentStatus = model.PaymentStatusFailed
+		o.PaymentRef = charged.PaymentRef
+		if err := tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef); err != nil {
+			return err
+		}
+
+		_, err = s.cancelOrder(tx, o, o.UserId)
+		return err
+	})
+}
+
+// refundPayment gives back the money collected for an order that is being
+// cancelled, rejected, deleted or refunded. Wallet refunds are booked right
+// away; a card refund is recorded and returned so that the caller can send
+// it to the provider with settleRefund after the commit.
+func refundPayment(tx store.Store, o *model.Order) (*model.PaymentRefund, error) {
+	var refund *model.PaymentRefund
+
+	switch o.PaymentMethod {
+	case model.PaymentMethodWallet:
+		if err := refundOrder(tx, o); err != nil {
+			return nil, err
+		}
+
+		o.PaymentStatus = model.PaymentStatusRefunded
+	case model.PaymentMethodCard:
+		if o.PaymentStatus != model.PaymentStatusCaptured {
+			return nil, nil
+		}
+
+		orderId := o.ID
+		refund = &model.PaymentRefund{
+			OrderId:    &orderId,
+			PaymentRef: o.PaymentRef,
+			Amount:     o.TotalAmount,
+			CreatedAt:  time.Now(),
+		}
+		if err := tx.PaymentRefund().Create(refund); err != nil {
+			return nil, err
+		}
+
+		o.PaymentStatus = model.PaymentStatusRefundPending
+	default:
+		return nil, nil
+	}
+
+	if err := tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef); err != nil {
+		return nil, err
+	}
+
+	return refund, nil
+}
+
+// settleRefund sends a recorded refund to the payment provider and marks the
+// order refunded once the provider accepts it. A refund the provider does
+// not accept goes back to pending together with the error, for RetryRefunds
+// to pick up. A refund left in processing by a failure after the provider
+// accepted it is not retried, so that the customer is never paid twice.
+func (s *OrderService) settleRefund(ctx context.Context, r *model.PaymentRefund) error {
+	claimed, err := s.store.PaymentRefund().Claim(r.ID)
+	if err != nil || !claimed {
+		return err
+	}
+
+	if err := s.sendRefund(ctx, r); err != nil {
+		if releaseErr := s.store.PaymentRefund().Release(r.ID, err.Error()); releaseErr != nil {
+			return fmt.Errorf("%v (release: %v)", err, releaseErr)
+		}
+
+		return err
+	}
+
+	return s.store.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.PaymentRefund().Settle(r.ID, time.Now()); err != nil {
+			return err
+		}
+
+		if r.OrderId == nil {
+			return nil
+		}
+
+		o, err := tx.Order().FindForUpdate(*r.OrderId)
+		if err != nil {
+			if errors.Is(err, store.ErrRecordNotFound) {
+				return nil
+			}
+
+			return err
+		}
+
+		if o.PaymentStatus != model.PaymentStatusRefundPending {
+			return nil
+		}
+
+		return tx.Order().UpdatePayment(o.ID, model.PaymentStatusRefunded, o.PaymentRef)
+	})
+}
+
+func (s *OrderService) sendRefund(ctx context.Context, r *model.PaymentRefund) error {
+	if s.payments == nil {
+		return ErrCardPaymentsDisabled
+	}
+
+	if _, err := s.payments.Refund(ctx, r.PaymentRef, r.Amount); err != nil {
+		return fmt.Errorf("%s: %w", s.payments.Name(), err)
+	}
+
+	return nil
+}
+
+// PendingRefunds returns the card refunds that still have to be accepted by
+// the payment provider, oldest first.
+func (s *OrderService) PendingRefunds() ([]*model.PaymentRefund, error) {
+	return s.store.PaymentRefund().GetPending(refundBatch)
+}
+
+// RetryRefunds sends the pending card refunds to the payment provider again.
+// It returns the number of refunds settled and the errors of the others.
+func (s *OrderService) RetryRefunds(ctx context.Context) (int, error) {
+	refunds, err := s.store.PaymentRefund().GetPending(refundBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	settled := 0
+	var errs []error
+	for _, r := range refunds {
+		if err := s.settleRefund(ctx, r); err != nil {
+			errs = append(errs, fmt.Errorf("refund %d: %w", r.ID, err))
+			continue
+		}
+
+		settled++
+	}
+
+	return settled, errors.Join(errs...)
+}
+
+// HandlePaymentEvent applies a verified provider webhook to the order it
+// refers to. Every event is applied once: a redelivered event is ignored.
+// A failed payment cancels the order it was for, and money captured for an
+// order that has been given up meanwhile is refunded. released is true when
+// the event is what lets the kitchen start on the order.
+func (s *OrderService) HandlePaymentEvent(ctx context.Context, e *payment.WebhookEvent) (o *model.Order, released bool, err error) {
+	if e.ID == "" {
+		return nil, false, ErrMissingEventID
+	}
+
+	var refund *model.PaymentRefund
+
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		o, err = tx.Order().FindByPaymentRef(e.Ref)
+		if err != nil {
+			return err
+		}
+
+		switch e.Type {
+		case payment.EventCaptured, payment.EventFailed, payment.EventRefunded:
+		default:
+			return nil
+		}
+
+		fresh, err := tx.PaymentEvent().Record(&model.PaymentEvent{
+			EventId:    e.ID,
+			Type:       e.Type,
+			Ref:        e.Ref,
+			ReceivedAt: time.Now(),
+		})
+		if err != nil || !fresh {
+			return err
+		}
+
+		o, err = tx.Order().FindForUpdate(o.ID)
+		if err != nil {
+			return err
+		}
+
+		switch e.Type {
+		case payment.EventCaptured:
+			refund, released, err = applyCapture(tx, o)
+			return err
+		case payment.EventFailed:
+			return s.applyFailure(tx, o)
+		default:
+			return applyRefund(tx, o)
+		}
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.settle(ctx, o, refund)
+
+	return o, released, nil
+}
+
+// applyCapture records a capture confirmed by the provider. An order that
+// was cancelled or whose payment had already failed does not take the money:
+// it is refunded instead. Captures of a payment that was already captured or
+// refunded are stale and change nothing.
+func applyCapture(tx store.Store, o *model.Order) (*model.PaymentRefund, bool, error) {
+	switch o.PaymentStatus {
+	case model.PaymentStatusPending, model.PaymentStatusAuthorized, model.PaymentStatusFailed:
+	default:
+		return nil, false, nil
+	}
+
+	givenUp := o.IsFinished() || o.PaymentStatus == model.PaymentStatusFailed
+
+	o.PaymentStatus = model.PaymentStatusCaptured
+	if givenUp {
+		refund, err := refundPayment(tx, o)
+		return refund, false, err
+	}
+
+	if err := tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef); err != nil {
+		return nil, false, err
+	}
+
+	return nil, o.Status == model.OrderStatusPlaced, nil
+}
+
+// applyFailure records a payment the provider gave up on and cancels the
+// order on behalf of its customer, giving back its stock, slot, points and
+// promotion uses.
+func (s *OrderService) applyFailure(tx store.Store, o *model.Order) error {
+	if o.PaymentStatus != model.PaymentStatusPending && o.PaymentStatus != model.PaymentStatusAuthorized {
+		return nil
+	}
+
+	o.PaymentStatus = model.PaymentStatusFailed
+	if err := tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef); err != nil {
+		return err
+	}
+
+	if o.IsFinished() {
+		return nil
+	}
+
+	_, err := s.cancelOrder(tx, o, o.UserId)
+	return err
+}
+
+// applyRefund records a refund confirmed by the provider and settles the
+// refunds recorded for the payment, so that they are not sent again.
+func applyRefund(tx store.Store, o *model.Order) error {
+	if o.PaymentStatus == model.PaymentStatusRefunded {
+		return nil
+	}
+
+	o.PaymentStatus = model.PaymentStatusRefunded
+	if err := tx.Order().UpdatePayment(o.ID, o.PaymentStatus, o.PaymentRef); err != nil {
+		return err
+	}
+
+	return tx.PaymentRefund().SettleRef(o.PaymentRef, time.Now())
+}
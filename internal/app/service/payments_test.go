@@ -0,0 +1,288 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/payment"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"testing"
+)
+
+var errGatewayDown = errors.New("gateway down")
+
+// flakyProvider is a fake gateway whose capture and refund calls can be made
+// to fail, and which remembers the authorizations it voided.
+type flakyProvider struct {
+	*payment.FakeProvider
+
+	captureErr   error
+	refundErr    error
+	asyncCapture bool
+	voided       []string
+}
+
+func (p *flakyProvider) Capture(ctx context.Context, ref string, amount int) (*payment.Result, error) {
+	if p.captureErr != nil {
+		return nil, p.captureErr
+	}
+
+	res, err := p.FakeProvider.Capture(ctx, ref, amount)
+	if err != nil || !p.asyncCapture {
+		return res, err
+	}
+
+	return &payment.Result{Ref: ref, Status: payment.StatusPending}, nil
+}
+
+func (p *flakyProvider) Void(ctx context.Context, ref string) (*payment.Result, error) {
+	p.voided = append(p.voided, ref)
+	return p.FakeProvider.Void(ctx, ref)
+}
+
+func (p *flakyProvider) Refund(ctx context.Context, ref string, amount int) (*payment.Result, error) {
+	if p.refundErr != nil {
+		return nil, p.refundErr
+	}
+
+	return p.FakeProvider.Refund(ctx, ref, amount)
+}
+
+func TestOrderService_Place_Card(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, newFakeProvider(t))
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 500, 10)
+
+	o := place(t, orders, u.ID, model.PaymentMethodCard, line(m.ID, 2))
+
+	assert.Equal(t, model.PaymentStatusCaptured, o.PaymentStatus)
+	assert.NotEmpty(t, o.PaymentRef)
+	assert.True(t, o.IsReleasedToKitchen())
+
+	stored := findOrder(t, st, o.ID)
+	assert.Equal(t, model.PaymentStatusCaptured, stored.PaymentStatus)
+	assert.Equal(t, o.PaymentRef, stored.PaymentRef)
+}
+
+func TestOrderService_Place_CardDeclined(t *testing.T) {
+	st := teststore.New()
+	p := newFakeProvider(t)
+	p.DeclineAbove = 100
+	orders := newOrderService(st, p)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 500, 10)
+
+	_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{line(m.ID, 1)},
+		PaymentMethod: model.PaymentMethodCard,
+	})
+	assert.ErrorIs(t, err, service.ErrPaymentDeclined)
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+
+	placed, err := st.Order().GetOrders(u.ID)
+	require.NoError(t, err)
+	require.Len(t, placed, 1)
+	assert.Equal(t, model.OrderStatusCancelled, placed[0].Status)
+	assert.Equal(t, model.PaymentStatusFailed, placed[0].PaymentStatus)
+}
+
+func TestOrderService_Place_CardCaptureFailedVoids(t *testing.T) {
+	st := teststore.New()
+	p := &flakyProvider{FakeProvider: newFakeProvider(t), captureErr: errGatewayDown}
+	orders := newOrderService(st, p)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 500, 10)
+
+	_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{line(m.ID, 3)},
+		PaymentMethod: model.PaymentMethodCard,
+	})
+	assert.ErrorIs(t, err, service.ErrPaymentDeclined)
+	assert.Len(t, p.voided, 1)
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+}
+
+func TestOrderService_Cancel_CardRefund(t *testing.T) {
+	st := teststore.New()
+	p := &flakyProvider{FakeProvider: newFakeProvider(t)}
+	orders := newOrderService(st, p)
+	admin := testUser(t, st, model.RoleAdmin)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 500, 10)
+	o := place(t, orders, u.ID, model.PaymentMethodCard, line(m.ID, 1))
+
+	o, err := orders.Cancel(context.Background(), o.ID, admin)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCancelled, o.Status)
+	assert.Equal(t, model.PaymentStatusRefunded, o.PaymentStatus)
+	assert.Equal(t, model.PaymentStatusRefunded, findOrder(t, st, o.ID).PaymentStatus)
+
+	pending, err := orders.PendingRefunds()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOrderService_Cancel_FailedRefundIsRetried(t *testing.T) {
+	st := teststore.New()
+	p := &flakyProvider{FakeProvider: newFakeProvider(t)}
+	orders := newOrderService(st, p)
+	admin := testUser(t, st, model.RoleAdmin)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 500, 10)
+	o := place(t, orders, u.ID, model.PaymentMethodCard, line(m.ID, 1))
+
+	p.refundErr = errGatewayDown
+	o, err := orders.Cancel(context.Background(), o.ID, admin)
+	require.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCancelled, o.Status)
+	assert.Equal(t, model.PaymentStatusRefundPending, o.PaymentStatus)
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+
+	pending, err := orders.PendingRefunds()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, 500, pending[0].Amount)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Contains(t, pending[0].LastError, errGatewayDown.Error())
+
+	settled, err := orders.RetryRefunds(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, 0, settled)
+
+	p.refundErr = nil
+	settled, err = orders.RetryRefunds(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, settled)
+	assert.Equal(t, model.PaymentStatusRefunded, findOrder(t, st, o.ID).PaymentStatus)
+
+	pending, err = orders.PendingRefunds()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestOrderService_Refund_PendingRefundCountsAsRefunded(t *testing.T) {
+	st := teststore.New()
+	p := &flakyProvider{FakeProvider: newFakeProvider(t)}
+	orders := newOrderService(st, p)
+	admin := testUser(t, st, model.RoleAdmin)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 500, 10)
+	o := place(t, orders, u.ID, model.PaymentMethodCard, line(m.ID, 1))
+
+	for _, status := range []string{
+		model.OrderStatusAccepted,
+		model.OrderStatusPreparing,
+		model.OrderStatusReady,
+		model.OrderStatusPickedUp,
+	} {
+		_, err := orders.ChangeStatus(context.Background(), o.ID, admin, status)
+		require.NoError(t, err)
+	}
+
+	p.refundErr = errGatewayDown
+	o, err := orders.Refund(context.Background(), o.ID, admin)
+	require.NoError(t, err)
+	assert.Equal(t, model.PaymentStatusRefundPending, o.PaymentStatus)
+
+	_, err = orders.Refund(context.Background(), o.ID, admin)
+	assert.ErrorIs(t, err, service.ErrAlreadyRefunded)
+}
+
+func placeAsync(t *testing.T) (*teststore.Store, *flakyProvider, *service.OrderService, *model.MenuItem, *model.Order) {
+	t.Helper()
+
+	st := teststore.New()
+	p := &flakyProvider{FakeProvider: newFakeProvider(t), asyncCapture: true}
+	orders := newOrderService(st, p)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 500, 10)
+	o := place(t, orders, u.ID, model.PaymentMethodCard, line(m.ID, 2))
+	require.Equal(t, model.PaymentStatusPending, o.PaymentStatus)
+
+	return st, p, orders, m, o
+}
+
+func event(id string, typ string, o *model.Order) *payment.WebhookEvent {
+	return &payment.WebhookEvent{ID: id, Type: typ, Ref: o.PaymentRef, Amount: o.TotalAmount}
+}
+
+func TestOrderService_HandlePaymentEvent_Captured(t *testing.T) {
+	st, _, orders, _, o := placeAsync(t)
+
+	got, released, err := orders.HandlePaymentEvent(context.Background(), event("evt_1", payment.EventCaptured, o))
+	require.NoError(t, err)
+	assert.True(t, released)
+	assert.Equal(t, model.PaymentStatusCaptured, got.PaymentStatus)
+	assert.Equal(t, model.PaymentStatusCaptured, findOrder(t, st, o.ID).PaymentStatus)
+}
+
+func TestOrderService_HandlePaymentEvent_FailedCancelsOrder(t *testing.T) {
+	st, _, orders, m, o := placeAsync(t)
+	require.Equal(t, 8, stockOf(t, st, m.ID))
+
+	got, released, err := orders.HandlePaymentEvent(context.Background(), event("evt_1", payment.EventFailed, o))
+	require.NoError(t, err)
+	assert.False(t, released)
+	assert.Equal(t, model.OrderStatusCancelled, got.Status)
+
+	stored := findOrder(t, st, o.ID)
+	assert.Equal(t, model.OrderStatusCancelled, stored.Status)
+	assert.Equal(t, model.PaymentStatusFailed, stored.PaymentStatus)
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+
+	history, err := orders.History(o.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, model.OrderStatusCancelled, history[1].ToStatus)
+	assert.Equal(t, o.UserId, history[1].ChangedBy)
+}
+
+func TestOrderService_HandlePaymentEvent_ReplayIgnored(t *testing.T) {
+	st, _, orders, _, o := placeAsync(t)
+	captured := event("evt_1", payment.EventCaptured, o)
+
+	_, released, err := orders.HandlePaymentEvent(context.Background(), captured)
+	require.NoError(t, err)
+	assert.True(t, released)
+
+	_, _, err = orders.HandlePaymentEvent(context.Background(), event("evt_2", payment.EventRefunded, o))
+	require.NoError(t, err)
+	assert.Equal(t, model.PaymentStatusRefunded, findOrder(t, st, o.ID).PaymentStatus)
+
+	_, released, err = orders.HandlePaymentEvent(context.Background(), captured)
+	require.NoError(t, err)
+	assert.False(t, released)
+	assert.Equal(t, model.PaymentStatusRefunded, findOrder(t, st, o.ID).PaymentStatus)
+
+	_, released, err = orders.HandlePaymentEvent(context.Background(), event("evt_3", payment.EventCaptured, o))
+	require.NoError(t, err)
+	assert.False(t, released)
+	assert.Equal(t, model.PaymentStatusRefunded, findOrder(t, st, o.ID).PaymentStatus)
+}
+
+func TestOrderService_HandlePaymentEvent_CaptureAfterCancelRefunds(t *testing.T) {
+	st, _, orders, _, o := placeAsync(t)
+	admin := testUser(t, st, model.RoleAdmin)
+
+	_, err := orders.Cancel(context.Background(), o.ID, admin)
+	require.NoError(t, err)
+
+	got, released, err := orders.HandlePaymentEvent(context.Background(), event("evt_1", payment.EventCaptured, o))
+	require.NoError(t, err)
+	assert.False(t, released)
+	assert.Equal(t, model.PaymentStatusRefunded, got.PaymentStatus)
+	assert.Equal(t, model.OrderStatusCancelled, findOrder(t, st, o.ID).Status)
+}
+
+func TestOrderService_HandlePaymentEvent_MissingID(t *testing.T) {
+	_, _, orders, _, o := placeAsync(t)
+
+	_, _, err := orders.HandlePaymentEvent(context.Background(), event("", payment.EventCaptured, o))
+	assert.ErrorIs(t, err, service.ErrMissingEventID)
+}
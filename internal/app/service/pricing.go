@@ -0,0 +1,269 @@
+package service
+
+import (
+	"errors"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+	"time"
+)
+
+const loyaltyDiscountName = "Loyalty points"
+
+// Pricing is the price breakdown of an order: the sum of its lines, the
+// discounts granted on them and the amount the customer pays.
+type Pricing struct {
+	Subtotal  int
+	Discounts []*model.OrderDiscount
+	Total     int
+}
+
+// DiscountAmount ...
+func (p *Pricing) DiscountAmount() int {
+	return p.Subtotal - p.Total
+}
+
+// priceOrder runs the pricing pipeline over the priced order items of a
+// user: automatic promotions apply first, then the coupon, if any. Every
+// discount is capped at what is left to pay. Lookups use the given store so
+// that usage limits are checked inside the caller's transaction.
+func priceOrder(st store.Store, userId int, items []*model.OrderItem, code string, now time.Time) (*Pricing, error) {
+	subtotal := model.OrderTotal(items)
+	p := &Pricing{Subtotal: subtotal, Total: subtotal}
+
+	categories, err := lineCategories(st, items)
+	if err != nil {
+		return nil, err
+	}
+
+	automatic, err := st.Promotion().GetAutomatic()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, promo := range automatic {
+		if !promo.IsActiveAt(now) {
+			continue
+		}
+
+		ok, err := withinLimits(st, promo, userId)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			p.apply(promo, userId, discountFor(promo, items, categories, p.Total))
+		}
+	}
+
+	if code == "" {
+		return p, nil
+	}
+
+	coupon, err := st.Promotion().FindByCode(code)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return nil, ErrUnknownCoupon
+		}
+
+		return nil, err
+	}
+
+	if !coupon.IsActiveAt(now) {
+		return nil, ErrCouponExpired
+	}
+
+	ok, err := withinLimits(st, coupon, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !ok {
+		return nil, ErrCouponUsedUp
+	}
+
+	if !p.apply(coupon, userId, discountFor(coupon, items, categories, p.Total)) {
+		return nil, ErrCouponNotApplicable
+	}
+
+	return p, nil
+}
+
+// apply records a discount of up to amount and reports whether anything was
+// taken off.
+func (p *Pricing) apply(promo *model.Promotion, userId int, amount int) bool {
+	if amount > p.Total {
+		amount = p.Total
+	}
+
+	if amount <= 0 {
+		return false
+	}
+
+	p.Total -= amount
+	p.Discounts = append(p.Discounts, &model.OrderDiscount{
+		PromotionId: promo.ID,
+		UserId:      userId,
+		Name:        promo.Name,
+		Code:        promo.Code,
+		Amount:      amount,
+	})
+
+	return true
+}
+
+// applyPoints takes up to points loyalty points worth pointValue each off
+// what is left to pay and returns how many were used.
+func (p *Pricing) applyPoints(userId int, points int, pointValue int) int {
+	if fit := p.Total / pointValue; points > fit {
+		points = fit
+	}
+
+	if points <= 0 {
+		return 0
+	}
+
+	p.Total -= points * pointValue
+	p.Discounts = append(p.Discounts, &model.OrderDiscount{
+		UserId: userId,
+		Name:   loyaltyDiscountName,
+		Amount: points * pointValue,
+	})
+
+	return points
+}
+
+// withinLimits reports whether the promotion may be used once more by the
+// user. Promotions with limits are locked first, so that concurrent orders
+// cannot both take the last use.
+func withinLimits(st store.Store, promo *model.Promotion, userId int) (bool, error) {
+	if promo.MaxUses == 0 && promo.MaxUsesPerUser == 0 {
+		return true, nil
+	}
+
+	if _, err := st.Promotion().FindForUpdate(promo.ID); err != nil {
+		return false, err
+	}
+
+	if promo.MaxUses > 0 {
+		n, err := st.Promotion().CountUses(promo.ID)
+		if err != nil {
+			return false, err
+		}
+
+		if n >= promo.MaxUses {
+			return false, nil
+		}
+	}
+
+	if promo.MaxUsesPerUser > 0 {
+		n, err := st.Promotion().CountUserUses(promo.ID, userId)
+		if err != nil {
+			return false, err
+		}
+
+		if n >= promo.MaxUsesPerUser {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// lineCategories maps the menu items of single-item lines to their category.
+// Combo lines belong to no category.
+func lineCategories(st store.Store, items []*model.OrderItem) (map[int]int, error) {
+	categories := make(map[int]int)
+	for _, item := range items {
+		if item.MenuItemId == 0 {
+			continue
+		}
+
+		if _, ok := categories[item.MenuItemId]; ok {
+			continue
+		}
+
+		mi, err := st.MenuItem().Find(item.MenuItemId)
+		if err != nil {
+			return nil, err
+		}
+
+		categories[mi.ID] = mi.CategoryID
+	}
+
+	return categories, nil
+}
+
+// discountFor computes the discount a promotion grants on the order items
+// before any capping. Order-wide percentages apply to remaining, the amount
+// left after earlier discounts.
+func discountFor(promo *model.Promotion, items []*model.OrderItem, categories map[int]int, remaining int) int {
+	matches := func(item *model.OrderItem) bool {
+		if item.MenuItemId == 0 {
+			return false
+		}
+
+		if promo.MenuItemId != 0 {
+			return item.MenuItemId == promo.MenuItemId
+		}
+
+		return promo.CategoryID == 0 || categories[item.MenuItemId] == promo.CategoryID
+	}
+
+	switch promo.Kind {
+	case model.PromotionPercentage:
+		base := remaining
+		if promo.CategoryID != 0 || promo.MenuItemId != 0 {
+			base = 0
+			for _, item := range items {
+				if matches(item) {
+					base += item.LineTotal
+				}
+			}
+		}
+
+		return base * promo.Percent / 100
+	case model.PromotionFixed:
+		return promo.Amount
+	case model.PromotionFreeItem:
+		for _, item := range items {
+			if matches(item) {
+				return item.UnitPrice
+			}
+		}
+	case model.PromotionBuyXGetY:
+		// The cheapest matching portions are the free ones.
+		var prices []int
+		for _, item := range items {
+			if matches(item) {
+				for i := 0; i < item.Quantity; i++ {
+					prices = append(prices, item.UnitPrice)
+				}
+			}
+		}
+
+		free := len(prices) / (promo.BuyQuantity + promo.FreeQuantity) * promo.FreeQuantity
+		sort.Ints(prices)
+
+		var amount int
+		for _, price := range prices[:free] {
+			amount += price
+		}
+
+		return amount
+	}
+
+	return 0
+}
+
+// saveDiscounts stores the discounts granted on an order.
+func saveDiscounts(st store.Store, orderId int, discounts []*model.OrderDiscount) error {
+	for _, d := range discounts {
+		d.OrderId = orderId
+		if err := st.Promotion().CreateDiscount(d); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,171 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"testing"
+	"time"
+)
+
+func testPromotion(t *testing.T, st store.Store, p *model.Promotion) *model.Promotion {
+	t.Helper()
+
+	if p.Name == "" {
+		p.Name = p.Kind
+	}
+	require.NoError(t, st.Promotion().Create(p))
+
+	return p
+}
+
+func placeWithCoupon(orders *service.OrderService, userId int, code string, lines ...service.OrderLine) (*model.Order, error) {
+	o, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        userId,
+		Lines:         lines,
+		PaymentMethod: model.PaymentMethodCash,
+		CouponCode:    code,
+	})
+
+	return o, err
+}
+
+func TestOrderService_Cancel_KeepsDiscountsAndGivesUseBack(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	admin := testUser(t, st, model.RoleAdmin)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 1000, 10)
+	testPromotion(t, st, &model.Promotion{Kind: model.PromotionFixed, Code: "ONCE", Amount: 200, MaxUses: 1})
+
+	o, err := placeWithCoupon(orders, u.ID, "ONCE", line(m.ID, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 800, o.TotalAmount)
+
+	_, err = placeWithCoupon(orders, u.ID, "ONCE", line(m.ID, 1))
+	assert.ErrorIs(t, err, service.ErrCouponUsedUp)
+
+	_, err = orders.Cancel(context.Background(), o.ID, admin)
+	require.NoError(t, err)
+
+	discounts, err := st.Promotion().GetDiscountsByOrder(o.ID)
+	require.NoError(t, err)
+	require.Len(t, discounts, 1)
+	assert.Equal(t, 200, discounts[0].Amount)
+	assert.Equal(t, 200, findOrder(t, st, o.ID).DiscountAmount)
+
+	again, err := placeWithCoupon(orders, u.ID, "ONCE", line(m.ID, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 800, again.TotalAmount)
+}
+
+func discountNames(o *model.Order) []string {
+	var names []string
+	for _, d := range o.Discounts {
+		names = append(names, d.Name)
+	}
+
+	return names
+}
+
+func TestOrderService_Place_PricingOrder(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 1000, 10)
+	testPromotion(t, st, &model.Promotion{Name: "Lunch", Kind: model.PromotionPercentage, Percent: 10})
+	testPromotion(t, st, &model.Promotion{Name: "Welcome", Kind: model.PromotionFixed, Code: "HELLO", Amount: 100})
+	testPromotion(t, st, &model.Promotion{Name: "Half", Kind: model.PromotionPercentage, Code: "HALF", Percent: 50})
+
+	o, err := placeWithCoupon(orders, u.ID, "HELLO", line(m.ID, 2))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Lunch", "Welcome"}, discountNames(o))
+	assert.Equal(t, 2000-200-100, o.TotalAmount)
+	assert.Equal(t, 300, o.DiscountAmount)
+
+	// Percentages apply to what is left after the earlier discounts.
+	o, err = placeWithCoupon(orders, u.ID, "HALF", line(m.ID, 2))
+	require.NoError(t, err)
+	assert.Equal(t, 900, o.TotalAmount)
+}
+
+func TestOrderService_Place_DiscountCappedAtTotal(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 300, 10)
+	testPromotion(t, st, &model.Promotion{Kind: model.PromotionFixed, Code: "BIG", Amount: 1000})
+
+	o, err := placeWithCoupon(orders, u.ID, "BIG", line(m.ID, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 0, o.TotalAmount)
+	require.Len(t, o.Discounts, 1)
+	assert.Equal(t, 300, o.Discounts[0].Amount)
+}
+
+func TestOrderService_Place_ItemPromotions(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	drinks := &model.Category{Name: "Drinks"}
+	require.NoError(t, st.Category().Create(drinks))
+	juice := &model.MenuItem{Name: "juice", Price: 200, CategoryID: drinks.ID}
+	require.NoError(t, st.MenuItem().Create(juice))
+	water := &model.MenuItem{Name: "water", Price: 100, CategoryID: drinks.ID}
+	require.NoError(t, st.MenuItem().Create(water))
+	soup := testMenuItem(t, st, 500, 10)
+	testPromotion(t, st, &model.Promotion{Kind: model.PromotionBuyXGetY, Code: "3FOR2", CategoryID: drinks.ID, BuyQuantity: 2, FreeQuantity: 1})
+	testPromotion(t, st, &model.Promotion{Kind: model.PromotionFreeItem, Code: "SOUP", MenuItemId: soup.ID})
+	testPromotion(t, st, &model.Promotion{Kind: model.PromotionPercentage, Code: "DRINKS", CategoryID: drinks.ID, Percent: 50})
+
+	// The cheapest portion of the three is free.
+	o, err := placeWithCoupon(orders, u.ID, "3FOR2", line(juice.ID, 2), line(water.ID, 1), line(soup.ID, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 400+100+500-100, o.TotalAmount)
+
+	o, err = placeWithCoupon(orders, u.ID, "SOUP", line(soup.ID, 2), line(water.ID, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 500+100, o.TotalAmount)
+
+	o, err = placeWithCoupon(orders, u.ID, "DRINKS", line(juice.ID, 1), line(soup.ID, 1))
+	require.NoError(t, err)
+	assert.Equal(t, 100+500, o.TotalAmount)
+
+	_, err = placeWithCoupon(orders, u.ID, "SOUP", line(juice.ID, 1))
+	assert.ErrorIs(t, err, service.ErrCouponNotApplicable)
+
+	_, err = placeWithCoupon(orders, u.ID, "3FOR2", line(juice.ID, 2))
+	assert.ErrorIs(t, err, service.ErrCouponNotApplicable)
+}
+
+func TestOrderService_Place_CouponRejected(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	other := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 300, 10)
+	ended := time.Now().Add(-time.Hour)
+	testPromotion(t, st, &model.Promotion{Kind: model.PromotionFixed, Code: "OLD", Amount: 50, EndsAt: &ended})
+	testPromotion(t, st, &model.Promotion{Kind: model.PromotionFixed, Code: "MINE", Amount: 50, MaxUsesPerUser: 1})
+
+	_, err := placeWithCoupon(orders, u.ID, "NOPE", line(m.ID, 1))
+	assert.ErrorIs(t, err, service.ErrUnknownCoupon)
+
+	_, err = placeWithCoupon(orders, u.ID, "OLD", line(m.ID, 1))
+	assert.ErrorIs(t, err, service.ErrCouponExpired)
+
+	_, err = placeWithCoupon(orders, u.ID, "MINE", line(m.ID, 1))
+	require.NoError(t, err)
+
+	_, err = placeWithCoupon(orders, u.ID, "MINE", line(m.ID, 1))
+	assert.ErrorIs(t, err, service.ErrCouponUsedUp)
+
+	_, err = placeWithCoupon(orders, other.ID, "MINE", line(m.ID, 1))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 8, stockOf(t, st, m.ID))
+}
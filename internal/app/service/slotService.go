@@ -0,0 +1,198 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// OpeningHours is the daily window in which the canteen hands out orders,
+// as offsets from midnight.
+type OpeningHours struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// ParseOpeningHours parses "HH:MM" opening and closing times.
+func ParseOpeningHours(open, close string) (OpeningHours, error) {
+	o, err := parseClock(open)
+	if err != nil {
+		return OpeningHours{}, fmt.Errorf("opening time: %w", err)
+	}
+
+	c, err := parseClock(close)
+	if err != nil {
+		return OpeningHours{}, fmt.Errorf("closing time: %w", err)
+	}
+
+	if c <= o {
+		return OpeningHours{}, ErrInvalidOpeningHours
+	}
+
+	return OpeningHours{Open: o, Close: c}, nil
+}
+
+// Contains reports whether [start, end) lies within the opening hours of
+// start's day.
+func (h OpeningHours) Contains(start, end time.Time) bool {
+	day := midnight(start)
+
+	return !start.Before(day.Add(h.Open)) && !end.After(day.Add(h.Close))
+}
+
+// GenerateSlots describes the slots an admin wants for a day.
+type GenerateSlots struct {
+	Date      time.Time
+	Interval  time.Duration
+	MaxOrders int
+	MaxItems  int
+}
+
+// SlotService manages pickup slots.
+type SlotService struct {
+	store store.Store
+	hours OpeningHours
+}
+
+// NewSlotService ...
+func NewSlotService(st store.Store, hours OpeningHours) *SlotService {
+	return &SlotService{
+		store: st,
+		hours: hours,
+	}
+}
+
+// Generate creates back-to-back slots covering the opening hours of the
+// given day. All slots are created or none are.
+func (s *SlotService) Generate(ctx context.Context, req *GenerateSlots) ([]*model.PickupSlot, error) {
+	if req.Interval <= 0 || req.MaxOrders <= 0 || req.MaxItems <= 0 {
+		return nil, ErrInvalidSlotSettings
+	}
+
+	day := midnight(req.Date)
+	var slots []*model.PickupSlot
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		for start := day.Add(s.hours.Open); !start.Add(req.Interval).After(day.Add(s.hours.Close)); start = start.Add(req.Interval) {
+			slot := &model.PickupSlot{
+				StartsAt:  start,
+				EndsAt:    start.Add(req.Interval),
+				MaxOrders: req.MaxOrders,
+				MaxItems:  req.MaxItems,
+			}
+
+			if err := tx.PickupSlot().Create(slot); err != nil {
+				return err
+			}
+
+			slots = append(slots, slot)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+// ForDay lists the slots of the given day.
+func (s *SlotService) ForDay(date time.Time) ([]*model.PickupSlot, error) {
+	day := midnight(date)
+
+	return s.store.PickupSlot().FindBetween(day, day.AddDate(0, 0, 1))
+}
+
+// Delete removes a slot nobody has booked. Cancelled and finished orders
+// that were booked into it keep no slot.
+func (s *SlotService) Delete(ctx context.Context, id int) error {
+	return s.store.WithTx(ctx, func(tx store.Store) error {
+		slot, err := tx.PickupSlot().Find(id)
+		if err != nil {
+			return err
+		}
+
+		if slot.ReservedOrders > 0 {
+			return ErrSlotInUse
+		}
+
+		return tx.PickupSlot().Delete(id)
+	})
+}
+
+// reserveSlot books the order into its pickup slot, if it has one.
+func reserveSlot(st store.Store, o *model.Order, items int, hours OpeningHours) error {
+	if o.PickupSlotId == nil {
+		return nil
+	}
+
+	slot, err := st.PickupSlot().Find(*o.PickupSlotId)
+	if err != nil {
+		if errors.Is(err, store.ErrRecordNotFound) {
+			return ErrUnknownSlot
+		}
+
+		return err
+	}
+
+	if !slot.StartsAt.After(time.Now()) || !hours.Contains(slot.StartsAt, slot.EndsAt) {
+		return ErrSlotUnavailable
+	}
+
+	if err := st.PickupSlot().Reserve(slot.ID, items); err != nil {
+		if errors.Is(err, store.ErrCapacityFull) {
+			return ErrSlotFull
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// releaseSlot gives back the reservation of an existing order.
+func releaseSlot(st store.Store, o *model.Order) error {
+	if o.PickupSlotId == nil {
+		return nil
+	}
+
+	items, err := st.OrderItem().GetOrderItems(o.ID)
+	if err != nil {
+		return err
+	}
+
+	return st.PickupSlot().Release(*o.PickupSlotId, itemCount(items))
+}
+
+// itemCount is the number of dishes the kitchen prepares for the order; each
+// component of a combo counts as a dish.
+func itemCount(items []*model.OrderItem) int {
+	var n int
+	for _, item := range items {
+		dishes := len(item.Components)
+		if dishes == 0 {
+			dishes = 1
+		}
+
+		n += item.Quantity * dishes
+	}
+
+	return n
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,209 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testSlot(t *testing.T, st store.Store, startsAt time.Time, maxOrders int, maxItems int) *model.PickupSlot {
+	t.Helper()
+
+	slot := &model.PickupSlot{
+		StartsAt:  startsAt,
+		EndsAt:    startsAt.Add(10 * time.Minute),
+		MaxOrders: maxOrders,
+		MaxItems:  maxItems,
+	}
+	require.NoError(t, st.PickupSlot().Create(slot))
+
+	return slot
+}
+
+func tomorrowNoon() time.Time {
+	now := time.Now()
+
+	return time.Date(now.Year(), now.Month(), now.Day()+1, 12, 0, 0, 0, time.Local)
+}
+
+func placeInSlot(orders *service.OrderService, userId int, slotId int, lines ...service.OrderLine) (*model.Order, error) {
+	o, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        userId,
+		Lines:         lines,
+		PaymentMethod: model.PaymentMethodCash,
+		PickupSlotId:  &slotId,
+	})
+
+	return o, err
+}
+
+func TestSlotService_Generate(t *testing.T) {
+	st := teststore.New()
+	hours, err := service.ParseOpeningHours("08:00", "09:00")
+	require.NoError(t, err)
+	slots := service.NewSlotService(st, hours)
+
+	_, err = slots.Generate(context.Background(), &service.GenerateSlots{Date: tomorrowNoon(), Interval: 0, MaxOrders: 1, MaxItems: 1})
+	assert.ErrorIs(t, err, service.ErrInvalidSlotSettings)
+
+	generated, err := slots.Generate(context.Background(), &service.GenerateSlots{
+		Date:      tomorrowNoon(),
+		Interval:  25 * time.Minute,
+		MaxOrders: 5,
+		MaxItems:  20,
+	})
+	require.NoError(t, err)
+	require.Len(t, generated, 2)
+	assert.Equal(t, 8, generated[0].StartsAt.Hour())
+	assert.Equal(t, 50, generated[1].EndsAt.Minute())
+
+	day, err := slots.ForDay(tomorrowNoon())
+	require.NoError(t, err)
+	assert.Len(t, day, 2)
+}
+
+func TestOrderService_Place_SlotCapacity(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 100, 100)
+	slot := testSlot(t, st, tomorrowNoon(), 2, 5)
+
+	_, err := placeInSlot(orders, u.ID, slot.ID, line(m.ID, 6))
+	assert.ErrorIs(t, err, service.ErrSlotFull)
+	assert.Equal(t, 100, stockOf(t, st, m.ID))
+
+	_, err = placeInSlot(orders, u.ID, slot.ID, line(m.ID, 3))
+	require.NoError(t, err)
+
+	_, err = placeInSlot(orders, u.ID, slot.ID, line(m.ID, 3))
+	assert.ErrorIs(t, err, service.ErrSlotFull)
+
+	o, err := placeInSlot(orders, u.ID, slot.ID, line(m.ID, 2))
+	require.NoError(t, err)
+
+	_, err = placeInSlot(orders, u.ID, slot.ID, line(m.ID, 1))
+	assert.ErrorIs(t, err, service.ErrSlotFull)
+
+	require.NoError(t, orders.Delete(context.Background(), o.ID))
+
+	stored, err := st.PickupSlot().Find(slot.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.ReservedOrders)
+	assert.Equal(t, 3, stored.ReservedItems)
+}
+
+func TestOrderService_Place_SlotUnavailable(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 100, 100)
+	past := testSlot(t, st, time.Now().Add(-time.Hour), 5, 5)
+
+	_, err := placeInSlot(orders, u.ID, past.ID, line(m.ID, 1))
+	assert.ErrorIs(t, err, service.ErrSlotUnavailable)
+
+	_, err = placeInSlot(orders, u.ID, past.ID+100, line(m.ID, 1))
+	assert.ErrorIs(t, err, service.ErrUnknownSlot)
+
+	assert.Equal(t, 100, stockOf(t, st, m.ID))
+}
+
+func TestSlotService_Delete_InUse(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	slots := service.NewSlotService(st, testHours)
+	u := testUser(t, st, model.RoleUser)
+	admin := testUser(t, st, model.RoleAdmin)
+	m := testMenuItem(t, st, 100, 100)
+	slot := testSlot(t, st, tomorrowNoon(), 5, 5)
+
+	o, err := placeInSlot(orders, u.ID, slot.ID, line(m.ID, 1))
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, slots.Delete(context.Background(), slot.ID), service.ErrSlotInUse)
+
+	_, err = orders.ChangeStatus(context.Background(), o.ID, admin, model.OrderStatusCancelled)
+	require.NoError(t, err)
+
+	stored, err := st.PickupSlot().Find(slot.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stored.ReservedOrders)
+	assert.Equal(t, 0, stored.ReservedItems)
+
+	require.NoError(t, slots.Delete(context.Background(), slot.ID))
+	assert.Nil(t, findOrder(t, st, o.ID).PickupSlotId)
+
+	unused := testSlot(t, st, tomorrowNoon().Add(time.Hour), 5, 5)
+	require.NoError(t, slots.Delete(context.Background(), unused.ID))
+
+	_, err = st.PickupSlot().Find(unused.ID)
+	assert.ErrorIs(t, err, store.ErrRecordNotFound)
+}
+
+func TestOrderService_Place_ConcurrentSlot(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 100, 100)
+	slot := testSlot(t, st, tomorrowNoon(), 3, 100)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := placeInSlot(orders, u.ID, slot.ID, line(m.ID, 1)); err != nil {
+				assert.ErrorIs(t, err, service.ErrSlotFull)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stored, err := st.PickupSlot().Find(slot.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.ReservedOrders)
+	assert.Equal(t, 97, stockOf(t, st, m.ID))
+}
+
+func TestOrderService_Place_SlotCountsComboDishes(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	u := testUser(t, st, model.RoleUser)
+	soup := testMenuItem(t, st, 300, 10)
+	tea := testMenuItem(t, st, 100, 10)
+	slot := testSlot(t, st, tomorrowNoon(), 5, 4)
+
+	c := &model.Combo{
+		Name:  "lunch",
+		Price: 350,
+		Slots: []*model.ComboSlot{
+			{Name: "soup", MenuItemIds: []int{soup.ID}},
+			{Name: "drink", MenuItemIds: []int{tea.ID}},
+		},
+	}
+	require.NoError(t, st.Combo().Create(c))
+	combo := service.OrderLine{
+		ComboId:    c.ID,
+		Quantity:   2,
+		Components: []service.ComboChoice{{c.Slots[0].ID, soup.ID}, {c.Slots[1].ID, tea.ID}},
+	}
+
+	_, err := placeInSlot(orders, u.ID, slot.ID, combo, line(tea.ID, 1))
+	assert.ErrorIs(t, err, service.ErrSlotFull)
+
+	_, err = placeInSlot(orders, u.ID, slot.ID, combo)
+	require.NoError(t, err)
+
+	stored, err := st.PickupSlot().Find(slot.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 4, stored.ReservedItems)
+}
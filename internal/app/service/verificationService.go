@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/yeboka/final-project/internal/app/auth"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/notify"
+	"github.com/yeboka/final-project/internal/app/store"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// VerificationService proves that new accounts own their email address by
+// mailing them a single-use link. Accounts cannot order until verified.
+type VerificationService struct {
+	store     store.Store
+	notifier  notify.Notifier
+	ttl       time.Duration
+	verifyURL string
+}
+
+// NewVerificationService ...
+func NewVerificationService(st store.Store, notifier notify.Notifier, ttl time.Duration, verifyURL string) *VerificationService {
+	return &VerificationService{
+		store:     st,
+		notifier:  notifier,
+		ttl:       ttl,
+		verifyURL: verifyURL,
+	}
+}
+
+// Send mails a verification link to the user. Links sent before stop
+// working.
+func (s *VerificationService) Send(ctx context.Context, u *model.User) error {
+	if u.EmailVerified {
+		return ErrAlreadyVerified
+	}
+
+	token, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	v := &model.EmailVerification{
+		UserId:    u.ID,
+		TokenHash: auth.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl),
+	}
+
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.EmailVerification().UseUser(u.ID, now); err != nil {
+			return err
+		}
+
+		return tx.EmailVerification().Create(v)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(ctx, &notify.Message{
+		To:      u.Email,
+		Subject: "Verify your email address",
+		Body: "Welcome! Follow the link below within " + strconv.Itoa(int(s.ttl.Hours())) + " hours " +
+			"to verify your email address and start ordering.\n\n" + s.verifyLink(token),
+	})
+}
+
+// Resend mails a new verification link to the unverified account with the
+// email address. Unknown and verified addresses are ignored so that the
+// answer does not tell which addresses have an account.
+func (s *VerificationService) Resend(ctx context.Context, email string) error {
+	u, err := s.store.User().FindByEmail(email)
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := s.Send(ctx, u); err != nil && !errors.Is(err, ErrAlreadyVerified) {
+		return err
+	}
+
+	return nil
+}
+
+// Verify marks the account of a verification token verified.
+func (s *VerificationService) Verify(ctx context.Context, token string) (*model.User, error) {
+	v, err := s.store.EmailVerification().FindByHash(auth.HashToken(token))
+	if errors.Is(err, store.ErrRecordNotFound) {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !v.IsActiveAt(time.Now()) {
+		return nil, ErrInvalidVerifyToken
+	}
+
+	err = s.store.WithTx(ctx, func(tx store.Store) error {
+		used, err := tx.EmailVerification().Use(v.ID, time.Now())
+		if err != nil {
+			return err
+		}
+
+		if !used {
+			return ErrInvalidVerifyToken
+		}
+
+		return tx.User().SetEmailVerified(v.UserId)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.User().Find(v.UserId)
+}
+
+// MarkVerified verifies the account without a token, for admins vouching
+// for an address. Outstanding links stop working.
+func (s *VerificationService) MarkVerified(ctx context.Context, userId int) (*model.User, error) {
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		if err := tx.User().SetEmailVerified(userId); err != nil {
+			return err
+		}
+
+		return tx.EmailVerification().UseUser(userId, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.User().Find(userId)
+}
+
+// verifyLink returns the link of a verification token, or the bare token
+// when no verification page is configured.
+func (s *VerificationService) verifyLink(token string) string {
+	if s.verifyURL == "" {
+		return "Verification token: " + token
+	}
+
+	return s.verifyURL + "?token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,127 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/notify"
+	"github.com/yeboka/final-project/internal/app/policy"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"testing"
+	"time"
+)
+
+const verifyURL = "http://localhost/verify"
+
+func TestVerificationService_EmailChange(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	verifier := service.NewVerificationService(st, outbox, time.Hour, verifyURL)
+	u := testUser(t, st, model.RoleUser)
+
+	u.Username = "renamed"
+	require.NoError(t, st.User().Update(u))
+	assert.True(t, u.EmailVerified)
+
+	u.Email = "new@example.org"
+	require.NoError(t, st.User().Update(u))
+	assert.False(t, u.EmailVerified)
+
+	stored, err := st.User().Find(u.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.EmailVerified)
+	assert.ErrorIs(t, policy.CanPlaceOrder(stored), policy.ErrEmailNotVerified)
+
+	require.NoError(t, verifier.Send(context.Background(), u))
+
+	verified, err := verifier.Verify(context.Background(), mailedToken(t, outbox, "new@example.org"))
+	require.NoError(t, err)
+	assert.True(t, verified.EmailVerified)
+}
+
+func unverifiedUser(t *testing.T, st store.Store) *model.User {
+	t.Helper()
+
+	u := &model.User{
+		Email:    "unverified@example.org",
+		Username: "unverified",
+		Password: "password",
+		Role:     model.RoleUser,
+	}
+	require.NoError(t, st.User().Create(u))
+
+	return u
+}
+
+func TestVerificationService_Verify_SingleUse(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	verifier := service.NewVerificationService(st, outbox, time.Hour, verifyURL)
+	u := unverifiedUser(t, st)
+
+	require.NoError(t, verifier.Send(context.Background(), u))
+	token := mailedToken(t, outbox, u.Email)
+
+	verified, err := verifier.Verify(context.Background(), token)
+	require.NoError(t, err)
+	assert.True(t, verified.EmailVerified)
+
+	_, err = verifier.Verify(context.Background(), token)
+	assert.ErrorIs(t, err, service.ErrInvalidVerifyToken)
+
+	assert.ErrorIs(t, verifier.Send(context.Background(), verified), service.ErrAlreadyVerified)
+}
+
+func TestVerificationService_Resend_OnlyLatestToken(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	verifier := service.NewVerificationService(st, outbox, time.Hour, verifyURL)
+	u := unverifiedUser(t, st)
+
+	require.NoError(t, verifier.Send(context.Background(), u))
+	first := mailedToken(t, outbox, u.Email)
+	require.NoError(t, verifier.Resend(context.Background(), u.Email))
+	second := mailedToken(t, outbox, u.Email)
+
+	_, err := verifier.Verify(context.Background(), first)
+	assert.ErrorIs(t, err, service.ErrInvalidVerifyToken)
+
+	_, err = verifier.Verify(context.Background(), second)
+	assert.NoError(t, err)
+
+	sent := len(outbox.Messages())
+	require.NoError(t, verifier.Resend(context.Background(), u.Email))
+	require.NoError(t, verifier.Resend(context.Background(), "nobody@example.org"))
+	assert.Len(t, outbox.Messages(), sent)
+}
+
+func TestVerificationService_Verify_Expired(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	verifier := service.NewVerificationService(st, outbox, -time.Minute, verifyURL)
+	u := unverifiedUser(t, st)
+
+	require.NoError(t, verifier.Send(context.Background(), u))
+
+	_, err := verifier.Verify(context.Background(), mailedToken(t, outbox, u.Email))
+	assert.ErrorIs(t, err, service.ErrInvalidVerifyToken)
+}
+
+func TestVerificationService_MarkVerified(t *testing.T) {
+	st := teststore.New()
+	outbox := notify.NewOutbox()
+	verifier := service.NewVerificationService(st, outbox, time.Hour, verifyURL)
+	u := unverifiedUser(t, st)
+
+	require.NoError(t, verifier.Send(context.Background(), u))
+
+	verified, err := verifier.MarkVerified(context.Background(), u.ID)
+	require.NoError(t, err)
+	assert.True(t, verified.EmailVerified)
+
+	_, err = verifier.Verify(context.Background(), mailedToken(t, outbox, u.Email))
+	assert.ErrorIs(t, err, service.ErrInvalidVerifyToken)
+}
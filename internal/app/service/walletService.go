@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// Wallet is a user's balance together with the ledger it is derived from.
+type Wallet struct {
+	Balance int                  `json:"balance"`
+	Entries []*model.WalletEntry `json:"entries"`
+}
+
+// WalletService manages prepaid balances.
+type WalletService struct {
+	store store.Store
+}
+
+// NewWalletService ...
+func NewWalletService(st store.Store) *WalletService {
+	return &WalletService{
+		store: st,
+	}
+}
+
+// Get returns the user's balance and ledger history.
+func (s *WalletService) Get(userId int) (*Wallet, error) {
+	if _, err := s.store.User().Find(userId); err != nil {
+		return nil, err
+	}
+
+	balance, err := s.store.Wallet().Balance(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.store.Wallet().GetEntries(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Wallet{
+		Balance: balance,
+		Entries: entries,
+	}, nil
+}
+
+// TopUp credits amount to the user's wallet on behalf of actor.
+func (s *WalletService) TopUp(ctx context.Context, userId int, amount int, actor *model.User, note string) (*model.WalletEntry, error) {
+	if amount <= 0 {
+		return nil, ErrNonPositiveAmount
+	}
+
+	return s.record(ctx, userId, model.WalletEntryTopUp, amount, actor, note)
+}
+
+// Adjust applies a manual correction, which may be negative, but never lets
+// the balance drop below zero.
+func (s *WalletService) Adjust(ctx context.Context, userId int, amount int, actor *model.User, note string) (*model.WalletEntry, error) {
+	if amount == 0 {
+		return nil, ErrZeroAmount
+	}
+
+	return s.record(ctx, userId, model.WalletEntryAdjustment, amount, actor, note)
+}
+
+func (s *WalletService) record(ctx context.Context, userId int, kind string, amount int, actor *model.User, note string) (*model.WalletEntry, error) {
+	e := &model.WalletEntry{
+		UserId:    userId,
+		Kind:      kind,
+		Amount:    amount,
+		CreatedBy: actor.ID,
+		Note:      note,
+		CreatedAt: time.Now(),
+	}
+
+	err := s.store.WithTx(ctx, func(tx store.Store) error {
+		balance, err := tx.Wallet().BalanceForUpdate(userId)
+		if err != nil {
+			return err
+		}
+
+		if balance+amount < 0 {
+			return ErrInsufficientFunds
+		}
+
+		return tx.Wallet().Create(e)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// chargeOrder brings the amount charged for the order in line with its
+// total: it debits the difference when the total grew and refunds it when
+// the total shrank. It must run inside the order's transaction.
+func chargeOrder(st store.Store, o *model.Order) error {
+	charged, err := st.Wallet().OrderBalance(o.ID)
+	if err != nil {
+		return err
+	}
+
+	delta := o.TotalAmount + charged
+	if delta == 0 {
+		return nil
+	}
+
+	if delta < 0 {
+		return walletEntry(st, o, model.WalletEntryRefund, -delta)
+	}
+
+	balance, err := st.Wallet().BalanceForUpdate(o.UserId)
+	if err != nil {
+		return err
+	}
+
+	if balance < delta {
+		return fmt.Errorf("balance %d, order needs %d more: %w", balance, delta, ErrInsufficientFunds)
+	}
+
+	return walletEntry(st, o, model.WalletEntryOrderCharge, -delta)
+}
+
+// refundOrder returns everything charged for the order to the user's wallet.
+func refundOrder(st store.Store, o *model.Order) error {
+	charged, err := st.Wallet().OrderBalance(o.ID)
+	if err != nil {
+		return err
+	}
+
+	if charged >= 0 {
+		return nil
+	}
+
+	return walletEntry(st, o, model.WalletEntryRefund, -charged)
+}
+
+func walletEntry(st store.Store, o *model.Order, kind string, amount int) error {
+	orderId := o.ID
+
+	return st.Wallet().Create(&model.WalletEntry{
+		UserId:    o.UserId,
+		Kind:      kind,
+		Amount:    amount,
+		OrderId:   &orderId,
+		CreatedBy: o.UserId,
+		Note:      fmt.Sprintf("order #%d", o.ID),
+		CreatedAt: time.Now(),
+	})
+}
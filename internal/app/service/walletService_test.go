@@ -0,0 +1,142 @@
+package service_test
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/service"
+	"github.com/yeboka/final-project/internal/app/store/teststore"
+	"sync"
+	"testing"
+)
+
+func balanceOf(t *testing.T, wallets *service.WalletService, userId int) int {
+	t.Helper()
+
+	w, err := wallets.Get(userId)
+	require.NoError(t, err)
+
+	return w.Balance
+}
+
+func TestWalletService_TopUpAndAdjust(t *testing.T) {
+	st := teststore.New()
+	wallets := service.NewWalletService(st)
+	u := testUser(t, st, model.RoleUser)
+	admin := testUser(t, st, model.RoleAdmin)
+
+	_, err := wallets.TopUp(context.Background(), u.ID, 0, admin, "")
+	assert.ErrorIs(t, err, service.ErrNonPositiveAmount)
+
+	_, err = wallets.Adjust(context.Background(), u.ID, 0, admin, "")
+	assert.ErrorIs(t, err, service.ErrZeroAmount)
+
+	_, err = wallets.TopUp(context.Background(), u.ID, 500, admin, "cash desk")
+	require.NoError(t, err)
+
+	_, err = wallets.Adjust(context.Background(), u.ID, -600, admin, "")
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+
+	_, err = wallets.Adjust(context.Background(), u.ID, -200, admin, "double top-up")
+	require.NoError(t, err)
+
+	w, err := wallets.Get(u.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 300, w.Balance)
+	require.Len(t, w.Entries, 2)
+	for _, e := range w.Entries {
+		assert.Equal(t, admin.ID, e.CreatedBy)
+	}
+}
+
+func TestWalletService_Get_UnknownUser(t *testing.T) {
+	_, err := service.NewWalletService(teststore.New()).Get(1)
+	assert.Error(t, err)
+}
+
+func TestOrderService_Place_WalletCharge(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	wallets := service.NewWalletService(st)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 300, 10)
+	topUp(t, st, u.ID, 500)
+
+	_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+		UserId:        u.ID,
+		Lines:         []service.OrderLine{line(m.ID, 2)},
+		PaymentMethod: model.PaymentMethodWallet,
+	})
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+	assert.Equal(t, 500, balanceOf(t, wallets, u.ID))
+
+	o := place(t, orders, u.ID, model.PaymentMethodWallet, line(m.ID, 1))
+	assert.Equal(t, 200, balanceOf(t, wallets, u.ID))
+
+	_, _, err = orders.Update(context.Background(), o.ID, []service.OrderLine{line(m.ID, 2)})
+	assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+	assert.Equal(t, 200, balanceOf(t, wallets, u.ID))
+	assert.Equal(t, 9, stockOf(t, st, m.ID))
+
+	topUp(t, st, u.ID, 100)
+
+	_, _, err = orders.Update(context.Background(), o.ID, []service.OrderLine{line(m.ID, 2)})
+	require.NoError(t, err)
+	assert.Equal(t, 0, balanceOf(t, wallets, u.ID))
+
+	_, _, err = orders.Update(context.Background(), o.ID, []service.OrderLine{line(m.ID, 1)})
+	require.NoError(t, err)
+	assert.Equal(t, 300, balanceOf(t, wallets, u.ID))
+
+	require.NoError(t, orders.Delete(context.Background(), o.ID))
+	assert.Equal(t, 600, balanceOf(t, wallets, u.ID))
+}
+
+func TestOrderService_ChangeStatus_RejectRefundsWallet(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	wallets := service.NewWalletService(st)
+	u := testUser(t, st, model.RoleUser)
+	staff := testUser(t, st, model.RoleStaff)
+	m := testMenuItem(t, st, 300, 10)
+	topUp(t, st, u.ID, 1000)
+	o := place(t, orders, u.ID, model.PaymentMethodWallet, line(m.ID, 3))
+	assert.Equal(t, 100, balanceOf(t, wallets, u.ID))
+
+	_, err := orders.ChangeStatus(context.Background(), o.ID, staff, model.OrderStatusRejected)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, balanceOf(t, wallets, u.ID))
+	assert.Equal(t, 10, stockOf(t, st, m.ID))
+}
+
+func TestOrderService_Place_ConcurrentWallet(t *testing.T) {
+	st := teststore.New()
+	orders := newOrderService(st, nil)
+	wallets := service.NewWalletService(st)
+	u := testUser(t, st, model.RoleUser)
+	m := testMenuItem(t, st, 200, 100)
+	topUp(t, st, u.ID, 500)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, _, err := orders.Place(context.Background(), &service.PlaceOrder{
+				UserId:        u.ID,
+				Lines:         []service.OrderLine{line(m.ID, 1)},
+				PaymentMethod: model.PaymentMethodWallet,
+			})
+			if err != nil {
+				assert.ErrorIs(t, err, service.ErrInsufficientFunds)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 100, balanceOf(t, wallets, u.ID))
+	assert.Equal(t, 98, stockOf(t, st, m.ID))
+}
@@ -4,4 +4,7 @@ import "errors"
 
 var (
 	ErrRecordNotFound = errors.New("record not found")
+	ErrOutOfStock     = errors.New("sold out")
+	ErrCapacityFull   = errors.New("capacity exhausted")
+	ErrInvalidRole    = errors.New("invalid role")
 )
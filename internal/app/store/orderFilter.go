@@ -0,0 +1,60 @@
+package store
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"time"
+)
+
+// Order listing sort orders. A leading minus sorts in descending order; ties
+// are broken by order ID in the same direction.
+const (
+	OrderSortNewest    = "-created_at"
+	OrderSortOldest    = "created_at"
+	OrderSortTotalDesc = "-total"
+	OrderSortTotalAsc  = "total"
+)
+
+// OrderFilter selects a page of orders. Zero fields do not filter. From is
+// inclusive and To exclusive, MinTotal and MaxTotal both inclusive.
+// MenuItemId matches orders with the item on a line or in a combo. After
+// continues the listing behind the order the cursor was taken from, and
+// Limit caps the page size.
+type OrderFilter struct {
+	UserId     int
+	Statuses   []string
+	From       *time.Time
+	To         *time.Time
+	MenuItemId int
+	MinTotal   *int
+	MaxTotal   *int
+	Sort       string
+	After      *OrderCursor
+	Limit      int
+}
+
+// OrderCursor is the position of an order in a listing: the values of every
+// sort key of the order.
+type OrderCursor struct {
+	CreatedAt   time.Time `json:"created_at"`
+	TotalAmount int       `json:"total_amount"`
+	ID          int       `json:"id"`
+}
+
+// CursorOf returns the cursor of the order.
+func CursorOf(o *model.Order) *OrderCursor {
+	return &OrderCursor{
+		CreatedAt:   o.CreatedAt,
+		TotalAmount: o.TotalAmount,
+		ID:          o.ID,
+	}
+}
+
+// IsValidOrderSort ...
+func IsValidOrderSort(sort string) bool {
+	switch sort {
+	case OrderSortNewest, OrderSortOldest, OrderSortTotalDesc, OrderSortTotalAsc:
+		return true
+	}
+
+	return false
+}
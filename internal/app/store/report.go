@@ -0,0 +1,41 @@
+package store
+
+import "time"
+
+// Periods revenue can be grouped by. Weeks start on Monday.
+const (
+	ReportPeriodDay   = "day"
+	ReportPeriodWeek  = "week"
+	ReportPeriodMonth = "month"
+)
+
+// ReportRange is the time span of a report. From is inclusive and To
+// exclusive.
+type ReportRange struct {
+	From time.Time
+	To   time.Time
+}
+
+// IsValidReportPeriod ...
+func IsValidReportPeriod(period string) bool {
+	switch period {
+	case ReportPeriodDay, ReportPeriodWeek, ReportPeriodMonth:
+		return true
+	}
+
+	return false
+}
+
+// PeriodStart returns the start of the period t falls in.
+func PeriodStart(period string, t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+
+	switch period {
+	case ReportPeriodWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case ReportPeriodMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
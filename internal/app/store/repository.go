@@ -2,6 +2,7 @@ package store
 
 import (
 	"github.com/yeboka/final-project/internal/app/model"
+	"time"
 )
 
 // UserRepository ...
@@ -11,15 +12,29 @@ type UserRepository interface {
 	FindByEmail(string) (*model.User, error)
 	Update(user *model.User) error
 	UpdateRole(id int, role string) error
+	UpdatePassword(user *model.User) error
+	SetEmailVerified(id int) error
 	Delete(id int) error
 }
 
 // OrderRepository ...
 type OrderRepository interface {
 	Create(order *model.Order) error
+	Find(id int) (*model.Order, error)
+	FindForUpdate(id int) (*model.Order, error)
+	FindByPaymentRef(ref string) (*model.Order, error)
 	Delete(id int) error
-	Update(id int, totalAmount int) error
+	Update(id int, totalAmount int, discountAmount int) error
+	UpdateStatus(id int, status string) error
+	UpdatePayment(id int, status string, ref string) error
 	GetOrders(userId int) ([]*model.Order, error)
+	List(filter OrderFilter) ([]*model.Order, error)
+}
+
+// OrderStatusHistoryRepository ...
+type OrderStatusHistoryRepository interface {
+	Create(change *model.OrderStatusChange) error
+	GetByOrder(orderId int) ([]*model.OrderStatusChange, error)
 }
 
 type CategoryRepository interface {
@@ -30,11 +45,18 @@ type CategoryRepository interface {
 
 type MenuItemRepository interface {
 	Create(menuItem *model.MenuItem) error
-	GetPrice(id int) int
+	Find(id int) (*model.MenuItem, error)
+	GetPrice(id int) (int, error)
 	//FindByName(id int) (*model.MenuItem, error)
 	FindByCategoryId(categoryId int) ([]*model.MenuItem, error)
 	Update(mi *model.MenuItem) error
 	Delete(id int) error
+	DecrementStock(id int, quantity int) error
+	IncrementStock(id int, quantity int) error
+	SetStock(id int, stock *int, lowStockThreshold int) error
+	FindLowStock() ([]*model.MenuItem, error)
+	SetTags(id int, allergens []string, diets []string) error
+	SetNutrition(id int, nutrition *model.Nutrition) error
 }
 
 type OrderItemRepository interface {
@@ -44,3 +66,150 @@ type OrderItemRepository interface {
 	DeleteAllOrder(orderId int) error
 	GetOrderItems(orderId int) ([]*model.OrderItem, error)
 }
+
+// WalletRepository is append-only: entries are never updated or deleted.
+type WalletRepository interface {
+	Create(entry *model.WalletEntry) error
+	Balance(userId int) (int, error)
+	BalanceForUpdate(userId int) (int, error)
+	OrderBalance(orderId int) (int, error)
+	GetEntries(userId int) ([]*model.WalletEntry, error)
+}
+
+// PickupSlotRepository ...
+type PickupSlotRepository interface {
+	Create(slot *model.PickupSlot) error
+	Find(id int) (*model.PickupSlot, error)
+	FindBetween(from, to time.Time) ([]*model.PickupSlot, error)
+	Reserve(id int, items int) error
+	Release(id int, items int) error
+	Delete(id int) error
+}
+
+// ModifierRepository ...
+type ModifierRepository interface {
+	CreateGroup(group *model.ModifierGroup) error
+	FindGroup(id int) (*model.ModifierGroup, error)
+	DeleteGroup(id int) error
+	CreateModifier(modifier *model.Modifier) error
+	DeleteModifier(id int) error
+	FindGroupsByMenuItem(menuItemId int) ([]*model.ModifierGroup, error)
+	GetAllGroups() ([]*model.ModifierGroup, error)
+}
+
+// ComboRepository ...
+type ComboRepository interface {
+	Create(combo *model.Combo) error
+	Find(id int) (*model.Combo, error)
+	Delete(id int) error
+	GetAll() ([]*model.Combo, error)
+}
+
+// PromotionRepository stores promotions and the discounts they granted.
+type PromotionRepository interface {
+	Create(promotion *model.Promotion) error
+	Find(id int) (*model.Promotion, error)
+	FindForUpdate(id int) (*model.Promotion, error)
+	FindByCode(code string) (*model.Promotion, error)
+	GetAutomatic() ([]*model.Promotion, error)
+	GetAll() ([]*model.Promotion, error)
+	Delete(id int) error
+	CreateDiscount(discount *model.OrderDiscount) error
+	GetDiscountsByOrder(orderId int) ([]*model.OrderDiscount, error)
+	DeleteDiscountsByOrder(orderId int) error
+	CountUses(promotionId int) (int, error)
+	CountUserUses(promotionId int, userId int) (int, error)
+}
+
+// LoyaltyRepository ...
+type LoyaltyRepository interface {
+	Create(entry *model.LoyaltyEntry) error
+	Balance(userId int) (int, error)
+	BalanceForUpdate(userId int) (int, error)
+	SumByKind(userId int, kind string) (int, error)
+	ExpiredPoints(userId int, now time.Time) (int, error)
+	OrderPoints(orderId int, kinds ...string) (int, error)
+	GetEntries(userId int) ([]*model.LoyaltyEntry, error)
+	GetBalances() ([]*model.LoyaltyBalance, error)
+}
+
+// MenuScheduleRepository ...
+type MenuScheduleRepository interface {
+	Create(s *model.MenuSchedule) error
+	Delete(id int) error
+	GetAll() ([]*model.MenuSchedule, error)
+}
+
+// ReportRepository computes sales figures over the orders of a time range.
+type ReportRepository interface {
+	Revenue(r ReportRange, period string) ([]*model.RevenuePeriod, error)
+	TopItems(r ReportRange, limit int) ([]*model.ItemSales, error)
+	SalesByCategory(r ReportRange) ([]*model.CategorySales, error)
+	OrderValue(r ReportRange) (*model.OrderValue, error)
+	PeakHours(r ReportRange) ([]*model.HourSales, error)
+}
+
+// RefreshTokenRepository ...
+type RefreshTokenRepository interface {
+	Create(t *model.RefreshToken) error
+	FindByHash(hash string) (*model.RefreshToken, error)
+	Revoke(id int, now time.Time) (bool, error)
+}
+
+// SessionRepository ...
+type SessionRepository interface {
+	Create(s *model.Session) error
+	Find(id int) (*model.Session, error)
+	FindByHash(hash string) (*model.Session, error)
+	GetByUser(userId int) ([]*model.Session, error)
+	Touch(id int, now time.Time) error
+	Revoke(id int, now time.Time) error
+	RevokeUser(userId int, exceptId int, now time.Time) error
+}
+
+// PasswordResetRepository ...
+type PasswordResetRepository interface {
+	Create(r *model.PasswordReset) error
+	FindByHash(hash string) (*model.PasswordReset, error)
+	Use(id int, now time.Time) (bool, error)
+	UseUser(userId int, now time.Time) error
+}
+
+// EmailVerificationRepository ...
+type EmailVerificationRepository interface {
+	Create(v *model.EmailVerification) error
+	FindByHash(hash string) (*model.EmailVerification, error)
+	Use(id int, now time.Time) (bool, error)
+	UseUser(userId int, now time.Time) error
+}
+
+// LoginThrottleRepository ...
+type LoginThrottleRepository interface {
+	Find(kind string, key string) (*model.LoginThrottle, error)
+	Attempt(kind string, key string, now time.Time, next time.Time) (bool, error)
+	RecordFailure(kind string, key string, now time.Time, since time.Time) (*model.LoginThrottle, error)
+	Lock(id int, until time.Time) error
+	Reset(kind string, key string) error
+	DeleteStale(since time.Time, now time.Time) (int, error)
+}
+
+// AuditRepository ...
+type AuditRepository interface {
+	Create(e *model.AuditEntry) error
+	List(action string, limit int) ([]*model.AuditEntry, error)
+}
+
+// PaymentRefundRepository ...
+type PaymentRefundRepository interface {
+	Create(r *model.PaymentRefund) error
+	GetPending(limit int) ([]*model.PaymentRefund, error)
+	Claim(id int) (bool, error)
+	Settle(id int, now time.Time) error
+	Release(id int, reason string) error
+	SettleRef(ref string, now time.Time) error
+}
+
+// PaymentEventRepository ...
+type PaymentEventRepository interface {
+	Record(e *model.PaymentEvent) (bool, error)
+}
@@ -0,0 +1,69 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+)
+
+// AuditRepository ...
+type AuditRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *AuditRepository) Create(e *model.AuditEntry) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO audit_entries (action, user_id, actor_id, ip, detail, created_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		e.Action,
+		e.UserId,
+		e.ActorId,
+		e.IP,
+		e.Detail,
+		e.CreatedAt,
+	).Scan(&e.ID)
+}
+
+// List returns the latest entries, newest first. An empty action lists
+// every action.
+func (r *AuditRepository) List(action string, limit int) ([]*model.AuditEntry, error) {
+	rows, err := r.store.db.Query(
+		`SELECT id, action, user_id, actor_id, ip, detail, created_at FROM audit_entries
+		WHERE $1 = '' OR action = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`,
+		action,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*model.AuditEntry{}
+	for rows.Next() {
+		e := &model.AuditEntry{}
+		var userId, actorId sql.NullInt64
+
+		if err := rows.Scan(&e.ID, &e.Action, &userId, &actorId, &e.IP, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if userId.Valid {
+			id := int(userId.Int64)
+			e.UserId = &id
+		}
+
+		if actorId.Valid {
+			id := int(actorId.Int64)
+			e.ActorId = &id
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
 )
 
 // CategoryRepository ...
@@ -45,6 +46,10 @@ func (r *CategoryRepository) Find(id int) (*model.Category, error) {
 		&c.Name,
 		&parentID,
 	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
 		return nil, err
 	}
 
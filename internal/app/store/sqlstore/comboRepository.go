@@ -0,0 +1,172 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+)
+
+// ComboRepository ...
+type ComboRepository struct {
+	store *Store
+}
+
+// Create stores the combo together with its slots and their item lists.
+func (r *ComboRepository) Create(c *model.Combo) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	for _, slot := range c.Slots {
+		if err := slot.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := r.store.db.QueryRow(
+		"INSERT INTO combos (category_id, name, price, description) VALUES ($1, $2, $3, $4) RETURNING id",
+		nullInt(c.CategoryID),
+		c.Name,
+		c.Price,
+		c.Description,
+	).Scan(&c.ID); err != nil {
+		return err
+	}
+
+	for _, slot := range c.Slots {
+		slot.ComboId = c.ID
+		if err := r.store.db.QueryRow(
+			"INSERT INTO combo_slots (combo_id, name, category_id) VALUES ($1, $2, $3) RETURNING id",
+			slot.ComboId,
+			slot.Name,
+			slot.CategoryID,
+		).Scan(&slot.ID); err != nil {
+			return err
+		}
+
+		for _, menuItemId := range slot.MenuItemIds {
+			if _, err := r.store.db.Exec(
+				"INSERT INTO combo_slot_items (slot_id, menu_item_id) VALUES ($1, $2)",
+				slot.ID,
+				menuItemId,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Find ...
+func (r *ComboRepository) Find(id int) (*model.Combo, error) {
+	combos, err := r.load("WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(combos) == 0 {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return combos[0], nil
+}
+
+// Delete ...
+func (r *ComboRepository) Delete(id int) error {
+	_, err := r.store.db.Exec("DELETE FROM combos WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAll ...
+func (r *ComboRepository) GetAll() ([]*model.Combo, error) {
+	return r.load("")
+}
+
+// load reads the combos matching where and attaches their slots.
+func (r *ComboRepository) load(where string, args ...interface{}) ([]*model.Combo, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, COALESCE(category_id, 0), name, price, description FROM combos "+where+" ORDER BY id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var combos []*model.Combo
+	byID := make(map[int]*model.Combo)
+	for rows.Next() {
+		c := &model.Combo{Slots: []*model.ComboSlot{}}
+		if err := rows.Scan(&c.ID, &c.CategoryID, &c.Name, &c.Price, &c.Description); err != nil {
+			return nil, err
+		}
+
+		combos = append(combos, c)
+		byID[c.ID] = c
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(combos) == 0 {
+		return combos, nil
+	}
+
+	if err := r.loadSlots(byID); err != nil {
+		return nil, err
+	}
+
+	return combos, nil
+}
+
+// loadSlots attaches the slots and their item lists to the given combos in a
+// single left join.
+func (r *ComboRepository) loadSlots(combos map[int]*model.Combo) error {
+	rows, err := r.store.db.Query(
+		"SELECT s.id, s.combo_id, s.name, s.category_id, i.menu_item_id " +
+			"FROM combo_slots s LEFT JOIN combo_slot_items i ON i.slot_id = s.id " +
+			"ORDER BY s.id, i.menu_item_id",
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var current *model.ComboSlot
+	for rows.Next() {
+		slot := &model.ComboSlot{}
+		var categoryId sql.NullInt64
+		var menuItemId sql.NullInt64
+
+		if err := rows.Scan(&slot.ID, &slot.ComboId, &slot.Name, &categoryId, &menuItemId); err != nil {
+			return err
+		}
+
+		c, ok := combos[slot.ComboId]
+		if !ok {
+			continue
+		}
+
+		if current == nil || current.ID != slot.ID {
+			if categoryId.Valid {
+				id := int(categoryId.Int64)
+				slot.CategoryID = &id
+			}
+
+			current = slot
+			c.Slots = append(c.Slots, current)
+		}
+
+		if menuItemId.Valid {
+			current.MenuItemIds = append(current.MenuItemIds, int(menuItemId.Int64))
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,76 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// EmailVerificationRepository ...
+type EmailVerificationRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *EmailVerificationRepository) Create(v *model.EmailVerification) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO email_verifications (user_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		v.UserId,
+		v.TokenHash,
+		v.CreatedAt,
+		v.ExpiresAt,
+	).Scan(&v.ID)
+}
+
+// FindByHash ...
+func (r *EmailVerificationRepository) FindByHash(hash string) (*model.EmailVerification, error) {
+	v := &model.EmailVerification{}
+	var usedAt sql.NullTime
+
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, token_hash, created_at, expires_at, used_at FROM email_verifications WHERE token_hash = $1",
+		hash,
+	).Scan(
+		&v.ID,
+		&v.UserId,
+		&v.TokenHash,
+		&v.CreatedAt,
+		&v.ExpiresAt,
+		&usedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		v.UsedAt = &usedAt.Time
+	}
+
+	return v, nil
+}
+
+// Use marks the token used unless it already is, and reports whether it
+// did. Of two requests racing to use the same token only one gets true.
+func (r *EmailVerificationRepository) Use(id int, now time.Time) (bool, error) {
+	res, err := r.store.db.Exec("UPDATE email_verifications SET used_at = $2 WHERE id = $1 AND used_at IS NULL", id, now)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// UseUser marks every unused token of the user used.
+func (r *EmailVerificationRepository) UseUser(userId int, now time.Time) error {
+	_, err := r.store.db.Exec("UPDATE email_verifications SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL", userId, now)
+	return err
+}
@@ -0,0 +1,132 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// LoginThrottleRepository ...
+type LoginThrottleRepository struct {
+	store *Store
+}
+
+func scanLoginThrottle(row scanner) (*model.LoginThrottle, error) {
+	t := &model.LoginThrottle{}
+	var lastFailureAt, nextAttemptAt, lockedUntil sql.NullTime
+
+	if err := row.Scan(
+		&t.ID,
+		&t.Kind,
+		&t.Key,
+		&t.Failures,
+		&lastFailureAt,
+		&nextAttemptAt,
+		&lockedUntil,
+	); err != nil {
+		return nil, err
+	}
+
+	t.LastFailureAt = lastFailureAt.Time
+
+	if nextAttemptAt.Valid {
+		t.NextAttemptAt = &nextAttemptAt.Time
+	}
+
+	if lockedUntil.Valid {
+		t.LockedUntil = &lockedUntil.Time
+	}
+
+	return t, nil
+}
+
+// Find ...
+func (r *LoginThrottleRepository) Find(kind string, key string) (*model.LoginThrottle, error) {
+	t, err := scanLoginThrottle(r.store.db.QueryRow(
+		"SELECT id, kind, key, failures, last_failure_at, next_attempt_at, locked_until FROM login_throttles WHERE kind = $1 AND key = $2",
+		kind,
+		key,
+	))
+	if err == sql.ErrNoRows {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return t, err
+}
+
+// Attempt records a login attempt at now and moves the next allowed attempt
+// to next, unless logins are locked out or the next attempt is not due yet.
+// It reports whether the attempt may go ahead, and never does for kinds and
+// keys without a throttle. Checking and recording in one statement lets only
+// one of several concurrent attempts through.
+func (r *LoginThrottleRepository) Attempt(kind string, key string, now time.Time, next time.Time) (bool, error) {
+	var id int
+
+	if err := r.store.db.QueryRow(
+		`UPDATE login_throttles SET next_attempt_at = $4
+		WHERE kind = $1 AND key = $2
+			AND (locked_until IS NULL OR locked_until <= $3)
+			AND (next_attempt_at IS NULL OR next_attempt_at <= $3)
+		RETURNING id`,
+		kind,
+		key,
+		now,
+		next,
+	).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RecordFailure counts a failed login at now. Failures before since no
+// longer count, so the count starts over when the last one is older.
+func (r *LoginThrottleRepository) RecordFailure(kind string, key string, now time.Time, since time.Time) (*model.LoginThrottle, error) {
+	return scanLoginThrottle(r.store.db.QueryRow(
+		`INSERT INTO login_throttles (kind, key, failures, last_failure_at) VALUES ($1, $2, 1, $3)
+		ON CONFLICT (kind, key) DO UPDATE SET
+			failures = CASE WHEN login_throttles.last_failure_at < $4 THEN 1 ELSE login_throttles.failures + 1 END,
+			last_failure_at = $3
+		RETURNING id, kind, key, failures, last_failure_at, next_attempt_at, locked_until`,
+		kind,
+		key,
+		now,
+		since,
+	))
+}
+
+// Lock ...
+func (r *LoginThrottleRepository) Lock(id int, until time.Time) error {
+	_, err := r.store.db.Exec("UPDATE login_throttles SET locked_until = $2 WHERE id = $1", id, until)
+	return err
+}
+
+// Reset forgets the failed logins and lifts the lockout.
+func (r *LoginThrottleRepository) Reset(kind string, key string) error {
+	_, err := r.store.db.Exec("DELETE FROM login_throttles WHERE kind = $1 AND key = $2", kind, key)
+	return err
+}
+
+// DeleteStale deletes the throttles whose last failure was before since and
+// that are neither locked out nor waiting for the next attempt at now.
+func (r *LoginThrottleRepository) DeleteStale(since time.Time, now time.Time) (int, error) {
+	res, err := r.store.db.Exec(
+		`DELETE FROM login_throttles
+		WHERE (last_failure_at IS NULL OR last_failure_at < $1)
+			AND (locked_until IS NULL OR locked_until <= $2)
+			AND (next_attempt_at IS NULL OR next_attempt_at <= $2)`,
+		since,
+		now,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	return int(n), err
+}
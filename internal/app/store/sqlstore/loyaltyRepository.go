@@ -0,0 +1,148 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// LoyaltyRepository ...
+type LoyaltyRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *LoyaltyRepository) Create(e *model.LoyaltyEntry) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO loyalty_entries (user_id, kind, points, order_id, created_by, note, expires_at, created_at) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		e.UserId,
+		e.Kind,
+		e.Points,
+		e.OrderId,
+		e.CreatedBy,
+		e.Note,
+		e.ExpiresAt,
+		e.CreatedAt,
+	).Scan(&e.ID)
+}
+
+// Balance ...
+func (r *LoyaltyRepository) Balance(userId int) (int, error) {
+	return r.sum("SELECT COALESCE(SUM(points), 0) FROM loyalty_entries WHERE user_id = $1", userId)
+}
+
+// BalanceForUpdate locks the user row until the surrounding transaction ends
+// and returns the balance, so that concurrent redemptions cannot overspend it.
+func (r *LoyaltyRepository) BalanceForUpdate(userId int) (int, error) {
+	var id int
+
+	if err := r.store.db.QueryRow("SELECT id FROM users WHERE id = $1 FOR UPDATE", userId).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, store.ErrRecordNotFound
+		}
+
+		return 0, err
+	}
+
+	return r.Balance(userId)
+}
+
+// SumByKind ...
+func (r *LoyaltyRepository) SumByKind(userId int, kind string) (int, error) {
+	return r.sum("SELECT COALESCE(SUM(points), 0) FROM loyalty_entries WHERE user_id = $1 AND kind = $2", userId, kind)
+}
+
+// ExpiredPoints returns the points earned by the user that expired by now.
+func (r *LoyaltyRepository) ExpiredPoints(userId int, now time.Time) (int, error) {
+	return r.sum(
+		"SELECT COALESCE(SUM(points), 0) FROM loyalty_entries WHERE user_id = $1 AND kind = $2 AND expires_at <= $3",
+		userId, model.LoyaltyEntryEarn, now,
+	)
+}
+
+// OrderPoints returns the sum of the entries of the given kinds tied to an
+// order.
+func (r *LoyaltyRepository) OrderPoints(orderId int, kinds ...string) (int, error) {
+	return r.sum(
+		"SELECT COALESCE(SUM(points), 0) FROM loyalty_entries WHERE order_id = $1 AND kind = ANY($2)",
+		orderId, pq.Array(kinds),
+	)
+}
+
+func (r *LoyaltyRepository) sum(query string, args ...interface{}) (int, error) {
+	var points int
+
+	if err := r.store.db.QueryRow(query, args...).Scan(&points); err != nil {
+		return 0, err
+	}
+
+	return points, nil
+}
+
+// GetEntries returns the user's ledger, newest first.
+func (r *LoyaltyRepository) GetEntries(userId int) ([]*model.LoyaltyEntry, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, user_id, kind, points, order_id, created_by, note, expires_at, created_at FROM loyalty_entries "+
+			"WHERE user_id = $1 ORDER BY created_at DESC, id DESC",
+		userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*model.LoyaltyEntry
+	for rows.Next() {
+		e := &model.LoyaltyEntry{}
+		var orderId sql.NullInt64
+		var expiresAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.UserId, &e.Kind, &e.Points, &orderId, &e.CreatedBy, &e.Note, &expiresAt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if orderId.Valid {
+			id := int(orderId.Int64)
+			e.OrderId = &id
+		}
+
+		if expiresAt.Valid {
+			e.ExpiresAt = &expiresAt.Time
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// GetBalances returns the balance of every user with loyalty history.
+func (r *LoyaltyRepository) GetBalances() ([]*model.LoyaltyBalance, error) {
+	rows, err := r.store.db.Query("SELECT user_id, SUM(points) FROM loyalty_entries GROUP BY user_id ORDER BY user_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []*model.LoyaltyBalance
+	for rows.Next() {
+		b := &model.LoyaltyBalance{}
+		if err := rows.Scan(&b.UserId, &b.Points); err != nil {
+			return nil, err
+		}
+
+		balances = append(balances, b)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return balances, nil
+}
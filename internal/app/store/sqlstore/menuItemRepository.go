@@ -1,27 +1,125 @@
 package sqlstore
 
-import "github.com/yeboka/final-project/internal/app/model"
+import (
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+)
+
+const menuItemColumns = "id, category_id, name, price, description, stock, low_stock_threshold, allergens, diets, " + nutritionColumns
 
 // MenuItemRepository ...
 type MenuItemRepository struct {
 	store *Store
 }
 
+// scanner is implemented by both *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanMenuItem(row scanner) (*model.MenuItem, error) {
+	m := &model.MenuItem{}
+	var stock sql.NullInt64
+	var nutrition nullNutrition
+
+	dest := []interface{}{
+		&m.ID,
+		&m.CategoryID,
+		&m.Name,
+		&m.Price,
+		&m.Description,
+		&stock,
+		&m.LowStockThreshold,
+		pq.Array(&m.Allergens),
+		pq.Array(&m.Diets),
+	}
+
+	if err := row.Scan(append(dest, nutrition.dest()...)...); err != nil {
+		return nil, err
+	}
+
+	if stock.Valid {
+		v := int(stock.Int64)
+		m.Stock = &v
+	}
+	m.Available = m.IsAvailable()
+	m.Allergens = tags(m.Allergens)
+	m.Diets = tags(m.Diets)
+	m.Nutrition = nutrition.nutrition()
+
+	return m, nil
+}
+
+// tags turns a missing tag list into an empty one, which the NOT NULL array
+// columns accept and which encodes as [] rather than null.
+func tags(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
+
 func (r *MenuItemRepository) Create(m *model.MenuItem) error {
-	return r.store.db.QueryRow(
-		"INSERT INTO menuitem (name, category_id, price, description) VALUES ($1, $2, $3, $4) RETURNING id",
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	m.Available = m.IsAvailable()
+	m.Allergens = tags(m.Allergens)
+	m.Diets = tags(m.Diets)
+
+	args := []interface{}{
 		m.Name,
 		m.CategoryID,
 		m.Price,
 		m.Description,
+		m.Stock,
+		m.LowStockThreshold,
+		pq.Array(m.Allergens),
+		pq.Array(m.Diets),
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO menuitem (name, category_id, price, description, stock, low_stock_threshold, allergens, diets, "+nutritionColumns+") "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13) RETURNING id",
+		append(args, nutritionArgs(m.Nutrition)...)...,
 	).Scan(&m.ID)
 }
 
+func (r *MenuItemRepository) Find(id int) (*model.MenuItem, error) {
+	m, err := scanMenuItem(r.store.db.QueryRow(
+		"SELECT "+menuItemColumns+" FROM menuitem WHERE id = $1",
+		id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return m, nil
+}
+
 func (r *MenuItemRepository) FindByCategoryId(categoryId int) ([]*model.MenuItem, error) {
-	rows, err := r.store.db.Query(
-		"SELECT id, category_id, name, price, description FROM menuitem WHERE category_id = $1",
+	return r.query(
+		"SELECT "+menuItemColumns+" FROM menuitem WHERE category_id = $1",
 		categoryId,
 	)
+}
+
+func (r *MenuItemRepository) FindLowStock() ([]*model.MenuItem, error) {
+	return r.query(
+		"SELECT " + menuItemColumns + " FROM menuitem WHERE stock IS NOT NULL AND stock <= low_stock_threshold ORDER BY stock",
+	)
+}
+
+func (r *MenuItemRepository) query(query string, args ...interface{}) ([]*model.MenuItem, error) {
+	rows, err := r.store.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -29,14 +127,8 @@ func (r *MenuItemRepository) FindByCategoryId(categoryId int) ([]*model.MenuItem
 
 	var menuItems []*model.MenuItem
 	for rows.Next() {
-		menuItem := &model.MenuItem{}
-		if err := rows.Scan(
-			&menuItem.ID,
-			&menuItem.CategoryID,
-			&menuItem.Name,
-			&menuItem.Price,
-			&menuItem.Description,
-		); err != nil {
+		menuItem, err := scanMenuItem(rows)
+		if err != nil {
 			return nil, err
 		}
 		menuItems = append(menuItems, menuItem)
@@ -48,7 +140,7 @@ func (r *MenuItemRepository) FindByCategoryId(categoryId int) ([]*model.MenuItem
 }
 
 func (r *MenuItemRepository) Update(mi *model.MenuItem) error {
-	_, err := r.store.db.Query(
+	_, err := r.store.db.Exec(
 		"UPDATE menuitem SET name = $1, price = $2, description = $3 WHERE id = $4",
 		mi.Name, mi.Price, mi.Description, mi.ID,
 	)
@@ -67,12 +159,121 @@ func (r *MenuItemRepository) Delete(id int) error {
 	return nil
 }
 
-func (r *MenuItemRepository) GetPrice(id int) int {
+func (r *MenuItemRepository) GetPrice(id int) (int, error) {
 	price := 0
 
 	if err := r.store.db.QueryRow("SELECT price from menuitem WHERE id = $1", id).Scan(&price); err != nil {
-		return 0
+		if err == sql.ErrNoRows {
+			return 0, store.ErrRecordNotFound
+		}
+
+		return 0, err
 	}
 
-	return price
+	return price, nil
+}
+
+// DecrementStock takes quantity portions out of stock in a single statement,
+// so concurrent orders cannot oversell. Untracked items are left untouched.
+func (r *MenuItemRepository) DecrementStock(id int, quantity int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE menuitem SET stock = stock - $1 WHERE id = $2 AND (stock IS NULL OR stock >= $1)",
+		quantity, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		if _, err := r.Find(id); err != nil {
+			return err
+		}
+
+		return store.ErrOutOfStock
+	}
+
+	return nil
+}
+
+// IncrementStock puts quantity portions back into stock. Untracked items are
+// left untouched.
+func (r *MenuItemRepository) IncrementStock(id int, quantity int) error {
+	_, err := r.store.db.Exec("UPDATE menuitem SET stock = stock + $1 WHERE id = $2", quantity, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SetStock sets the stock count, or stops tracking stock when stock is nil.
+func (r *MenuItemRepository) SetStock(id int, stock *int, lowStockThreshold int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE menuitem SET stock = $1, low_stock_threshold = $2 WHERE id = $3",
+		stock, lowStockThreshold, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetTags replaces the allergens and dietary tags of a menu item.
+func (r *MenuItemRepository) SetTags(id int, allergens []string, diets []string) error {
+	res, err := r.store.db.Exec(
+		"UPDATE menuitem SET allergens = $1, diets = $2 WHERE id = $3",
+		pq.Array(tags(allergens)), pq.Array(tags(diets)), id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// SetNutrition replaces the nutrition facts of a menu item, or clears them
+// when nutrition is nil.
+func (r *MenuItemRepository) SetNutrition(id int, nutrition *model.Nutrition) error {
+	res, err := r.store.db.Exec(
+		"UPDATE menuitem SET kcal = $1, protein = $2, carbs = $3, fat = $4, salt = $5 WHERE id = $6",
+		append(nutritionArgs(nutrition), id)...,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
 }
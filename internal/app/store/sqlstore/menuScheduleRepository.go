@@ -0,0 +1,89 @@
+package sqlstore
+
+import (
+	"github.com/lib/pq"
+	"github.com/yeboka/final-project/internal/app/model"
+)
+
+// MenuScheduleRepository ...
+type MenuScheduleRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *MenuScheduleRepository) Create(s *model.MenuSchedule) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	weekdays := make(pq.Int64Array, len(s.Weekdays))
+	for i, d := range s.Weekdays {
+		weekdays[i] = int64(d)
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO menu_schedules (menu_item_id, category_id, weekdays, daily_from, daily_to, starts_on, ends_on) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		nullInt(s.MenuItemId),
+		nullInt(s.CategoryID),
+		weekdays,
+		nullString(s.DailyFrom),
+		nullString(s.DailyTo),
+		nullString(s.StartsOn),
+		nullString(s.EndsOn),
+	).Scan(&s.ID)
+}
+
+// Delete ...
+func (r *MenuScheduleRepository) Delete(id int) error {
+	_, err := r.store.db.Exec("DELETE FROM menu_schedules WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetAll ...
+func (r *MenuScheduleRepository) GetAll() ([]*model.MenuSchedule, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, COALESCE(menu_item_id, 0), COALESCE(category_id, 0), weekdays, COALESCE(daily_from, ''), COALESCE(daily_to, ''), " +
+			"COALESCE(to_char(starts_on, 'YYYY-MM-DD'), ''), COALESCE(to_char(ends_on, 'YYYY-MM-DD'), '') " +
+			"FROM menu_schedules ORDER BY id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*model.MenuSchedule
+	for rows.Next() {
+		s := &model.MenuSchedule{}
+		var weekdays pq.Int64Array
+
+		if err := rows.Scan(
+			&s.ID,
+			&s.MenuItemId,
+			&s.CategoryID,
+			&weekdays,
+			&s.DailyFrom,
+			&s.DailyTo,
+			&s.StartsOn,
+			&s.EndsOn,
+		); err != nil {
+			return nil, err
+		}
+
+		for _, d := range weekdays {
+			s.Weekdays = append(s.Weekdays, int(d))
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
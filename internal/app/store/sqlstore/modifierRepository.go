@@ -0,0 +1,153 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+)
+
+// ModifierRepository ...
+type ModifierRepository struct {
+	store *Store
+}
+
+// CreateGroup stores the group together with the modifiers it lists.
+func (r *ModifierRepository) CreateGroup(g *model.ModifierGroup) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
+	if err := r.store.db.QueryRow(
+		"INSERT INTO modifier_groups (menu_item_id, name, min_select, max_select) VALUES ($1, $2, $3, $4) RETURNING id",
+		g.MenuItemId,
+		g.Name,
+		g.MinSelect,
+		g.MaxSelect,
+	).Scan(&g.ID); err != nil {
+		return err
+	}
+
+	for _, m := range g.Modifiers {
+		m.GroupId = g.ID
+		if err := r.CreateModifier(m); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FindGroup ...
+func (r *ModifierRepository) FindGroup(id int) (*model.ModifierGroup, error) {
+	groups, err := r.loadGroups("WHERE g.id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(groups) == 0 {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return groups[0], nil
+}
+
+// DeleteGroup ...
+func (r *ModifierRepository) DeleteGroup(id int) error {
+	_, err := r.store.db.Exec("DELETE FROM modifier_groups WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateModifier ...
+func (r *ModifierRepository) CreateModifier(m *model.Modifier) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO modifiers (group_id, name, price_delta) VALUES ($1, $2, $3) RETURNING id",
+		m.GroupId,
+		m.Name,
+		m.PriceDelta,
+	).Scan(&m.ID)
+}
+
+// DeleteModifier ...
+func (r *ModifierRepository) DeleteModifier(id int) error {
+	_, err := r.store.db.Exec("DELETE FROM modifiers WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// FindGroupsByMenuItem ...
+func (r *ModifierRepository) FindGroupsByMenuItem(menuItemId int) ([]*model.ModifierGroup, error) {
+	return r.loadGroups("WHERE g.menu_item_id = $1", menuItemId)
+}
+
+// GetAllGroups ...
+func (r *ModifierRepository) GetAllGroups() ([]*model.ModifierGroup, error) {
+	return r.loadGroups("")
+}
+
+// loadGroups reads the groups matching where together with their modifiers
+// in a single left join.
+func (r *ModifierRepository) loadGroups(where string, args ...interface{}) ([]*model.ModifierGroup, error) {
+	rows, err := r.store.db.Query(
+		"SELECT g.id, g.menu_item_id, g.name, g.min_select, g.max_select, m.id, m.name, m.price_delta "+
+			"FROM modifier_groups g LEFT JOIN modifiers m ON m.group_id = g.id "+
+			where+" ORDER BY g.id, m.id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var groups []*model.ModifierGroup
+	var current *model.ModifierGroup
+	for rows.Next() {
+		g := &model.ModifierGroup{Modifiers: []*model.Modifier{}}
+		var modifierId sql.NullInt64
+		var modifierName sql.NullString
+		var priceDelta sql.NullInt64
+
+		if err := rows.Scan(
+			&g.ID,
+			&g.MenuItemId,
+			&g.Name,
+			&g.MinSelect,
+			&g.MaxSelect,
+			&modifierId,
+			&modifierName,
+			&priceDelta,
+		); err != nil {
+			return nil, err
+		}
+
+		if current == nil || current.ID != g.ID {
+			current = g
+			groups = append(groups, current)
+		}
+
+		if modifierId.Valid {
+			current.Modifiers = append(current.Modifiers, &model.Modifier{
+				ID:         int(modifierId.Int64),
+				GroupId:    current.ID,
+				Name:       modifierName.String,
+				PriceDelta: int(priceDelta.Int64),
+			})
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,43 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+)
+
+// nutritionColumns are stored on both menuitem and orderitem. They are all
+// NULL when the nutrition facts are unknown.
+const nutritionColumns = "kcal, protein, carbs, fat, salt"
+
+// nullNutrition scans the nutrition columns.
+type nullNutrition struct {
+	kcal                      sql.NullInt64
+	protein, carbs, fat, salt sql.NullFloat64
+}
+
+func (n *nullNutrition) dest() []interface{} {
+	return []interface{}{&n.kcal, &n.protein, &n.carbs, &n.fat, &n.salt}
+}
+
+func (n *nullNutrition) nutrition() *model.Nutrition {
+	if !n.kcal.Valid {
+		return nil
+	}
+
+	return &model.Nutrition{
+		Kcal:    int(n.kcal.Int64),
+		Protein: n.protein.Float64,
+		Carbs:   n.carbs.Float64,
+		Fat:     n.fat.Float64,
+		Salt:    n.salt.Float64,
+	}
+}
+
+// nutritionArgs returns the values of the nutrition columns.
+func nutritionArgs(n *model.Nutrition) []interface{} {
+	if n == nil {
+		return []interface{}{nil, nil, nil, nil, nil}
+	}
+
+	return []interface{}{n.Kcal, n.Protein, n.Carbs, n.Fat, n.Salt}
+}
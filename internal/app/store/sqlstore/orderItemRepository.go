@@ -7,13 +7,56 @@ type OrderItemRepository struct {
 	s *Store
 }
 
-// Create ...
+// Create stores the order line together with its chosen modifiers and combo
+// components.
 func (i *OrderItemRepository) Create(item *model.OrderItem) error {
-	return i.s.db.QueryRow(
-		"INSERT INTO orderitem (order_id, menu_item_id, quantity) VALUES ($1, $2, $3) RETURNING id",
+	args := []interface{}{
 		item.OrderId,
-		item.MenuItemId,
-		item.Quantity).Scan(&item.ID)
+		nullInt(item.MenuItemId),
+		nullInt(item.ComboId),
+		item.ItemName,
+		item.Quantity,
+		item.UnitPrice,
+		item.LineTotal,
+	}
+
+	if err := i.s.db.QueryRow(
+		"INSERT INTO orderitem (order_id, menu_item_id, combo_id, item_name, quantity, unit_price, line_total, "+nutritionColumns+") "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id",
+		append(args, nutritionArgs(item.Nutrition)...)...,
+	).Scan(&item.ID); err != nil {
+		return err
+	}
+
+	for _, m := range item.Modifiers {
+		m.OrderItemId = item.ID
+		if err := i.s.db.QueryRow(
+			"INSERT INTO orderitem_modifiers (order_item_id, modifier_id, name, price_delta) VALUES ($1, $2, $3, $4) RETURNING id",
+			m.OrderItemId,
+			m.ModifierId,
+			m.Name,
+			m.PriceDelta,
+		).Scan(&m.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range item.Components {
+		c.OrderItemId = item.ID
+		if err := i.s.db.QueryRow(
+			"INSERT INTO orderitem_components (order_item_id, combo_slot_id, slot_name, menu_item_id, item_name) "+
+				"VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			c.OrderItemId,
+			nullInt(c.ComboSlotId),
+			c.SlotName,
+			nullInt(c.MenuItemId),
+			c.ItemName,
+		).Scan(&c.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (i *OrderItemRepository) Delete(id int) error {
@@ -26,7 +69,7 @@ func (i *OrderItemRepository) Delete(id int) error {
 }
 
 func (i *OrderItemRepository) Update(menuItemId int, quantity int) error {
-	_, err := i.s.db.Exec("UPDATE orderitem SET quantity = $1 WHERE menu_item_id = $2", quantity, menuItemId)
+	_, err := i.s.db.Exec("UPDATE orderitem SET quantity = $1, line_total = unit_price * $1 WHERE menu_item_id = $2", quantity, menuItemId)
 	if err != nil {
 		return err
 	}
@@ -45,9 +88,27 @@ func (i *OrderItemRepository) DeleteAllOrder(orderId int) error {
 
 // GetOrderItems ...
 func (i *OrderItemRepository) GetOrderItems(orderId int) ([]*model.OrderItem, error) {
+	orderItems, err := i.getItems(orderId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.loadModifiers(orderId, orderItems); err != nil {
+		return nil, err
+	}
+
+	if err := i.loadComponents(orderId, orderItems); err != nil {
+		return nil, err
+	}
+
+	return orderItems, nil
+}
+
+func (i *OrderItemRepository) getItems(orderId int) ([]*model.OrderItem, error) {
 	var orderItems []*model.OrderItem
 
-	rows, err := i.s.db.Query("SELECT id, order_id, menu_item_id, quantity FROM orderitem WHERE order_id = $1", orderId)
+	rows, err := i.s.db.Query("SELECT id, order_id, COALESCE(menu_item_id, 0), COALESCE(combo_id, 0), item_name, quantity, unit_price, line_total, "+
+		nutritionColumns+" FROM orderitem WHERE order_id = $1 ORDER BY id", orderId)
 	if err != nil {
 		return nil, err
 	}
@@ -55,9 +116,13 @@ func (i *OrderItemRepository) GetOrderItems(orderId int) ([]*model.OrderItem, er
 
 	for rows.Next() {
 		var oi model.OrderItem
-		if err := rows.Scan(&oi.ID, &oi.MenuItemId, &oi.OrderId, &oi.Quantity); err != nil {
+		var nutrition nullNutrition
+
+		dest := []interface{}{&oi.ID, &oi.OrderId, &oi.MenuItemId, &oi.ComboId, &oi.ItemName, &oi.Quantity, &oi.UnitPrice, &oi.LineTotal}
+		if err := rows.Scan(append(dest, nutrition.dest()...)...); err != nil {
 			return nil, err
 		}
+		oi.Nutrition = nutrition.nutrition()
 		orderItems = append(orderItems, &oi)
 	}
 
@@ -67,3 +132,74 @@ func (i *OrderItemRepository) GetOrderItems(orderId int) ([]*model.OrderItem, er
 
 	return orderItems, nil
 }
+
+// loadModifiers attaches the chosen modifiers to the lines of an order.
+func (i *OrderItemRepository) loadModifiers(orderId int, orderItems []*model.OrderItem) error {
+	if len(orderItems) == 0 {
+		return nil
+	}
+
+	byItem := make(map[int]*model.OrderItem, len(orderItems))
+	for _, oi := range orderItems {
+		byItem[oi.ID] = oi
+	}
+
+	rows, err := i.s.db.Query(
+		"SELECT m.id, m.order_item_id, COALESCE(m.modifier_id, 0), m.name, m.price_delta FROM orderitem_modifiers m "+
+			"JOIN orderitem oi ON oi.id = m.order_item_id WHERE oi.order_id = $1 ORDER BY m.id",
+		orderId,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m := &model.OrderItemModifier{}
+		if err := rows.Scan(&m.ID, &m.OrderItemId, &m.ModifierId, &m.Name, &m.PriceDelta); err != nil {
+			return err
+		}
+
+		if oi, ok := byItem[m.OrderItemId]; ok {
+			oi.Modifiers = append(oi.Modifiers, m)
+		}
+	}
+
+	return rows.Err()
+}
+
+// loadComponents attaches the picked combo components to the lines of an
+// order.
+func (i *OrderItemRepository) loadComponents(orderId int, orderItems []*model.OrderItem) error {
+	if len(orderItems) == 0 {
+		return nil
+	}
+
+	byItem := make(map[int]*model.OrderItem, len(orderItems))
+	for _, oi := range orderItems {
+		byItem[oi.ID] = oi
+	}
+
+	rows, err := i.s.db.Query(
+		"SELECT c.id, c.order_item_id, COALESCE(c.combo_slot_id, 0), c.slot_name, COALESCE(c.menu_item_id, 0), c.item_name "+
+			"FROM orderitem_components c JOIN orderitem oi ON oi.id = c.order_item_id WHERE oi.order_id = $1 ORDER BY c.id",
+		orderId,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		c := &model.OrderItemComponent{}
+		if err := rows.Scan(&c.ID, &c.OrderItemId, &c.ComboSlotId, &c.SlotName, &c.MenuItemId, &c.ItemName); err != nil {
+			return err
+		}
+
+		if oi, ok := byItem[c.OrderItemId]; ok {
+			oi.Components = append(oi.Components, c)
+		}
+	}
+
+	return rows.Err()
+}
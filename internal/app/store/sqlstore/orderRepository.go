@@ -1,24 +1,75 @@
 package sqlstore
 
 import (
+	"database/sql"
+	"encoding/json"
+	"github.com/lib/pq"
 	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const orderColumns = "id, user_id, createdat, totalamount, status, payment_method, payment_status, payment_ref, pickup_slot_id, coupon_code, discount_amount"
+
 // OrderRepository ...
 type OrderRepository struct {
 	store *Store
 }
 
+func scanOrder(row scanner) (*model.Order, error) {
+	o := &model.Order{}
+	var paymentRef sql.NullString
+	var pickupSlotId sql.NullInt64
+	var couponCode sql.NullString
+
+	if err := row.Scan(
+		&o.ID,
+		&o.UserId,
+		&o.CreatedAt,
+		&o.TotalAmount,
+		&o.Status,
+		&o.PaymentMethod,
+		&o.PaymentStatus,
+		&paymentRef,
+		&pickupSlotId,
+		&couponCode,
+		&o.DiscountAmount,
+	); err != nil {
+		return nil, err
+	}
+
+	o.PaymentRef = paymentRef.String
+	o.CouponCode = couponCode.String
+	if pickupSlotId.Valid {
+		id := int(pickupSlotId.Int64)
+		o.PickupSlotId = &id
+	}
+
+	return o, nil
+}
+
 // Create ...
 func (o *OrderRepository) Create(order *model.Order) error {
 	order.CreatedAt = time.Now()
+	if order.Status == "" {
+		order.Status = model.OrderStatusPlaced
+	}
 
 	err := o.store.db.QueryRow(
-		"INSERT INTO orders (user_id, createdAt, totalamount) VALUES ($1, $2, $3) RETURNING id",
+		"INSERT INTO orders (user_id, createdAt, totalamount, status, payment_method, payment_status, payment_ref, pickup_slot_id, coupon_code, discount_amount) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id",
 		order.UserId,
 		order.CreatedAt,
 		order.TotalAmount,
+		order.Status,
+		order.PaymentMethod,
+		order.PaymentStatus,
+		nullString(order.PaymentRef),
+		order.PickupSlotId,
+		nullString(order.CouponCode),
+		order.DiscountAmount,
 	).Scan(&order.ID)
 	if err != nil {
 		return err
@@ -27,6 +78,35 @@ func (o *OrderRepository) Create(order *model.Order) error {
 	return nil
 }
 
+// Find ...
+func (o *OrderRepository) Find(id int) (*model.Order, error) {
+	return o.find("SELECT "+orderColumns+" FROM orders WHERE id = $1", id)
+}
+
+// FindForUpdate is like Find but locks the order row until the surrounding
+// transaction ends, so concurrent status changes and edits are serialized.
+func (o *OrderRepository) FindForUpdate(id int) (*model.Order, error) {
+	return o.find("SELECT "+orderColumns+" FROM orders WHERE id = $1 FOR UPDATE", id)
+}
+
+// FindByPaymentRef locks and returns the order paid with the given provider reference.
+func (o *OrderRepository) FindByPaymentRef(ref string) (*model.Order, error) {
+	return o.find("SELECT "+orderColumns+" FROM orders WHERE payment_ref = $1 FOR UPDATE", ref)
+}
+
+func (o *OrderRepository) find(query string, arg interface{}) (*model.Order, error) {
+	order, err := scanOrder(o.store.db.QueryRow(query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return order, nil
+}
+
 // Delete ...
 func (o *OrderRepository) Delete(id int) error {
 	_, err := o.store.db.Exec("DELETE FROM orders WHERE id = $1", id)
@@ -37,8 +117,35 @@ func (o *OrderRepository) Delete(id int) error {
 	return nil
 }
 
-func (o *OrderRepository) Update(id int, totalAmount int) error {
-	_, err := o.store.db.Exec("UPDATE orders SET totalamount = $1 WHERE id = $2", totalAmount, id)
+// Update ...
+func (o *OrderRepository) Update(id int, totalAmount int, discountAmount int) error {
+	_, err := o.store.db.Exec(
+		"UPDATE orders SET totalamount = $1, discount_amount = $2 WHERE id = $3",
+		totalAmount, discountAmount, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdateStatus ...
+func (o *OrderRepository) UpdateStatus(id int, status string) error {
+	_, err := o.store.db.Exec("UPDATE orders SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UpdatePayment ...
+func (o *OrderRepository) UpdatePayment(id int, status string, ref string) error {
+	_, err := o.store.db.Exec(
+		"UPDATE orders SET payment_status = $1, payment_ref = $2 WHERE id = $3",
+		status, nullString(ref), id,
+	)
 	if err != nil {
 		return err
 	}
@@ -50,7 +157,7 @@ func (o *OrderRepository) Update(id int, totalAmount int) error {
 func (o *OrderRepository) GetOrders(userId int) ([]*model.Order, error) {
 	var orders []*model.Order
 
-	rows, err := o.store.db.Query("SELECT id, user_id, createdat, totalamount FROM orders WHERE user_id = $1", userId)
+	rows, err := o.store.db.Query("SELECT "+orderColumns+" FROM orders WHERE user_id = $1", userId)
 	if err != nil {
 		return nil, err
 	}
@@ -58,11 +165,11 @@ func (o *OrderRepository) GetOrders(userId int) ([]*model.Order, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var o model.Order
-		if err := rows.Scan(&o.ID, &o.UserId, &o.CreatedAt, &o.TotalAmount); err != nil {
+		order, err := scanOrder(rows)
+		if err != nil {
 			return nil, err
 		}
-		orders = append(orders, &o)
+		orders = append(orders, order)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -71,3 +178,173 @@ func (o *OrderRepository) GetOrders(userId int) ([]*model.Order, error) {
 
 	return orders, nil
 }
+
+// orderListingItemColumns selects the lines of the listed orders, with their
+// modifiers and components aggregated into JSON arrays. Orders without lines
+// come back once with a NULL line.
+const orderListingItemColumns = "oi.id, COALESCE(oi.menu_item_id, 0), COALESCE(oi.combo_id, 0), COALESCE(oi.item_name, ''), " +
+	"COALESCE(oi.quantity, 0), COALESCE(oi.unit_price, 0), COALESCE(oi.line_total, 0), " +
+	"oi.kcal, oi.protein, oi.carbs, oi.fat, oi.salt, " +
+	"(SELECT COALESCE(json_agg(json_build_object('id', m.id, 'order_item_id', m.order_item_id, " +
+	"'modifier_id', COALESCE(m.modifier_id, 0), 'name', m.name, 'price_delta', m.price_delta) ORDER BY m.id), '[]') " +
+	"FROM orderitem_modifiers m WHERE m.order_item_id = oi.id), " +
+	"(SELECT COALESCE(json_agg(json_build_object('id', c.id, 'order_item_id', c.order_item_id, " +
+	"'combo_slot_id', COALESCE(c.combo_slot_id, 0), 'slot_name', c.slot_name, 'menu_item_id', COALESCE(c.menu_item_id, 0), " +
+	"'item_name', c.item_name) ORDER BY c.id), '[]') " +
+	"FROM orderitem_components c WHERE c.order_item_id = oi.id)"
+
+// orderSortKeys maps the listing sort orders to their column.
+var orderSortKeys = map[string]string{
+	store.OrderSortNewest:    "createdat",
+	store.OrderSortOldest:    "createdat",
+	store.OrderSortTotalDesc: "totalamount",
+	store.OrderSortTotalAsc:  "totalamount",
+}
+
+// rowScanner appends extra destinations to every Scan call, so that scanOrder
+// can read the order columns of a joined row.
+type rowScanner struct {
+	row   scanner
+	extra []interface{}
+}
+
+func (r rowScanner) Scan(dest ...interface{}) error {
+	return r.row.Scan(append(dest, r.extra...)...)
+}
+
+// List returns a page of orders together with their lines in a single query.
+// Pages are keyset-paginated on the sort column and the order ID.
+func (o *OrderRepository) List(f store.OrderFilter) ([]*model.Order, error) {
+	var where []string
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if f.UserId != 0 {
+		where = append(where, "user_id = "+arg(f.UserId))
+	}
+
+	if len(f.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(pq.Array(f.Statuses))+")")
+	}
+
+	if f.From != nil {
+		where = append(where, "createdat >= "+arg(*f.From))
+	}
+
+	if f.To != nil {
+		where = append(where, "createdat < "+arg(*f.To))
+	}
+
+	if f.MenuItemId != 0 {
+		id := arg(f.MenuItemId)
+		where = append(where, "EXISTS (SELECT 1 FROM orderitem x LEFT JOIN orderitem_components xc ON xc.order_item_id = x.id "+
+			"WHERE x.order_id = orders.id AND (x.menu_item_id = "+id+" OR xc.menu_item_id = "+id+"))")
+	}
+
+	if f.MinTotal != nil {
+		where = append(where, "totalamount >= "+arg(*f.MinTotal))
+	}
+
+	if f.MaxTotal != nil {
+		where = append(where, "totalamount <= "+arg(*f.MaxTotal))
+	}
+
+	sort := f.Sort
+	if sort == "" {
+		sort = store.OrderSortNewest
+	}
+
+	column := orderSortKeys[sort]
+	direction, cmp := "ASC", ">"
+	if strings.HasPrefix(sort, "-") {
+		direction, cmp = "DESC", "<"
+	}
+
+	if f.After != nil {
+		var key interface{} = f.After.CreatedAt
+		if column == "totalamount" {
+			key = f.After.TotalAmount
+		}
+
+		where = append(where, "("+column+", id) "+cmp+" ("+arg(key)+", "+arg(f.After.ID)+")")
+	}
+
+	page := "SELECT " + orderColumns + " FROM orders"
+	if len(where) > 0 {
+		page += " WHERE " + strings.Join(where, " AND ")
+	}
+
+	page += " ORDER BY " + column + " " + direction + ", id " + direction
+	if f.Limit > 0 {
+		page += " LIMIT " + arg(f.Limit)
+	}
+
+	rows, err := o.store.db.Query(
+		"SELECT o.*, "+orderListingItemColumns+" FROM ("+page+") o LEFT JOIN orderitem oi ON oi.order_id = o.id "+
+			"ORDER BY o."+column+" "+direction+", o.id "+direction+", oi.id",
+		args...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []*model.Order
+	for rows.Next() {
+		var itemId sql.NullInt64
+		var oi model.OrderItem
+		var nutrition nullNutrition
+		var modifiers, components []byte
+
+		extra := []interface{}{&itemId, &oi.MenuItemId, &oi.ComboId, &oi.ItemName, &oi.Quantity, &oi.UnitPrice, &oi.LineTotal}
+		extra = append(extra, nutrition.dest()...)
+		extra = append(extra, &modifiers, &components)
+
+		order, err := scanOrder(rowScanner{row: rows, extra: extra})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(orders) == 0 || orders[len(orders)-1].ID != order.ID {
+			orders = append(orders, order)
+		}
+
+		if !itemId.Valid {
+			continue
+		}
+
+		oi.ID = int(itemId.Int64)
+		oi.OrderId = order.ID
+		oi.Nutrition = nutrition.nutrition()
+
+		if err := json.Unmarshal(modifiers, &oi.Modifiers); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(components, &oi.Components); err != nil {
+			return nil, err
+		}
+
+		last := orders[len(orders)-1]
+		last.Items = append(last.Items, &oi)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// nullString maps the empty string to NULL, for optional unique columns.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+// nullInt maps zero to NULL, for optional references.
+func nullInt(n int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(n), Valid: n != 0}
+}
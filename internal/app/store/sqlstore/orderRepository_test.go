@@ -0,0 +1,106 @@
+package sqlstore_test
+
+import (
+	"database/sql"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"github.com/yeboka/final-project/internal/app/store/sqlstore"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDB connects to the migrated database named by DATABASE_URL and empties
+// the tables the test touches. Tests that need it are skipped without one.
+func testDB(t *testing.T, tables ...string) *sql.DB {
+	t.Helper()
+
+	databaseURL := os.Getenv("DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", databaseURL)
+	require.NoError(t, err)
+	require.NoError(t, db.Ping())
+
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = pq.QuoteIdentifier(table)
+	}
+
+	truncate := func() {
+		_, err := db.Exec("TRUNCATE " + strings.Join(quoted, ", ") + " RESTART IDENTITY CASCADE")
+		require.NoError(t, err)
+	}
+	truncate()
+
+	t.Cleanup(func() {
+		truncate()
+		db.Close()
+	})
+
+	return db
+}
+
+func TestOrderRepository_List_KeysetAcrossTies(t *testing.T) {
+	db := testDB(t, "users", "orders")
+	st := sqlstore.New(db)
+
+	u := &model.User{Email: "user@example.org", Username: "user", Password: "password"}
+	require.NoError(t, st.User().Create(u))
+
+	// Orders 1-4 share a creation time and so do 5-7; totals tie as well.
+	early := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	late := early.Add(time.Hour)
+	for i, total := range []int{200, 100, 200, 300, 200, 100, 200} {
+		o := &model.Order{UserId: u.ID, TotalAmount: total, PaymentMethod: model.PaymentMethodCash}
+		require.NoError(t, st.Order().Create(o))
+
+		createdAt := early
+		if i >= 4 {
+			createdAt = late
+		}
+
+		_, err := db.Exec("UPDATE orders SET createdat = $1 WHERE id = $2", createdAt, o.ID)
+		require.NoError(t, err)
+	}
+
+	collect := func(sort string, limit int) []int {
+		var ids []int
+		f := store.OrderFilter{UserId: u.ID, Sort: sort, Limit: limit}
+		for pages := 0; pages < 10; pages++ {
+			orders, err := st.Order().List(f)
+			require.NoError(t, err)
+
+			for _, o := range orders {
+				ids = append(ids, o.ID)
+			}
+
+			if len(orders) < limit {
+				return ids
+			}
+
+			f.After = store.CursorOf(orders[len(orders)-1])
+		}
+
+		t.Fatalf("listing with sort %s did not end", sort)
+		return nil
+	}
+
+	for _, limit := range []int{1, 2, 3, 10} {
+		assert.Equal(t, []int{2, 6, 1, 3, 5, 7, 4}, collect(store.OrderSortTotalAsc, limit), "total, limit %d", limit)
+		assert.Equal(t, []int{4, 7, 5, 3, 1, 6, 2}, collect(store.OrderSortTotalDesc, limit), "-total, limit %d", limit)
+		assert.Equal(t, []int{1, 2, 3, 4, 5, 6, 7}, collect(store.OrderSortOldest, limit), "created_at, limit %d", limit)
+		assert.Equal(t, []int{7, 6, 5, 4, 3, 2, 1}, collect(store.OrderSortNewest, limit), "-created_at, limit %d", limit)
+	}
+
+	from := late
+	orders, err := st.Order().List(store.OrderFilter{From: &from, Statuses: []string{model.OrderStatusPlaced}})
+	require.NoError(t, err)
+	assert.Len(t, orders, 3)
+}
@@ -0,0 +1,47 @@
+package sqlstore
+
+import "github.com/yeboka/final-project/internal/app/model"
+
+// OrderStatusHistoryRepository ...
+type OrderStatusHistoryRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *OrderStatusHistoryRepository) Create(c *model.OrderStatusChange) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, changed_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		c.OrderId,
+		c.FromStatus,
+		c.ToStatus,
+		c.ChangedBy,
+		c.ChangedAt,
+	).Scan(&c.ID)
+}
+
+// GetByOrder ...
+func (r *OrderStatusHistoryRepository) GetByOrder(orderId int) ([]*model.OrderStatusChange, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, order_id, from_status, to_status, changed_by, changed_at FROM order_status_history WHERE order_id = $1 ORDER BY changed_at, id",
+		orderId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*model.OrderStatusChange
+	for rows.Next() {
+		c := &model.OrderStatusChange{}
+		if err := rows.Scan(&c.ID, &c.OrderId, &c.FromStatus, &c.ToStatus, &c.ChangedBy, &c.ChangedAt); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
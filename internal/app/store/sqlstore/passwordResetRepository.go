@@ -0,0 +1,76 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// PasswordResetRepository ...
+type PasswordResetRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *PasswordResetRepository) Create(pr *model.PasswordReset) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO password_resets (user_id, token_hash, created_at, expires_at) VALUES ($1, $2, $3, $4) RETURNING id",
+		pr.UserId,
+		pr.TokenHash,
+		pr.CreatedAt,
+		pr.ExpiresAt,
+	).Scan(&pr.ID)
+}
+
+// FindByHash ...
+func (r *PasswordResetRepository) FindByHash(hash string) (*model.PasswordReset, error) {
+	pr := &model.PasswordReset{}
+	var usedAt sql.NullTime
+
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, token_hash, created_at, expires_at, used_at FROM password_resets WHERE token_hash = $1",
+		hash,
+	).Scan(
+		&pr.ID,
+		&pr.UserId,
+		&pr.TokenHash,
+		&pr.CreatedAt,
+		&pr.ExpiresAt,
+		&usedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		pr.UsedAt = &usedAt.Time
+	}
+
+	return pr, nil
+}
+
+// Use marks the token used unless it already is, and reports whether it
+// did. Of two requests racing to use the same token only one gets true.
+func (r *PasswordResetRepository) Use(id int, now time.Time) (bool, error) {
+	res, err := r.store.db.Exec("UPDATE password_resets SET used_at = $2 WHERE id = $1 AND used_at IS NULL", id, now)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// UseUser marks every unused token of the user used.
+func (r *PasswordResetRepository) UseUser(userId int, now time.Time) error {
+	_, err := r.store.db.Exec("UPDATE password_resets SET used_at = $2 WHERE user_id = $1 AND used_at IS NULL", userId, now)
+	return err
+}
@@ -0,0 +1,32 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+)
+
+// PaymentEventRepository ...
+type PaymentEventRepository struct {
+	store *Store
+}
+
+// Record stores the event unless one with the same provider id is already
+// stored, and reports whether it did. Of two transactions recording the same
+// event the second waits for the first and gets false once it commits.
+func (r *PaymentEventRepository) Record(e *model.PaymentEvent) (bool, error) {
+	if err := r.store.db.QueryRow(
+		"INSERT INTO payment_events (event_id, type, ref, received_at) VALUES ($1, $2, $3, $4) ON CONFLICT (event_id) DO NOTHING RETURNING id",
+		e.EventId,
+		e.Type,
+		e.Ref,
+		e.ReceivedAt,
+	).Scan(&e.ID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
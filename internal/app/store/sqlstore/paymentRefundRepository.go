@@ -0,0 +1,135 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"time"
+)
+
+// PaymentRefundRepository ...
+type PaymentRefundRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *PaymentRefundRepository) Create(pr *model.PaymentRefund) error {
+	if pr.Status == "" {
+		pr.Status = model.RefundStatusPending
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO payment_refunds (order_id, payment_ref, amount, status, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		pr.OrderId,
+		pr.PaymentRef,
+		pr.Amount,
+		pr.Status,
+		pr.CreatedAt,
+	).Scan(&pr.ID)
+}
+
+// GetPending returns up to limit refunds that still have to be sent to the
+// payment provider, oldest first.
+func (r *PaymentRefundRepository) GetPending(limit int) ([]*model.PaymentRefund, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, order_id, payment_ref, amount, status, attempts, last_error, created_at, settled_at FROM payment_refunds WHERE status = $1 ORDER BY id LIMIT $2",
+		model.RefundStatusPending,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []*model.PaymentRefund
+	for rows.Next() {
+		pr := &model.PaymentRefund{}
+		var orderId sql.NullInt64
+		var settledAt sql.NullTime
+		if err := rows.Scan(
+			&pr.ID,
+			&orderId,
+			&pr.PaymentRef,
+			&pr.Amount,
+			&pr.Status,
+			&pr.Attempts,
+			&pr.LastError,
+			&pr.CreatedAt,
+			&settledAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if orderId.Valid {
+			id := int(orderId.Int64)
+			pr.OrderId = &id
+		}
+
+		if settledAt.Valid {
+			pr.SettledAt = &settledAt.Time
+		}
+
+		refunds = append(refunds, pr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return refunds, nil
+}
+
+// Claim moves a pending refund to processing and reports whether it did, so
+// that of two workers racing for the same refund only one sends it.
+func (r *PaymentRefundRepository) Claim(id int) (bool, error) {
+	res, err := r.store.db.Exec(
+		"UPDATE payment_refunds SET status = $2 WHERE id = $1 AND status = $3",
+		id,
+		model.RefundStatusProcessing,
+		model.RefundStatusPending,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// Settle marks a refund accepted by the payment provider.
+func (r *PaymentRefundRepository) Settle(id int, now time.Time) error {
+	_, err := r.store.db.Exec(
+		"UPDATE payment_refunds SET status = $2, settled_at = $3 WHERE id = $1",
+		id,
+		model.RefundStatusDone,
+		now,
+	)
+	return err
+}
+
+// Release puts a claimed refund back to pending after a failed attempt and
+// records why it failed.
+func (r *PaymentRefundRepository) Release(id int, reason string) error {
+	_, err := r.store.db.Exec(
+		"UPDATE payment_refunds SET status = $2, attempts = attempts + 1, last_error = $3 WHERE id = $1",
+		id,
+		model.RefundStatusPending,
+		reason,
+	)
+	return err
+}
+
+// SettleRef marks every unsettled refund of a payment settled, once the
+// provider reports the payment refunded.
+func (r *PaymentRefundRepository) SettleRef(ref string, now time.Time) error {
+	_, err := r.store.db.Exec(
+		"UPDATE payment_refunds SET status = $2, settled_at = $3 WHERE payment_ref = $1 AND status <> $2",
+		ref,
+		model.RefundStatusDone,
+		now,
+	)
+	return err
+}
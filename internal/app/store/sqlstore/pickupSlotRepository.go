@@ -0,0 +1,140 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+const pickupSlotColumns = "id, starts_at, ends_at, max_orders, max_items, reserved_orders, reserved_items"
+
+// PickupSlotRepository ...
+type PickupSlotRepository struct {
+	store *Store
+}
+
+func scanPickupSlot(row scanner) (*model.PickupSlot, error) {
+	p := &model.PickupSlot{}
+
+	if err := row.Scan(
+		&p.ID,
+		&p.StartsAt,
+		&p.EndsAt,
+		&p.MaxOrders,
+		&p.MaxItems,
+		&p.ReservedOrders,
+		&p.ReservedItems,
+	); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Create ...
+func (r *PickupSlotRepository) Create(p *model.PickupSlot) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO pickup_slots (starts_at, ends_at, max_orders, max_items) VALUES ($1, $2, $3, $4) RETURNING id",
+		p.StartsAt,
+		p.EndsAt,
+		p.MaxOrders,
+		p.MaxItems,
+	).Scan(&p.ID)
+}
+
+// Find ...
+func (r *PickupSlotRepository) Find(id int) (*model.PickupSlot, error) {
+	p, err := scanPickupSlot(r.store.db.QueryRow(
+		"SELECT "+pickupSlotColumns+" FROM pickup_slots WHERE id = $1",
+		id,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// FindBetween returns the slots starting in [from, to), earliest first.
+func (r *PickupSlotRepository) FindBetween(from, to time.Time) ([]*model.PickupSlot, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+pickupSlotColumns+" FROM pickup_slots WHERE starts_at >= $1 AND starts_at < $2 ORDER BY starts_at",
+		from, to,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slots []*model.PickupSlot
+	for rows.Next() {
+		p, err := scanPickupSlot(rows)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+// Reserve books one order with the given number of items in the slot. The
+// capacity check and the increment happen in one statement, so concurrent
+// orders cannot overbook the slot.
+func (r *PickupSlotRepository) Reserve(id int, items int) error {
+	res, err := r.store.db.Exec(
+		"UPDATE pickup_slots SET reserved_orders = reserved_orders + 1, reserved_items = reserved_items + $1 "+
+			"WHERE id = $2 AND reserved_orders < max_orders AND reserved_items + $1 <= max_items",
+		items, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		if _, err := r.Find(id); err != nil {
+			return err
+		}
+
+		return store.ErrCapacityFull
+	}
+
+	return nil
+}
+
+// Release gives back a reservation made with Reserve.
+func (r *PickupSlotRepository) Release(id int, items int) error {
+	_, err := r.store.db.Exec(
+		"UPDATE pickup_slots SET reserved_orders = GREATEST(reserved_orders - 1, 0), reserved_items = GREATEST(reserved_items - $1, 0) WHERE id = $2",
+		items, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete ...
+func (r *PickupSlotRepository) Delete(id int) error {
+	_, err := r.store.db.Exec("DELETE FROM pickup_slots WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
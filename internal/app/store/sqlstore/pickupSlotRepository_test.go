@@ -0,0 +1,31 @@
+package sqlstore_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store/sqlstore"
+	"testing"
+	"time"
+)
+
+func TestPickupSlotRepository_Delete_KeepsOrders(t *testing.T) {
+	db := testDB(t, "users", "orders", "pickup_slots")
+	st := sqlstore.New(db)
+
+	u := &model.User{Email: "user@example.org", Username: "user", Password: "password"}
+	require.NoError(t, st.User().Create(u))
+
+	start := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	slot := &model.PickupSlot{StartsAt: start, EndsAt: start.Add(15 * time.Minute), MaxOrders: 5, MaxItems: 5}
+	require.NoError(t, st.PickupSlot().Create(slot))
+
+	o := &model.Order{UserId: u.ID, TotalAmount: 100, PaymentMethod: model.PaymentMethodCash, PickupSlotId: &slot.ID}
+	require.NoError(t, st.Order().Create(o))
+
+	require.NoError(t, st.PickupSlot().Delete(slot.ID))
+
+	found, err := st.Order().Find(o.ID)
+	require.NoError(t, err)
+	assert.Nil(t, found.PickupSlotId)
+}
@@ -0,0 +1,245 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+const promotionColumns = "id, name, code, kind, percent, amount, menu_item_id, category_id, buy_quantity, free_quantity, " +
+	"starts_at, ends_at, daily_from, daily_to, max_uses, max_uses_per_user"
+
+// PromotionRepository ...
+type PromotionRepository struct {
+	store *Store
+}
+
+func scanPromotion(row scanner) (*model.Promotion, error) {
+	p := &model.Promotion{}
+	var code, dailyFrom, dailyTo sql.NullString
+	var menuItemId, categoryId sql.NullInt64
+	var startsAt, endsAt sql.NullTime
+
+	if err := row.Scan(
+		&p.ID,
+		&p.Name,
+		&code,
+		&p.Kind,
+		&p.Percent,
+		&p.Amount,
+		&menuItemId,
+		&categoryId,
+		&p.BuyQuantity,
+		&p.FreeQuantity,
+		&startsAt,
+		&endsAt,
+		&dailyFrom,
+		&dailyTo,
+		&p.MaxUses,
+		&p.MaxUsesPerUser,
+	); err != nil {
+		return nil, err
+	}
+
+	p.Code = code.String
+	p.DailyFrom = dailyFrom.String
+	p.DailyTo = dailyTo.String
+	p.MenuItemId = int(menuItemId.Int64)
+	p.CategoryID = int(categoryId.Int64)
+	if startsAt.Valid {
+		p.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		p.EndsAt = &endsAt.Time
+	}
+
+	return p, nil
+}
+
+// Create ...
+func (r *PromotionRepository) Create(p *model.Promotion) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	return r.store.db.QueryRow(
+		"INSERT INTO promotions (name, code, kind, percent, amount, menu_item_id, category_id, buy_quantity, free_quantity, "+
+			"starts_at, ends_at, daily_from, daily_to, max_uses, max_uses_per_user) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING id",
+		p.Name,
+		nullString(p.Code),
+		p.Kind,
+		p.Percent,
+		p.Amount,
+		nullInt(p.MenuItemId),
+		nullInt(p.CategoryID),
+		p.BuyQuantity,
+		p.FreeQuantity,
+		p.StartsAt,
+		p.EndsAt,
+		nullString(p.DailyFrom),
+		nullString(p.DailyTo),
+		p.MaxUses,
+		p.MaxUsesPerUser,
+	).Scan(&p.ID)
+}
+
+// Find ...
+func (r *PromotionRepository) Find(id int) (*model.Promotion, error) {
+	return r.find("SELECT "+promotionColumns+" FROM promotions WHERE id = $1", id)
+}
+
+// FindForUpdate is like Find but locks the promotion row until the
+// surrounding transaction ends, so usage limits hold under concurrent orders.
+func (r *PromotionRepository) FindForUpdate(id int) (*model.Promotion, error) {
+	return r.find("SELECT "+promotionColumns+" FROM promotions WHERE id = $1 FOR UPDATE", id)
+}
+
+// FindByCode ...
+func (r *PromotionRepository) FindByCode(code string) (*model.Promotion, error) {
+	return r.find("SELECT "+promotionColumns+" FROM promotions WHERE code = $1", code)
+}
+
+func (r *PromotionRepository) find(query string, arg interface{}) (*model.Promotion, error) {
+	p, err := scanPromotion(r.store.db.QueryRow(query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetAutomatic returns the promotions that apply without a coupon code.
+func (r *PromotionRepository) GetAutomatic() ([]*model.Promotion, error) {
+	return r.query("SELECT " + promotionColumns + " FROM promotions WHERE code IS NULL ORDER BY id")
+}
+
+// GetAll ...
+func (r *PromotionRepository) GetAll() ([]*model.Promotion, error) {
+	return r.query("SELECT " + promotionColumns + " FROM promotions ORDER BY id")
+}
+
+func (r *PromotionRepository) query(query string, args ...interface{}) ([]*model.Promotion, error) {
+	rows, err := r.store.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []*model.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		promotions = append(promotions, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return promotions, nil
+}
+
+// Delete ...
+func (r *PromotionRepository) Delete(id int) error {
+	_, err := r.store.db.Exec("DELETE FROM promotions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CreateDiscount ...
+func (r *PromotionRepository) CreateDiscount(d *model.OrderDiscount) error {
+	d.CreatedAt = time.Now()
+
+	return r.store.db.QueryRow(
+		"INSERT INTO order_discounts (order_id, promotion_id, user_id, name, code, amount, created_at) "+
+			"VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		d.OrderId,
+		nullInt(d.PromotionId),
+		d.UserId,
+		d.Name,
+		nullString(d.Code),
+		d.Amount,
+		d.CreatedAt,
+	).Scan(&d.ID)
+}
+
+// GetDiscountsByOrder ...
+func (r *PromotionRepository) GetDiscountsByOrder(orderId int) ([]*model.OrderDiscount, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, order_id, COALESCE(promotion_id, 0), user_id, name, COALESCE(code, ''), amount, created_at "+
+			"FROM order_discounts WHERE order_id = $1 ORDER BY id",
+		orderId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var discounts []*model.OrderDiscount
+	for rows.Next() {
+		d := &model.OrderDiscount{}
+		if err := rows.Scan(&d.ID, &d.OrderId, &d.PromotionId, &d.UserId, &d.Name, &d.Code, &d.Amount, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		discounts = append(discounts, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return discounts, nil
+}
+
+// DeleteDiscountsByOrder ...
+func (r *PromotionRepository) DeleteDiscountsByOrder(orderId int) error {
+	_, err := r.store.db.Exec("DELETE FROM order_discounts WHERE order_id = $1", orderId)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// countedUse restricts a usage count to orders that still stand: cancelled
+// and rejected orders keep their discounts but give their uses back.
+const countedUse = "JOIN orders o ON o.id = d.order_id AND o.status NOT IN ('cancelled', 'rejected')"
+
+// CountUses ...
+func (r *PromotionRepository) CountUses(promotionId int) (int, error) {
+	var n int
+	if err := r.store.db.QueryRow(
+		"SELECT COUNT(DISTINCT d.order_id) FROM order_discounts d "+countedUse+" WHERE d.promotion_id = $1",
+		promotionId,
+	).Scan(&n); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// CountUserUses ...
+func (r *PromotionRepository) CountUserUses(promotionId int, userId int) (int, error) {
+	var n int
+	if err := r.store.db.QueryRow(
+		"SELECT COUNT(DISTINCT d.order_id) FROM order_discounts d "+countedUse+" WHERE d.promotion_id = $1 AND d.user_id = $2",
+		promotionId, userId,
+	).Scan(&n); err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
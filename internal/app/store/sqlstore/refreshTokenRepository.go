@@ -0,0 +1,72 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// RefreshTokenRepository ...
+type RefreshTokenRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *RefreshTokenRepository) Create(t *model.RefreshToken) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO refresh_tokens (user_id, session_id, token_hash, expires_at, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+		t.UserId,
+		t.SessionId,
+		t.TokenHash,
+		t.ExpiresAt,
+		t.CreatedAt,
+	).Scan(&t.ID)
+}
+
+// FindByHash ...
+func (r *RefreshTokenRepository) FindByHash(hash string) (*model.RefreshToken, error) {
+	t := &model.RefreshToken{}
+	var revokedAt sql.NullTime
+
+	if err := r.store.db.QueryRow(
+		"SELECT id, user_id, session_id, token_hash, expires_at, created_at, revoked_at FROM refresh_tokens WHERE token_hash = $1",
+		hash,
+	).Scan(
+		&t.ID,
+		&t.UserId,
+		&t.SessionId,
+		&t.TokenHash,
+		&t.ExpiresAt,
+		&t.CreatedAt,
+		&revokedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+
+	return t, nil
+}
+
+// Revoke revokes the token unless it already is, and reports whether it
+// did. Of two requests racing to use the same token only one gets true.
+func (r *RefreshTokenRepository) Revoke(id int, now time.Time) (bool, error) {
+	res, err := r.store.db.Exec("UPDATE refresh_tokens SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL", id, now)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
@@ -0,0 +1,178 @@
+package sqlstore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+)
+
+// reportedOrders restricts a report to the sales of the range in $1 and $2:
+// orders that were picked up and not refunded.
+const reportedOrders = "o.status = 'picked_up' AND o.payment_status NOT IN ('refunded', 'refund_pending') AND o.createdat >= $1 AND o.createdat < $2"
+
+// ReportRepository ...
+type ReportRepository struct {
+	store *Store
+}
+
+// Revenue ...
+func (r *ReportRepository) Revenue(rng store.ReportRange, period string) ([]*model.RevenuePeriod, error) {
+	rows, err := r.store.db.Query(
+		"SELECT date_trunc($3, o.createdat), COUNT(*), SUM(o.totalamount + o.discount_amount), SUM(o.discount_amount), SUM(o.totalamount) "+
+			"FROM orders o WHERE "+reportedOrders+" GROUP BY 1 ORDER BY 1",
+		rng.From,
+		rng.To,
+		period,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	periods := []*model.RevenuePeriod{}
+	for rows.Next() {
+		p := &model.RevenuePeriod{}
+		if err := rows.Scan(&p.PeriodStart, &p.Orders, &p.Subtotal, &p.Discounts, &p.Revenue); err != nil {
+			return nil, err
+		}
+		periods = append(periods, p)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return periods, nil
+}
+
+// TopItems returns the best selling menu items, most portions first.
+// Portions picked for a combo count as well.
+func (r *ReportRepository) TopItems(rng store.ReportRange, limit int) ([]*model.ItemSales, error) {
+	rows, err := r.store.db.Query(
+		"WITH sold AS ("+
+			"SELECT oi.menu_item_id, oi.quantity, 0 AS combo_quantity, oi.line_total AS revenue "+
+			"FROM orders o JOIN orderitem oi ON oi.order_id = o.id "+
+			"WHERE "+reportedOrders+" AND oi.menu_item_id IS NOT NULL "+
+			"UNION ALL "+
+			"SELECT c.menu_item_id, 0, oi.quantity, 0 "+
+			"FROM orders o JOIN orderitem oi ON oi.order_id = o.id JOIN orderitem_components c ON c.order_item_id = oi.id "+
+			"WHERE "+reportedOrders+" AND c.menu_item_id IS NOT NULL"+
+			") "+
+			"SELECT m.id, m.name, COALESCE(m.category_id, 0), SUM(s.quantity), SUM(s.combo_quantity), SUM(s.revenue) "+
+			"FROM sold s JOIN menuitem m ON m.id = s.menu_item_id "+
+			"GROUP BY m.id, m.name, m.category_id "+
+			"ORDER BY SUM(s.quantity) + SUM(s.combo_quantity) DESC, SUM(s.revenue) DESC, m.id "+
+			"LIMIT $3",
+		rng.From,
+		rng.To,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []*model.ItemSales{}
+	for rows.Next() {
+		s := &model.ItemSales{}
+		if err := rows.Scan(&s.MenuItemId, &s.Name, &s.CategoryID, &s.Quantity, &s.ComboQuantity, &s.Revenue); err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// SalesByCategory returns the sales of every category that sold anything,
+// highest revenue first. A line counts for the category of its menu item or
+// combo and for every category above it.
+func (r *ReportRepository) SalesByCategory(rng store.ReportRange) ([]*model.CategorySales, error) {
+	rows, err := r.store.db.Query(
+		"WITH RECURSIVE ancestors (category_id, ancestor_id) AS ("+
+			"SELECT id, id FROM categories "+
+			"UNION "+
+			"SELECT a.category_id, c.parent_id FROM ancestors a JOIN categories c ON c.id = a.ancestor_id "+
+			"WHERE c.parent_id IS NOT NULL"+
+			"), sold AS ("+
+			"SELECT COALESCE(m.category_id, cb.category_id) AS category_id, oi.quantity, oi.line_total "+
+			"FROM orders o JOIN orderitem oi ON oi.order_id = o.id "+
+			"LEFT JOIN menuitem m ON m.id = oi.menu_item_id "+
+			"LEFT JOIN combos cb ON cb.id = oi.combo_id "+
+			"WHERE "+reportedOrders+
+			") "+
+			"SELECT c.id, COALESCE(c.parent_id, -1), c.name, SUM(s.quantity), SUM(s.line_total) "+
+			"FROM sold s JOIN ancestors a ON a.category_id = s.category_id JOIN categories c ON c.id = a.ancestor_id "+
+			"GROUP BY c.id, c.parent_id, c.name "+
+			"ORDER BY SUM(s.line_total) DESC, c.id",
+		rng.From,
+		rng.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*model.CategorySales{}
+	for rows.Next() {
+		s := &model.CategorySales{}
+		if err := rows.Scan(&s.CategoryID, &s.ParentID, &s.Name, &s.Quantity, &s.Revenue); err != nil {
+			return nil, err
+		}
+		categories = append(categories, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+// OrderValue ...
+func (r *ReportRepository) OrderValue(rng store.ReportRange) (*model.OrderValue, error) {
+	v := &model.OrderValue{}
+	if err := r.store.db.QueryRow(
+		"SELECT COUNT(*), COALESCE(SUM(o.totalamount), 0), COALESCE(ROUND(AVG(o.totalamount)), 0)::int "+
+			"FROM orders o WHERE "+reportedOrders,
+		rng.From,
+		rng.To,
+	).Scan(&v.Orders, &v.Revenue, &v.Average); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// PeakHours returns the sales per hour of the day, for the hours that had
+// any.
+func (r *ReportRepository) PeakHours(rng store.ReportRange) ([]*model.HourSales, error) {
+	rows, err := r.store.db.Query(
+		"SELECT EXTRACT(HOUR FROM o.createdat)::int, COUNT(*), SUM(o.totalamount) "+
+			"FROM orders o WHERE "+reportedOrders+" GROUP BY 1 ORDER BY 1",
+		rng.From,
+		rng.To,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hours := []*model.HourSales{}
+	for rows.Next() {
+		h := &model.HourSales{}
+		if err := rows.Scan(&h.Hour, &h.Orders, &h.Revenue); err != nil {
+			return nil, err
+		}
+		hours = append(hours, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return hours, nil
+}
@@ -0,0 +1,126 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+const sessionColumns = "id, user_id, token_hash, device, ip, created_at, last_seen_at, revoked_at"
+
+// SessionRepository ...
+type SessionRepository struct {
+	store *Store
+}
+
+func scanSession(row scanner) (*model.Session, error) {
+	s := &model.Session{}
+	var revokedAt sql.NullTime
+
+	if err := row.Scan(
+		&s.ID,
+		&s.UserId,
+		&s.TokenHash,
+		&s.Device,
+		&s.IP,
+		&s.CreatedAt,
+		&s.LastSeenAt,
+		&revokedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+
+	return s, nil
+}
+
+// Create ...
+func (r *SessionRepository) Create(s *model.Session) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO sessions (user_id, token_hash, device, ip, created_at, last_seen_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id",
+		s.UserId,
+		s.TokenHash,
+		s.Device,
+		s.IP,
+		s.CreatedAt,
+		s.LastSeenAt,
+	).Scan(&s.ID)
+}
+
+// Find ...
+func (r *SessionRepository) Find(id int) (*model.Session, error) {
+	return r.find("SELECT "+sessionColumns+" FROM sessions WHERE id = $1", id)
+}
+
+// FindByHash ...
+func (r *SessionRepository) FindByHash(hash string) (*model.Session, error) {
+	return r.find("SELECT "+sessionColumns+" FROM sessions WHERE token_hash = $1", hash)
+}
+
+func (r *SessionRepository) find(query string, arg interface{}) (*model.Session, error) {
+	s, err := scanSession(r.store.db.QueryRow(query, arg))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// GetByUser returns the sessions of the user that were not revoked, most
+// recently seen first.
+func (r *SessionRepository) GetByUser(userId int) ([]*model.Session, error) {
+	rows, err := r.store.db.Query(
+		"SELECT "+sessionColumns+" FROM sessions WHERE user_id = $1 AND revoked_at IS NULL ORDER BY last_seen_at DESC, id DESC",
+		userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*model.Session
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch ...
+func (r *SessionRepository) Touch(id int, now time.Time) error {
+	_, err := r.store.db.Exec("UPDATE sessions SET last_seen_at = $2 WHERE id = $1", id, now)
+	return err
+}
+
+// Revoke ...
+func (r *SessionRepository) Revoke(id int, now time.Time) error {
+	_, err := r.store.db.Exec("UPDATE sessions SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL", id, now)
+	return err
+}
+
+// RevokeUser revokes every session of the user but exceptId.
+func (r *SessionRepository) RevokeUser(userId int, exceptId int, now time.Time) error {
+	_, err := r.store.db.Exec(
+		"UPDATE sessions SET revoked_at = $3 WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL",
+		userId,
+		exceptId,
+		now,
+	)
+	return err
+}
@@ -1,27 +1,75 @@
 package sqlstore
 
 import (
+	"context"
 	"database/sql"
 	"github.com/yeboka/final-project/internal/app/store"
 
 	_ "github.com/lib/pq" // ...
 )
 
+// executor is the subset of *sql.DB and *sql.Tx used by the repositories.
+type executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 // Store ...
 type Store struct {
-	db                  *sql.DB
-	UserRepository      *UserRepository
-	CategoryRepository  *CategoryRepository
-	MenuItemRepository  *MenuItemRepository
-	OrderRepository     *OrderRepository
-	OrderItemRepository *OrderItemRepository
+	conn                         *sql.DB
+	db                           executor
+	UserRepository               *UserRepository
+	CategoryRepository           *CategoryRepository
+	MenuItemRepository           *MenuItemRepository
+	OrderRepository              *OrderRepository
+	OrderItemRepository          *OrderItemRepository
+	OrderStatusHistoryRepository *OrderStatusHistoryRepository
+	WalletRepository             *WalletRepository
+	PickupSlotRepository         *PickupSlotRepository
+	ModifierRepository           *ModifierRepository
+	ComboRepository              *ComboRepository
+	PromotionRepository          *PromotionRepository
+	LoyaltyRepository            *LoyaltyRepository
+	MenuScheduleRepository       *MenuScheduleRepository
+	ReportRepository             *ReportRepository
+	RefreshTokenRepository       *RefreshTokenRepository
+	SessionRepository            *SessionRepository
+	PasswordResetRepository      *PasswordResetRepository
+	EmailVerificationRepository  *EmailVerificationRepository
+	LoginThrottleRepository      *LoginThrottleRepository
+	AuditRepository              *AuditRepository
+	PaymentRefundRepository      *PaymentRefundRepository
+	PaymentEventRepository       *PaymentEventRepository
 }
 
 // New ...
 func New(db *sql.DB) *Store {
 	return &Store{
-		db: db,
+		conn: db,
+		db:   db,
+	}
+}
+
+// WithTx runs fn in a single database transaction. The store passed to fn
+// executes every query on that transaction; it is committed when fn returns
+// nil and rolled back otherwise. Nested calls reuse the outer transaction.
+func (s *Store) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	if s.conn == nil {
+		return fn(s)
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(&Store{db: tx}); err != nil {
+		return err
 	}
+
+	return tx.Commit()
 }
 
 // User ...
@@ -75,3 +123,186 @@ func (s *Store) OrderItem() store.OrderItemRepository {
 
 	return s.OrderItemRepository
 }
+
+func (s *Store) OrderStatusHistory() store.OrderStatusHistoryRepository {
+	if s.OrderStatusHistoryRepository != nil {
+		return s.OrderStatusHistoryRepository
+	}
+
+	s.OrderStatusHistoryRepository = &OrderStatusHistoryRepository{store: s}
+
+	return s.OrderStatusHistoryRepository
+}
+
+func (s *Store) Wallet() store.WalletRepository {
+	if s.WalletRepository != nil {
+		return s.WalletRepository
+	}
+
+	s.WalletRepository = &WalletRepository{store: s}
+
+	return s.WalletRepository
+}
+
+func (s *Store) PickupSlot() store.PickupSlotRepository {
+	if s.PickupSlotRepository != nil {
+		return s.PickupSlotRepository
+	}
+
+	s.PickupSlotRepository = &PickupSlotRepository{store: s}
+
+	return s.PickupSlotRepository
+}
+
+func (s *Store) Modifier() store.ModifierRepository {
+	if s.ModifierRepository != nil {
+		return s.ModifierRepository
+	}
+
+	s.ModifierRepository = &ModifierRepository{store: s}
+
+	return s.ModifierRepository
+}
+
+// Combo ...
+func (s *Store) Combo() store.ComboRepository {
+	if s.ComboRepository != nil {
+		return s.ComboRepository
+	}
+
+	s.ComboRepository = &ComboRepository{store: s}
+
+	return s.ComboRepository
+}
+
+// Promotion ...
+func (s *Store) Promotion() store.PromotionRepository {
+	if s.PromotionRepository != nil {
+		return s.PromotionRepository
+	}
+
+	s.PromotionRepository = &PromotionRepository{store: s}
+
+	return s.PromotionRepository
+}
+
+// Loyalty ...
+func (s *Store) Loyalty() store.LoyaltyRepository {
+	if s.LoyaltyRepository != nil {
+		return s.LoyaltyRepository
+	}
+
+	s.LoyaltyRepository = &LoyaltyRepository{store: s}
+
+	return s.LoyaltyRepository
+}
+
+// MenuSchedule ...
+func (s *Store) MenuSchedule() store.MenuScheduleRepository {
+	if s.MenuScheduleRepository != nil {
+		return s.MenuScheduleRepository
+	}
+
+	s.MenuScheduleRepository = &MenuScheduleRepository{store: s}
+
+	return s.MenuScheduleRepository
+}
+
+// Report ...
+func (s *Store) Report() store.ReportRepository {
+	if s.ReportRepository != nil {
+		return s.ReportRepository
+	}
+
+	s.ReportRepository = &ReportRepository{store: s}
+
+	return s.ReportRepository
+}
+
+// RefreshToken ...
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	if s.RefreshTokenRepository != nil {
+		return s.RefreshTokenRepository
+	}
+
+	s.RefreshTokenRepository = &RefreshTokenRepository{store: s}
+
+	return s.RefreshTokenRepository
+}
+
+// Session ...
+func (s *Store) Session() store.SessionRepository {
+	if s.SessionRepository != nil {
+		return s.SessionRepository
+	}
+
+	s.SessionRepository = &SessionRepository{store: s}
+
+	return s.SessionRepository
+}
+
+// PasswordReset ...
+func (s *Store) PasswordReset() store.PasswordResetRepository {
+	if s.PasswordResetRepository != nil {
+		return s.PasswordResetRepository
+	}
+
+	s.PasswordResetRepository = &PasswordResetRepository{store: s}
+
+	return s.PasswordResetRepository
+}
+
+// EmailVerification ...
+func (s *Store) EmailVerification() store.EmailVerificationRepository {
+	if s.EmailVerificationRepository != nil {
+		return s.EmailVerificationRepository
+	}
+
+	s.EmailVerificationRepository = &EmailVerificationRepository{store: s}
+
+	return s.EmailVerificationRepository
+}
+
+// LoginThrottle ...
+func (s *Store) LoginThrottle() store.LoginThrottleRepository {
+	if s.LoginThrottleRepository != nil {
+		return s.LoginThrottleRepository
+	}
+
+	s.LoginThrottleRepository = &LoginThrottleRepository{store: s}
+
+	return s.LoginThrottleRepository
+}
+
+// Audit ...
+func (s *Store) Audit() store.AuditRepository {
+	if s.AuditRepository != nil {
+		return s.AuditRepository
+	}
+
+	s.AuditRepository = &AuditRepository{store: s}
+
+	return s.AuditRepository
+}
+
+// PaymentRefund ...
+func (s *Store) PaymentRefund() store.PaymentRefundRepository {
+	if s.PaymentRefundRepository != nil {
+		return s.PaymentRefundRepository
+	}
+
+	s.PaymentRefundRepository = &PaymentRefundRepository{store: s}
+
+	return s.PaymentRefundRepository
+}
+
+// PaymentEvent ...
+func (s *Store) PaymentEvent() store.PaymentEventRepository {
+	if s.PaymentEventRepository != nil {
+		return s.PaymentEventRepository
+	}
+
+	s.PaymentEventRepository = &PaymentEventRepository{store: s}
+
+	return s.PaymentEventRepository
+}
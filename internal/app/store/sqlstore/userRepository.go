@@ -1,8 +1,9 @@
 package sqlstore
 
 import (
-	"errors"
+	"database/sql"
 	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
 )
 
 // UserRepository ...
@@ -21,11 +22,12 @@ func (r *UserRepository) Create(u *model.User) error {
 	}
 
 	return r.store.db.QueryRow(
-		"INSERT INTO users (email, encrypted_password, username, role) VALUES ($1, $2, $3, $4) RETURNING id",
+		"INSERT INTO users (email, encrypted_password, username, role, email_verified) VALUES ($1, $2, $3, $4, $5) RETURNING id",
 		u.Email,
 		u.EncryptedPassword,
 		u.Username,
 		u.Role,
+		u.EmailVerified,
 	).Scan(&u.ID)
 }
 
@@ -34,14 +36,20 @@ func (r *UserRepository) Find(id int) (*model.User, error) {
 	u := &model.User{}
 
 	if err := r.store.db.QueryRow(
-		"SELECT id, username, email, role FROM users WHERE id = $1",
+		"SELECT id, username, email, role, email_verified, encrypted_password FROM users WHERE id = $1",
 		id,
 	).Scan(
 		&u.ID,
 		&u.Username,
 		&u.Email,
 		&u.Role,
+		&u.EmailVerified,
+		&u.EncryptedPassword,
 	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
 		return nil, err
 	}
 
@@ -53,13 +61,20 @@ func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
 	u := &model.User{}
 
 	if err := r.store.db.QueryRow(
-		"SELECT id, email, encrypted_password FROM users WHERE email = $1",
+		"SELECT id, username, email, role, email_verified, encrypted_password FROM users WHERE email = $1",
 		email,
 	).Scan(
 		&u.ID,
+		&u.Username,
 		&u.Email,
+		&u.Role,
+		&u.EmailVerified,
 		&u.EncryptedPassword,
 	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, store.ErrRecordNotFound
+		}
+
 		return nil, err
 	}
 
@@ -72,11 +87,12 @@ func (r *UserRepository) Update(user *model.User) error {
 		return err
 	}
 
-	_, err := r.store.db.Exec(
-		"UPDATE users SET username = $1, email = $2 WHERE id = $3",
+	// A new address has to be verified again.
+	err := r.store.db.QueryRow(
+		"UPDATE users SET username = $1, email = $2, email_verified = email_verified AND email = $2 WHERE id = $3 RETURNING email_verified",
 		user.Username, user.Email, user.ID,
-	)
-	if err != nil {
+	).Scan(&user.EmailVerified)
+	if err != nil && err != sql.ErrNoRows {
 		return err
 	}
 	return nil
@@ -84,8 +100,8 @@ func (r *UserRepository) Update(user *model.User) error {
 
 // UpdateRole ...
 func (r *UserRepository) UpdateRole(userID int, newRole string) error {
-	if newRole != "user" && newRole != "admin" {
-		return errors.New("invalid role")
+	if newRole != model.RoleUser && newRole != model.RoleStaff && newRole != model.RoleAdmin {
+		return store.ErrInvalidRole
 	}
 
 	_, err := r.store.db.Exec(
@@ -98,6 +114,42 @@ func (r *UserRepository) UpdateRole(userID int, newRole string) error {
 	return nil
 }
 
+// UpdatePassword sets the password of the user to user.Password.
+func (r *UserRepository) UpdatePassword(user *model.User) error {
+	if err := user.ValidatePassword(); err != nil {
+		return err
+	}
+
+	if err := user.BeforeCreate(); err != nil {
+		return err
+	}
+
+	_, err := r.store.db.Exec(
+		"UPDATE users SET encrypted_password = $1 WHERE id = $2",
+		user.EncryptedPassword, user.ID,
+	)
+	return err
+}
+
+// SetEmailVerified ...
+func (r *UserRepository) SetEmailVerified(id int) error {
+	res, err := r.store.db.Exec("UPDATE users SET email_verified = true WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return store.ErrRecordNotFound
+	}
+
+	return nil
+}
+
 // Delete ...
 func (r *UserRepository) Delete(id int) error {
 	_, err := r.store.db.Exec("DELETE FROM users WHERE id = $1", id)
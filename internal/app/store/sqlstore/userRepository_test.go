@@ -0,0 +1,24 @@
+package sqlstore_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store/sqlstore"
+	"testing"
+)
+
+func TestUserRepository_FindByEmail(t *testing.T) {
+	db := testDB(t, "users")
+	st := sqlstore.New(db)
+
+	u := &model.User{Email: "staff@example.org", Username: "staff", Password: "password", Role: model.RoleStaff}
+	require.NoError(t, st.User().Create(u))
+
+	found, err := st.User().FindByEmail(u.Email)
+	require.NoError(t, err)
+	assert.Equal(t, u.ID, found.ID)
+	assert.Equal(t, "staff", found.Username)
+	assert.Equal(t, model.RoleStaff, found.Role)
+	assert.True(t, found.ComparePassword("password"))
+}
@@ -0,0 +1,105 @@
+package sqlstore
+
+import (
+	"database/sql"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+)
+
+// WalletRepository ...
+type WalletRepository struct {
+	store *Store
+}
+
+// Create ...
+func (r *WalletRepository) Create(e *model.WalletEntry) error {
+	return r.store.db.QueryRow(
+		"INSERT INTO wallet_entries (user_id, kind, amount, order_id, created_by, note, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+		e.UserId,
+		e.Kind,
+		e.Amount,
+		e.OrderId,
+		e.CreatedBy,
+		e.Note,
+		e.CreatedAt,
+	).Scan(&e.ID)
+}
+
+// Balance ...
+func (r *WalletRepository) Balance(userId int) (int, error) {
+	var balance int
+
+	if err := r.store.db.QueryRow(
+		"SELECT COALESCE(SUM(amount), 0) FROM wallet_entries WHERE user_id = $1",
+		userId,
+	).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// BalanceForUpdate locks the user row until the surrounding transaction ends
+// and returns the balance, so that concurrent debits cannot overdraw it.
+func (r *WalletRepository) BalanceForUpdate(userId int) (int, error) {
+	var id int
+
+	if err := r.store.db.QueryRow("SELECT id FROM users WHERE id = $1 FOR UPDATE", userId).Scan(&id); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, store.ErrRecordNotFound
+		}
+
+		return 0, err
+	}
+
+	return r.Balance(userId)
+}
+
+// OrderBalance returns the sum of the entries tied to an order; it is
+// negative while the order is charged.
+func (r *WalletRepository) OrderBalance(orderId int) (int, error) {
+	var balance int
+
+	if err := r.store.db.QueryRow(
+		"SELECT COALESCE(SUM(amount), 0) FROM wallet_entries WHERE order_id = $1",
+		orderId,
+	).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+// GetEntries returns the user's ledger, newest first.
+func (r *WalletRepository) GetEntries(userId int) ([]*model.WalletEntry, error) {
+	rows, err := r.store.db.Query(
+		"SELECT id, user_id, kind, amount, order_id, created_by, note, created_at FROM wallet_entries WHERE user_id = $1 ORDER BY created_at DESC, id DESC",
+		userId,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*model.WalletEntry
+	for rows.Next() {
+		e := &model.WalletEntry{}
+		var orderId sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.UserId, &e.Kind, &e.Amount, &orderId, &e.CreatedBy, &e.Note, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		if orderId.Valid {
+			id := int(orderId.Int64)
+			e.OrderId = &id
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
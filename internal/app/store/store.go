@@ -1,10 +1,31 @@
 package store
 
+import "context"
+
 // Store ...
 type Store interface {
+	WithTx(ctx context.Context, fn func(Store) error) error
+
 	User() UserRepository
 	Order() OrderRepository
 	Category() CategoryRepository
 	MenuItem() MenuItemRepository
 	OrderItem() OrderItemRepository
+	OrderStatusHistory() OrderStatusHistoryRepository
+	Wallet() WalletRepository
+	PickupSlot() PickupSlotRepository
+	Modifier() ModifierRepository
+	Combo() ComboRepository
+	Promotion() PromotionRepository
+	Loyalty() LoyaltyRepository
+	MenuSchedule() MenuScheduleRepository
+	Report() ReportRepository
+	RefreshToken() RefreshTokenRepository
+	Session() SessionRepository
+	PasswordReset() PasswordResetRepository
+	EmailVerification() EmailVerificationRepository
+	LoginThrottle() LoginThrottleRepository
+	Audit() AuditRepository
+	PaymentRefund() PaymentRefundRepository
+	PaymentEvent() PaymentEventRepository
 }
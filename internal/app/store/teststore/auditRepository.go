@@ -0,0 +1,69 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"sort"
+)
+
+// AuditRepository ...
+type AuditRepository struct {
+	store   *Store
+	entries map[int]*model.AuditEntry
+	nextID  int
+}
+
+// Create ...
+func (r *AuditRepository) Create(e *model.AuditEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.nextID++
+	e.ID = r.nextID
+
+	stored := *e
+	r.entries[e.ID] = &stored
+
+	return nil
+}
+
+// List ...
+func (r *AuditRepository) List(action string, limit int) ([]*model.AuditEntry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	entries := []*model.AuditEntry{}
+	for _, e := range r.entries {
+		if action == "" || e.Action == action {
+			found := *e
+			entries = append(entries, &found)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CreatedAt.Equal(entries[j].CreatedAt) {
+			return entries[i].CreatedAt.After(entries[j].CreatedAt)
+		}
+
+		return entries[i].ID > entries[j].ID
+	})
+
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+
+	return entries, nil
+}
+
+// detachUser clears the references of a deleted user, as the foreign keys
+// do. The caller holds the store mutex.
+func (r *AuditRepository) detachUser(userId int) {
+	for _, e := range r.entries {
+		if e.UserId != nil && *e.UserId == userId {
+			e.UserId = nil
+		}
+
+		if e.ActorId != nil && *e.ActorId == userId {
+			e.ActorId = nil
+		}
+	}
+}
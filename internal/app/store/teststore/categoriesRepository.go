@@ -0,0 +1,81 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+)
+
+// CategoryRepository ...
+type CategoryRepository struct {
+	store      *Store
+	categories map[int]*model.Category
+	nextID     int
+}
+
+// Create ...
+func (r *CategoryRepository) Create(c *model.Category) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.categories {
+		if existing.Name == c.Name {
+			return errCategoryNameTaken
+		}
+	}
+
+	parentID := -1
+	if c.ParentID > 0 {
+		if _, ok := r.categories[c.ParentID]; !ok {
+			return errForeignKeyViolation
+		}
+		parentID = c.ParentID
+	}
+
+	r.nextID++
+	c.ID = r.nextID
+
+	r.categories[c.ID] = &model.Category{
+		ID:       c.ID,
+		ParentID: parentID,
+		Name:     c.Name,
+	}
+
+	return nil
+}
+
+// Find ...
+func (r *CategoryRepository) Find(id int) (*model.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	c, ok := r.categories[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	found := *c
+	return &found, nil
+}
+
+// GetAllCategories ...
+func (r *CategoryRepository) GetAllCategories() ([]*model.Category, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var categories []*model.Category
+	for _, c := range r.categories {
+		found := *c
+		categories = append(categories, &found)
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].ID < categories[j].ID
+	})
+
+	return categories, nil
+}
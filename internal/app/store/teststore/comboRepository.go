@@ -0,0 +1,163 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+)
+
+// ComboRepository ...
+type ComboRepository struct {
+	store      *Store
+	combos     map[int]*model.Combo
+	nextID     int
+	nextSlotID int
+}
+
+// Create ...
+func (r *ComboRepository) Create(c *model.Combo) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	for _, slot := range c.Slots {
+		if err := slot.Validate(); err != nil {
+			return err
+		}
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.combos {
+		if existing.Name == c.Name {
+			return errComboNameTaken
+		}
+	}
+
+	categories := r.store.CategoryRepository.categories
+	if _, ok := categories[c.CategoryID]; c.CategoryID != 0 && !ok {
+		return errForeignKeyViolation
+	}
+
+	for _, slot := range c.Slots {
+		if slot.CategoryID != nil {
+			if _, ok := categories[*slot.CategoryID]; !ok {
+				return errForeignKeyViolation
+			}
+		}
+
+		for _, id := range slot.MenuItemIds {
+			if _, ok := r.store.MenuItemRepository.menuItems[id]; !ok {
+				return errForeignKeyViolation
+			}
+		}
+	}
+
+	r.nextID++
+	c.ID = r.nextID
+
+	for _, slot := range c.Slots {
+		r.nextSlotID++
+		slot.ID = r.nextSlotID
+		slot.ComboId = c.ID
+	}
+
+	r.combos[c.ID] = copyCombo(c)
+
+	return nil
+}
+
+// Find ...
+func (r *ComboRepository) Find(id int) (*model.Combo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	c, ok := r.combos[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return copyCombo(c), nil
+}
+
+// Delete ...
+func (r *ComboRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.combos, id)
+
+	// Order lines keep their snapshot and lose the reference.
+	orderItems := r.store.OrderItemRepository.orderItems
+	for itemID, item := range orderItems {
+		if item.ComboId == id {
+			detached := copyOrderItem(item)
+			detached.ComboId = 0
+			for _, c := range detached.Components {
+				c.ComboSlotId = 0
+			}
+			orderItems[itemID] = detached
+		}
+	}
+
+	return nil
+}
+
+// GetAll ...
+func (r *ComboRepository) GetAll() ([]*model.Combo, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var combos []*model.Combo
+	for _, c := range r.combos {
+		combos = append(combos, copyCombo(c))
+	}
+
+	sort.Slice(combos, func(i, j int) bool {
+		return combos[i].ID < combos[j].ID
+	})
+
+	return combos, nil
+}
+
+// removeMenuItem drops a deleted menu item from every slot item list;
+// callers hold the lock.
+func (r *ComboRepository) removeMenuItem(menuItemId int) {
+	for id, c := range r.combos {
+		updated := copyCombo(c)
+		changed := false
+
+		for _, slot := range updated.Slots {
+			var kept []int
+			for _, itemID := range slot.MenuItemIds {
+				if itemID == menuItemId {
+					changed = true
+					continue
+				}
+				kept = append(kept, itemID)
+			}
+			slot.MenuItemIds = kept
+		}
+
+		if changed {
+			r.combos[id] = updated
+		}
+	}
+}
+
+func copyCombo(c *model.Combo) *model.Combo {
+	found := *c
+	found.Slots = make([]*model.ComboSlot, 0, len(c.Slots))
+	for _, slot := range c.Slots {
+		s := *slot
+		s.MenuItemIds = append([]int(nil), slot.MenuItemIds...)
+		if slot.CategoryID != nil {
+			categoryID := *slot.CategoryID
+			s.CategoryID = &categoryID
+		}
+		found.Slots = append(found.Slots, &s)
+	}
+
+	return &found
+}
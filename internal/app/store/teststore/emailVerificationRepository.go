@@ -0,0 +1,87 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// EmailVerificationRepository ...
+type EmailVerificationRepository struct {
+	store         *Store
+	verifications map[int]*model.EmailVerification
+	nextID        int
+}
+
+// Create ...
+func (r *EmailVerificationRepository) Create(v *model.EmailVerification) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.UserRepository.users[v.UserId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	r.nextID++
+	v.ID = r.nextID
+
+	stored := *v
+	r.verifications[v.ID] = &stored
+
+	return nil
+}
+
+// FindByHash ...
+func (r *EmailVerificationRepository) FindByHash(hash string) (*model.EmailVerification, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.verifications {
+		if v.TokenHash == hash {
+			found := *v
+			return &found, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// Use ...
+func (r *EmailVerificationRepository) Use(id int, now time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	v, ok := r.verifications[id]
+	if !ok || v.UsedAt != nil {
+		return false, nil
+	}
+
+	v.UsedAt = &now
+
+	return true, nil
+}
+
+// UseUser ...
+func (r *EmailVerificationRepository) UseUser(userId int, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, v := range r.verifications {
+		if v.UserId == userId && v.UsedAt == nil {
+			usedAt := now
+			v.UsedAt = &usedAt
+		}
+	}
+
+	return nil
+}
+
+// removeUser deletes the tokens of a deleted user. The caller holds the
+// store mutex.
+func (r *EmailVerificationRepository) removeUser(userId int) {
+	for id, v := range r.verifications {
+		if v.UserId == userId {
+			delete(r.verifications, id)
+		}
+	}
+}
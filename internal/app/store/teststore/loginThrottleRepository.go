@@ -0,0 +1,119 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// LoginThrottleRepository ...
+type LoginThrottleRepository struct {
+	store     *Store
+	throttles map[int]*model.LoginThrottle
+	nextID    int
+}
+
+// find returns the stored throttle of kind and key. The caller holds the
+// store mutex.
+func (r *LoginThrottleRepository) find(kind string, key string) *model.LoginThrottle {
+	for _, t := range r.throttles {
+		if t.Kind == kind && t.Key == key {
+			return t
+		}
+	}
+
+	return nil
+}
+
+// Find ...
+func (r *LoginThrottleRepository) Find(kind string, key string) (*model.LoginThrottle, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.find(kind, key)
+	if t == nil {
+		return nil, store.ErrRecordNotFound
+	}
+
+	found := *t
+	return &found, nil
+}
+
+// Attempt ...
+func (r *LoginThrottleRepository) Attempt(kind string, key string, now time.Time, next time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.find(kind, key)
+	if t == nil || t.IsLockedAt(now) || (t.NextAttemptAt != nil && now.Before(*t.NextAttemptAt)) {
+		return false, nil
+	}
+
+	t.NextAttemptAt = &next
+
+	return true, nil
+}
+
+// RecordFailure ...
+func (r *LoginThrottleRepository) RecordFailure(kind string, key string, now time.Time, since time.Time) (*model.LoginThrottle, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t := r.find(kind, key)
+	switch {
+	case t == nil:
+		r.nextID++
+		t = &model.LoginThrottle{ID: r.nextID, Kind: kind, Key: key, Failures: 1}
+		r.throttles[t.ID] = t
+	case t.LastFailureAt.Before(since):
+		t.Failures = 1
+	default:
+		t.Failures++
+	}
+	t.LastFailureAt = now
+
+	found := *t
+	return &found, nil
+}
+
+// Lock ...
+func (r *LoginThrottleRepository) Lock(id int, until time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if t, ok := r.throttles[id]; ok {
+		t.LockedUntil = &until
+	}
+
+	return nil
+}
+
+// Reset ...
+func (r *LoginThrottleRepository) Reset(kind string, key string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if t := r.find(kind, key); t != nil {
+		delete(r.throttles, t.ID)
+	}
+
+	return nil
+}
+
+// DeleteStale ...
+func (r *LoginThrottleRepository) DeleteStale(since time.Time, now time.Time) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	n := 0
+	for id, t := range r.throttles {
+		if !t.LastFailureAt.Before(since) || t.IsLockedAt(now) || (t.NextAttemptAt != nil && now.Before(*t.NextAttemptAt)) {
+			continue
+		}
+
+		delete(r.throttles, id)
+		n++
+	}
+
+	return n, nil
+}
@@ -0,0 +1,149 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+	"time"
+)
+
+// LoyaltyRepository ...
+type LoyaltyRepository struct {
+	store   *Store
+	entries map[int]*model.LoyaltyEntry
+	nextID  int
+}
+
+// Create ...
+func (r *LoyaltyRepository) Create(e *model.LoyaltyEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.UserRepository.users[e.UserId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	if e.OrderId != nil {
+		if _, ok := r.store.OrderRepository.orders[*e.OrderId]; !ok {
+			return errForeignKeyViolation
+		}
+	}
+
+	r.nextID++
+	e.ID = r.nextID
+
+	stored := *e
+	r.entries[e.ID] = &stored
+
+	return nil
+}
+
+// Balance ...
+func (r *LoyaltyRepository) Balance(userId int) (int, error) {
+	return r.sum(func(e *model.LoyaltyEntry) bool {
+		return e.UserId == userId
+	})
+}
+
+// BalanceForUpdate ...
+func (r *LoyaltyRepository) BalanceForUpdate(userId int) (int, error) {
+	r.store.mu.Lock()
+	_, ok := r.store.UserRepository.users[userId]
+	r.store.mu.Unlock()
+
+	if !ok {
+		return 0, store.ErrRecordNotFound
+	}
+
+	return r.Balance(userId)
+}
+
+// SumByKind ...
+func (r *LoyaltyRepository) SumByKind(userId int, kind string) (int, error) {
+	return r.sum(func(e *model.LoyaltyEntry) bool {
+		return e.UserId == userId && e.Kind == kind
+	})
+}
+
+// ExpiredPoints ...
+func (r *LoyaltyRepository) ExpiredPoints(userId int, now time.Time) (int, error) {
+	return r.sum(func(e *model.LoyaltyEntry) bool {
+		return e.UserId == userId && e.Kind == model.LoyaltyEntryEarn && e.ExpiresAt != nil && !e.ExpiresAt.After(now)
+	})
+}
+
+// OrderPoints ...
+func (r *LoyaltyRepository) OrderPoints(orderId int, kinds ...string) (int, error) {
+	return r.sum(func(e *model.LoyaltyEntry) bool {
+		if e.OrderId == nil || *e.OrderId != orderId {
+			return false
+		}
+
+		for _, kind := range kinds {
+			if e.Kind == kind {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+func (r *LoyaltyRepository) sum(match func(*model.LoyaltyEntry) bool) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var points int
+	for _, e := range r.entries {
+		if match(e) {
+			points += e.Points
+		}
+	}
+
+	return points, nil
+}
+
+// GetEntries ...
+func (r *LoyaltyRepository) GetEntries(userId int) ([]*model.LoyaltyEntry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var entries []*model.LoyaltyEntry
+	for _, e := range r.entries {
+		if e.UserId == userId {
+			found := *e
+			entries = append(entries, &found)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+
+	return entries, nil
+}
+
+// GetBalances ...
+func (r *LoyaltyRepository) GetBalances() ([]*model.LoyaltyBalance, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	byUser := make(map[int]*model.LoyaltyBalance)
+	var balances []*model.LoyaltyBalance
+	for _, e := range r.entries {
+		b, ok := byUser[e.UserId]
+		if !ok {
+			b = &model.LoyaltyBalance{UserId: e.UserId}
+			byUser[e.UserId] = b
+			balances = append(balances, b)
+		}
+
+		b.Points += e.Points
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].UserId < balances[j].UserId
+	})
+
+	return balances, nil
+}
@@ -0,0 +1,304 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+)
+
+// MenuItemRepository ...
+type MenuItemRepository struct {
+	store     *Store
+	menuItems map[int]*model.MenuItem
+	nextID    int
+}
+
+// Create ...
+func (r *MenuItemRepository) Create(m *model.MenuItem) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.menuItems {
+		if existing.Name == m.Name {
+			return errMenuItemNameTaken
+		}
+	}
+
+	if m.CategoryID != 0 {
+		if _, ok := r.store.CategoryRepository.categories[m.CategoryID]; !ok {
+			return errForeignKeyViolation
+		}
+	}
+
+	r.nextID++
+	m.ID = r.nextID
+
+	m.Available = m.IsAvailable()
+	m.Allergens = tags(m.Allergens)
+	m.Diets = tags(m.Diets)
+	stored := *m
+	stored.Allergens = tags(append([]string(nil), m.Allergens...))
+	stored.Diets = tags(append([]string(nil), m.Diets...))
+	stored.Nutrition = copyNutrition(m.Nutrition)
+	if m.Stock != nil {
+		stock := *m.Stock
+		stored.Stock = &stock
+	}
+	r.menuItems[m.ID] = &stored
+
+	return nil
+}
+
+// Find ...
+func (r *MenuItemRepository) Find(id int) (*model.MenuItem, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	m, ok := r.menuItems[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	found := *m
+	return &found, nil
+}
+
+// FindByCategoryId ...
+func (r *MenuItemRepository) FindByCategoryId(categoryId int) ([]*model.MenuItem, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var menuItems []*model.MenuItem
+	for _, m := range r.menuItems {
+		if m.CategoryID == categoryId {
+			found := *m
+			menuItems = append(menuItems, &found)
+		}
+	}
+
+	sort.Slice(menuItems, func(i, j int) bool {
+		return menuItems[i].ID < menuItems[j].ID
+	})
+
+	return menuItems, nil
+}
+
+// Update ...
+func (r *MenuItemRepository) Update(mi *model.MenuItem) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	m, ok := r.menuItems[mi.ID]
+	if !ok {
+		return nil
+	}
+
+	for _, existing := range r.menuItems {
+		if existing.ID != mi.ID && existing.Name == mi.Name {
+			return errMenuItemNameTaken
+		}
+	}
+
+	m.Name = mi.Name
+	m.Price = mi.Price
+	m.Description = mi.Description
+
+	return nil
+}
+
+// Delete ...
+func (r *MenuItemRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.menuItems, id)
+
+	// Order lines keep their snapshot and lose the reference.
+	orderItems := r.store.OrderItemRepository.orderItems
+	for itemID, item := range orderItems {
+		detached := copyOrderItem(item)
+		changed := false
+
+		if detached.MenuItemId == id {
+			detached.MenuItemId = 0
+			changed = true
+		}
+
+		for _, c := range detached.Components {
+			if c.MenuItemId == id {
+				c.MenuItemId = 0
+				changed = true
+			}
+		}
+
+		if changed {
+			orderItems[itemID] = detached
+		}
+	}
+
+	r.store.ComboRepository.removeMenuItem(id)
+	r.store.MenuScheduleRepository.removeMenuItem(id)
+
+	promotions := r.store.PromotionRepository.promotions
+	for promotionID, p := range promotions {
+		if p.MenuItemId == id {
+			delete(promotions, promotionID)
+		}
+	}
+
+	modifiers := r.store.ModifierRepository
+	for groupID, g := range modifiers.groups {
+		if g.MenuItemId == id {
+			modifiers.deleteGroup(groupID)
+		}
+	}
+
+	return nil
+}
+
+// GetPrice ...
+func (r *MenuItemRepository) GetPrice(id int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	m, ok := r.menuItems[id]
+	if !ok {
+		return 0, store.ErrRecordNotFound
+	}
+
+	return m.Price, nil
+}
+
+// DecrementStock ...
+func (r *MenuItemRepository) DecrementStock(id int, quantity int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	m, ok := r.menuItems[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if m.Stock == nil {
+		return nil
+	}
+
+	if *m.Stock < quantity {
+		return store.ErrOutOfStock
+	}
+
+	// Replace rather than mutate the pointer: snapshots share it.
+	r.setStock(m, *m.Stock-quantity)
+
+	return nil
+}
+
+// IncrementStock ...
+func (r *MenuItemRepository) IncrementStock(id int, quantity int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if m, ok := r.menuItems[id]; ok && m.Stock != nil {
+		r.setStock(m, *m.Stock+quantity)
+	}
+
+	return nil
+}
+
+// SetStock ...
+func (r *MenuItemRepository) SetStock(id int, stock *int, lowStockThreshold int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	m, ok := r.menuItems[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	m.Stock = nil
+	if stock != nil {
+		r.setStock(m, *stock)
+	}
+	m.LowStockThreshold = lowStockThreshold
+	m.Available = m.IsAvailable()
+
+	return nil
+}
+
+// FindLowStock ...
+func (r *MenuItemRepository) FindLowStock() ([]*model.MenuItem, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var menuItems []*model.MenuItem
+	for _, m := range r.menuItems {
+		if m.IsLowStock() {
+			found := *m
+			menuItems = append(menuItems, &found)
+		}
+	}
+
+	sort.Slice(menuItems, func(i, j int) bool {
+		return *menuItems[i].Stock < *menuItems[j].Stock
+	})
+
+	return menuItems, nil
+}
+
+func (r *MenuItemRepository) setStock(m *model.MenuItem, stock int) {
+	m.Stock = &stock
+	m.Available = m.IsAvailable()
+}
+
+// SetTags ...
+func (r *MenuItemRepository) SetTags(id int, allergens []string, diets []string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	m, ok := r.menuItems[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	// Replace rather than mutate the slices: snapshots share them.
+	m.Allergens = tags(append([]string(nil), allergens...))
+	m.Diets = tags(append([]string(nil), diets...))
+
+	return nil
+}
+
+// SetNutrition ...
+func (r *MenuItemRepository) SetNutrition(id int, nutrition *model.Nutrition) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	m, ok := r.menuItems[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	m.Nutrition = copyNutrition(nutrition)
+
+	return nil
+}
+
+func copyNutrition(n *model.Nutrition) *model.Nutrition {
+	if n == nil {
+		return nil
+	}
+
+	c := *n
+	return &c
+}
+
+func tags(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
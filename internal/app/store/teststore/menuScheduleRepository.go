@@ -0,0 +1,79 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"sort"
+)
+
+// MenuScheduleRepository ...
+type MenuScheduleRepository struct {
+	store     *Store
+	schedules map[int]*model.MenuSchedule
+	nextID    int
+}
+
+// Create ...
+func (r *MenuScheduleRepository) Create(s *model.MenuSchedule) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.MenuItemRepository.menuItems[s.MenuItemId]; s.MenuItemId != 0 && !ok {
+		return errForeignKeyViolation
+	}
+
+	if _, ok := r.store.CategoryRepository.categories[s.CategoryID]; s.CategoryID != 0 && !ok {
+		return errForeignKeyViolation
+	}
+
+	r.nextID++
+	s.ID = r.nextID
+
+	stored := *s
+	stored.Weekdays = append([]int(nil), s.Weekdays...)
+	r.schedules[s.ID] = &stored
+
+	return nil
+}
+
+// Delete ...
+func (r *MenuScheduleRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.schedules, id)
+
+	return nil
+}
+
+// GetAll ...
+func (r *MenuScheduleRepository) GetAll() ([]*model.MenuSchedule, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	schedules := make([]*model.MenuSchedule, 0, len(r.schedules))
+	for _, s := range r.schedules {
+		found := *s
+		found.Weekdays = append([]int(nil), s.Weekdays...)
+		schedules = append(schedules, &found)
+	}
+
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].ID < schedules[j].ID
+	})
+
+	return schedules, nil
+}
+
+// removeMenuItem deletes the schedules of a deleted menu item. The caller
+// holds the store mutex.
+func (r *MenuScheduleRepository) removeMenuItem(menuItemId int) {
+	for id, s := range r.schedules {
+		if s.MenuItemId == menuItemId {
+			delete(r.schedules, id)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+)
+
+// ModifierRepository ...
+type ModifierRepository struct {
+	store          *Store
+	groups         map[int]*model.ModifierGroup
+	modifiers      map[int]*model.Modifier
+	nextGroupID    int
+	nextModifierID int
+}
+
+// CreateGroup ...
+func (r *ModifierRepository) CreateGroup(g *model.ModifierGroup) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+
+	for _, m := range g.Modifiers {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.MenuItemRepository.menuItems[g.MenuItemId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	r.nextGroupID++
+	g.ID = r.nextGroupID
+
+	stored := *g
+	stored.Modifiers = nil
+	r.groups[g.ID] = &stored
+
+	for _, m := range g.Modifiers {
+		m.GroupId = g.ID
+		r.createModifier(m)
+	}
+
+	return nil
+}
+
+// FindGroup ...
+func (r *ModifierRepository) FindGroup(id int) (*model.ModifierGroup, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	g, ok := r.groups[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	return r.withModifiers(g), nil
+}
+
+// DeleteGroup ...
+func (r *ModifierRepository) DeleteGroup(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	r.deleteGroup(id)
+
+	return nil
+}
+
+// CreateModifier ...
+func (r *ModifierRepository) CreateModifier(m *model.Modifier) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.groups[m.GroupId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	r.createModifier(m)
+
+	return nil
+}
+
+// DeleteModifier ...
+func (r *ModifierRepository) DeleteModifier(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.modifiers, id)
+
+	return nil
+}
+
+// FindGroupsByMenuItem ...
+func (r *ModifierRepository) FindGroupsByMenuItem(menuItemId int) ([]*model.ModifierGroup, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var groups []*model.ModifierGroup
+	for _, g := range r.groups {
+		if g.MenuItemId == menuItemId {
+			groups = append(groups, r.withModifiers(g))
+		}
+	}
+
+	sortGroups(groups)
+
+	return groups, nil
+}
+
+// GetAllGroups ...
+func (r *ModifierRepository) GetAllGroups() ([]*model.ModifierGroup, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var groups []*model.ModifierGroup
+	for _, g := range r.groups {
+		groups = append(groups, r.withModifiers(g))
+	}
+
+	sortGroups(groups)
+
+	return groups, nil
+}
+
+func (r *ModifierRepository) createModifier(m *model.Modifier) {
+	r.nextModifierID++
+	m.ID = r.nextModifierID
+
+	stored := *m
+	r.modifiers[m.ID] = &stored
+}
+
+// deleteGroup removes a group and its modifiers; callers hold the lock.
+func (r *ModifierRepository) deleteGroup(id int) {
+	delete(r.groups, id)
+
+	for modifierID, m := range r.modifiers {
+		if m.GroupId == id {
+			delete(r.modifiers, modifierID)
+		}
+	}
+}
+
+func (r *ModifierRepository) withModifiers(g *model.ModifierGroup) *model.ModifierGroup {
+	found := *g
+	found.Modifiers = []*model.Modifier{}
+
+	for _, m := range r.modifiers {
+		if m.GroupId == g.ID {
+			modifier := *m
+			found.Modifiers = append(found.Modifiers, &modifier)
+		}
+	}
+
+	sort.Slice(found.Modifiers, func(i, j int) bool {
+		return found.Modifiers[i].ID < found.Modifiers[j].ID
+	})
+
+	return &found
+}
+
+func sortGroups(groups []*model.ModifierGroup) {
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].ID < groups[j].ID
+	})
+}
@@ -0,0 +1,141 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"sort"
+)
+
+// OrderItemRepository ...
+type OrderItemRepository struct {
+	store      *Store
+	orderItems map[int]*model.OrderItem
+	nextID     int
+
+	nextModifierID  int
+	nextComponentID int
+}
+
+// Create ...
+func (i *OrderItemRepository) Create(item *model.OrderItem) error {
+	i.store.mu.Lock()
+	defer i.store.mu.Unlock()
+
+	if _, ok := i.store.OrderRepository.orders[item.OrderId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	if _, ok := i.store.MenuItemRepository.menuItems[item.MenuItemId]; item.MenuItemId != 0 && !ok {
+		return errForeignKeyViolation
+	}
+
+	if _, ok := i.store.ComboRepository.combos[item.ComboId]; item.ComboId != 0 && !ok {
+		return errForeignKeyViolation
+	}
+
+	i.nextID++
+	item.ID = i.nextID
+
+	stored := *item
+	stored.Modifiers = nil
+	for _, m := range item.Modifiers {
+		i.nextModifierID++
+		m.ID = i.nextModifierID
+		m.OrderItemId = item.ID
+
+		modifier := *m
+		stored.Modifiers = append(stored.Modifiers, &modifier)
+	}
+
+	stored.Components = nil
+	for _, c := range item.Components {
+		i.nextComponentID++
+		c.ID = i.nextComponentID
+		c.OrderItemId = item.ID
+
+		component := *c
+		stored.Components = append(stored.Components, &component)
+	}
+	stored.Nutrition = copyNutrition(item.Nutrition)
+	i.orderItems[item.ID] = &stored
+
+	return nil
+}
+
+// Delete ...
+func (i *OrderItemRepository) Delete(id int) error {
+	i.store.mu.Lock()
+	defer i.store.mu.Unlock()
+
+	delete(i.orderItems, id)
+
+	return nil
+}
+
+// Update ...
+func (i *OrderItemRepository) Update(menuItemId int, quantity int) error {
+	i.store.mu.Lock()
+	defer i.store.mu.Unlock()
+
+	for id, item := range i.orderItems {
+		if item.MenuItemId == menuItemId {
+			updated := *item
+			updated.Quantity = quantity
+			updated.LineTotal = updated.UnitPrice * quantity
+			i.orderItems[id] = &updated
+		}
+	}
+
+	return nil
+}
+
+// DeleteAllOrder ...
+func (i *OrderItemRepository) DeleteAllOrder(orderId int) error {
+	i.store.mu.Lock()
+	defer i.store.mu.Unlock()
+
+	for id, item := range i.orderItems {
+		if item.OrderId == orderId {
+			delete(i.orderItems, id)
+		}
+	}
+
+	return nil
+}
+
+// GetOrderItems ...
+func (i *OrderItemRepository) GetOrderItems(orderId int) ([]*model.OrderItem, error) {
+	i.store.mu.Lock()
+	defer i.store.mu.Unlock()
+
+	var orderItems []*model.OrderItem
+	for _, item := range i.orderItems {
+		if item.OrderId == orderId {
+			orderItems = append(orderItems, copyOrderItem(item))
+		}
+	}
+
+	sort.Slice(orderItems, func(a, b int) bool {
+		return orderItems[a].ID < orderItems[b].ID
+	})
+
+	return orderItems, nil
+}
+
+func copyOrderItem(item *model.OrderItem) *model.OrderItem {
+	found := *item
+	found.Modifiers = nil
+	for _, m := range item.Modifiers {
+		modifier := *m
+		found.Modifiers = append(found.Modifiers, &modifier)
+	}
+
+	found.Components = nil
+	for _, c := range item.Components {
+		component := *c
+		found.Components = append(found.Components, &component)
+	}
+
+	found.Nutrition = copyNutrition(item.Nutrition)
+
+	return &found
+}
@@ -0,0 +1,286 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+	"time"
+)
+
+// OrderRepository ...
+type OrderRepository struct {
+	store  *Store
+	orders map[int]*model.Order
+	nextID int
+}
+
+// Create ...
+func (o *OrderRepository) Create(order *model.Order) error {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	if _, ok := o.store.UserRepository.users[order.UserId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	if order.PickupSlotId != nil {
+		if _, ok := o.store.PickupSlotRepository.slots[*order.PickupSlotId]; !ok {
+			return errForeignKeyViolation
+		}
+	}
+
+	order.CreatedAt = time.Now()
+	if order.Status == "" {
+		order.Status = model.OrderStatusPlaced
+	}
+
+	o.nextID++
+	order.ID = o.nextID
+
+	stored := *order
+	stored.Discounts = nil
+	o.orders[order.ID] = &stored
+
+	return nil
+}
+
+// Find ...
+func (o *OrderRepository) Find(id int) (*model.Order, error) {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	order, ok := o.orders[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	found := *order
+	return &found, nil
+}
+
+// FindForUpdate ...
+func (o *OrderRepository) FindForUpdate(id int) (*model.Order, error) {
+	return o.Find(id)
+}
+
+// FindByPaymentRef ...
+func (o *OrderRepository) FindByPaymentRef(ref string) (*model.Order, error) {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	for _, order := range o.orders {
+		if ref != "" && order.PaymentRef == ref {
+			found := *order
+			return &found, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// Delete ...
+func (o *OrderRepository) Delete(id int) error {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	delete(o.orders, id)
+
+	history := o.store.OrderStatusHistoryRepository
+	for changeID, c := range history.changes {
+		if c.OrderId == id {
+			delete(history.changes, changeID)
+		}
+	}
+
+	discounts := o.store.PromotionRepository.discounts
+	for discountID, d := range discounts {
+		if d.OrderId == id {
+			delete(discounts, discountID)
+		}
+	}
+
+	// Ledger entries outlive the order, as with ON DELETE SET NULL.
+	for _, e := range o.store.WalletRepository.entries {
+		if e.OrderId != nil && *e.OrderId == id {
+			e.OrderId = nil
+		}
+	}
+
+	for _, e := range o.store.LoyaltyRepository.entries {
+		if e.OrderId != nil && *e.OrderId == id {
+			e.OrderId = nil
+		}
+	}
+
+	for _, r := range o.store.PaymentRefundRepository.refunds {
+		if r.OrderId != nil && *r.OrderId == id {
+			r.OrderId = nil
+		}
+	}
+
+	return nil
+}
+
+// Update ...
+func (o *OrderRepository) Update(id int, totalAmount int, discountAmount int) error {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	if order, ok := o.orders[id]; ok {
+		order.TotalAmount = totalAmount
+		order.DiscountAmount = discountAmount
+	}
+
+	return nil
+}
+
+// UpdateStatus ...
+func (o *OrderRepository) UpdateStatus(id int, status string) error {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	if order, ok := o.orders[id]; ok {
+		order.Status = status
+	}
+
+	return nil
+}
+
+// UpdatePayment ...
+func (o *OrderRepository) UpdatePayment(id int, status string, ref string) error {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	if order, ok := o.orders[id]; ok {
+		order.PaymentStatus = status
+		order.PaymentRef = ref
+	}
+
+	return nil
+}
+
+// GetOrders ...
+func (o *OrderRepository) GetOrders(userId int) ([]*model.Order, error) {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	var orders []*model.Order
+	for _, order := range o.orders {
+		if order.UserId == userId {
+			found := *order
+			orders = append(orders, &found)
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return orders[i].ID < orders[j].ID
+	})
+
+	return orders, nil
+}
+
+// List ...
+func (o *OrderRepository) List(f store.OrderFilter) ([]*model.Order, error) {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	sortOrder := f.Sort
+	if sortOrder == "" {
+		sortOrder = store.OrderSortNewest
+	}
+
+	// before reports whether a comes first in the listing.
+	before := func(a, b *store.OrderCursor) bool {
+		switch sortOrder {
+		case store.OrderSortOldest:
+			return a.CreatedAt.Before(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.ID < b.ID)
+		case store.OrderSortTotalAsc:
+			return a.TotalAmount < b.TotalAmount || (a.TotalAmount == b.TotalAmount && a.ID < b.ID)
+		case store.OrderSortTotalDesc:
+			return a.TotalAmount > b.TotalAmount || (a.TotalAmount == b.TotalAmount && a.ID > b.ID)
+		default:
+			return a.CreatedAt.After(b.CreatedAt) || (a.CreatedAt.Equal(b.CreatedAt) && a.ID > b.ID)
+		}
+	}
+
+	var orders []*model.Order
+	for _, order := range o.orders {
+		if f.UserId != 0 && order.UserId != f.UserId {
+			continue
+		}
+
+		if len(f.Statuses) > 0 && !containsString(f.Statuses, order.Status) {
+			continue
+		}
+
+		if (f.From != nil && order.CreatedAt.Before(*f.From)) || (f.To != nil && !order.CreatedAt.Before(*f.To)) {
+			continue
+		}
+
+		if (f.MinTotal != nil && order.TotalAmount < *f.MinTotal) || (f.MaxTotal != nil && order.TotalAmount > *f.MaxTotal) {
+			continue
+		}
+
+		if f.MenuItemId != 0 && !o.hasMenuItem(order.ID, f.MenuItemId) {
+			continue
+		}
+
+		if f.After != nil && !before(f.After, store.CursorOf(order)) {
+			continue
+		}
+
+		found := *order
+		orders = append(orders, &found)
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return before(store.CursorOf(orders[i]), store.CursorOf(orders[j]))
+	})
+
+	if f.Limit > 0 && len(orders) > f.Limit {
+		orders = orders[:f.Limit]
+	}
+
+	items := o.store.OrderItemRepository.orderItems
+	for _, order := range orders {
+		for _, item := range items {
+			if item.OrderId == order.ID {
+				order.Items = append(order.Items, copyOrderItem(item))
+			}
+		}
+
+		sort.Slice(order.Items, func(i, j int) bool {
+			return order.Items[i].ID < order.Items[j].ID
+		})
+	}
+
+	return orders, nil
+}
+
+// hasMenuItem reports whether the order has the menu item on a line or in a
+// combo. The caller holds the store mutex.
+func (o *OrderRepository) hasMenuItem(orderId int, menuItemId int) bool {
+	for _, item := range o.store.OrderItemRepository.orderItems {
+		if item.OrderId != orderId {
+			continue
+		}
+
+		for _, id := range item.DishIds() {
+			if id == menuItemId {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
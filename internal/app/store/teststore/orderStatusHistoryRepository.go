@@ -0,0 +1,51 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"sort"
+)
+
+// OrderStatusHistoryRepository ...
+type OrderStatusHistoryRepository struct {
+	store   *Store
+	changes map[int]*model.OrderStatusChange
+	nextID  int
+}
+
+// Create ...
+func (r *OrderStatusHistoryRepository) Create(c *model.OrderStatusChange) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.OrderRepository.orders[c.OrderId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	r.nextID++
+	c.ID = r.nextID
+
+	stored := *c
+	r.changes[c.ID] = &stored
+
+	return nil
+}
+
+// GetByOrder ...
+func (r *OrderStatusHistoryRepository) GetByOrder(orderId int) ([]*model.OrderStatusChange, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var changes []*model.OrderStatusChange
+	for _, c := range r.changes {
+		if c.OrderId == orderId {
+			found := *c
+			changes = append(changes, &found)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].ID < changes[j].ID
+	})
+
+	return changes, nil
+}
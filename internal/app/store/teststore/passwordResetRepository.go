@@ -0,0 +1,87 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// PasswordResetRepository ...
+type PasswordResetRepository struct {
+	store  *Store
+	resets map[int]*model.PasswordReset
+	nextID int
+}
+
+// Create ...
+func (r *PasswordResetRepository) Create(pr *model.PasswordReset) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.UserRepository.users[pr.UserId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	r.nextID++
+	pr.ID = r.nextID
+
+	stored := *pr
+	r.resets[pr.ID] = &stored
+
+	return nil
+}
+
+// FindByHash ...
+func (r *PasswordResetRepository) FindByHash(hash string) (*model.PasswordReset, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, pr := range r.resets {
+		if pr.TokenHash == hash {
+			found := *pr
+			return &found, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// Use ...
+func (r *PasswordResetRepository) Use(id int, now time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	pr, ok := r.resets[id]
+	if !ok || pr.UsedAt != nil {
+		return false, nil
+	}
+
+	pr.UsedAt = &now
+
+	return true, nil
+}
+
+// UseUser ...
+func (r *PasswordResetRepository) UseUser(userId int, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, pr := range r.resets {
+		if pr.UserId == userId && pr.UsedAt == nil {
+			usedAt := now
+			pr.UsedAt = &usedAt
+		}
+	}
+
+	return nil
+}
+
+// removeUser deletes the tokens of a deleted user. The caller holds the
+// store mutex.
+func (r *PasswordResetRepository) removeUser(userId int) {
+	for id, pr := range r.resets {
+		if pr.UserId == userId {
+			delete(r.resets, id)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package teststore
+
+import "github.com/yeboka/final-project/internal/app/model"
+
+// PaymentEventRepository ...
+type PaymentEventRepository struct {
+	store  *Store
+	events map[int]*model.PaymentEvent
+	nextID int
+}
+
+// Record ...
+func (r *PaymentEventRepository) Record(e *model.PaymentEvent) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.events {
+		if existing.EventId == e.EventId {
+			return false, nil
+		}
+	}
+
+	r.nextID++
+	e.ID = r.nextID
+
+	stored := *e
+	r.events[e.ID] = &stored
+
+	return true, nil
+}
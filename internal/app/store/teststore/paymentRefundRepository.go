@@ -0,0 +1,120 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"sort"
+	"time"
+)
+
+// PaymentRefundRepository ...
+type PaymentRefundRepository struct {
+	store   *Store
+	refunds map[int]*model.PaymentRefund
+	nextID  int
+}
+
+// Create ...
+func (r *PaymentRefundRepository) Create(pr *model.PaymentRefund) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if pr.OrderId != nil {
+		if _, ok := r.store.OrderRepository.orders[*pr.OrderId]; !ok {
+			return errForeignKeyViolation
+		}
+	}
+
+	if pr.Status == "" {
+		pr.Status = model.RefundStatusPending
+	}
+
+	r.nextID++
+	pr.ID = r.nextID
+
+	stored := *pr
+	r.refunds[pr.ID] = &stored
+
+	return nil
+}
+
+// GetPending ...
+func (r *PaymentRefundRepository) GetPending(limit int) ([]*model.PaymentRefund, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var refunds []*model.PaymentRefund
+	for _, pr := range r.refunds {
+		if pr.Status == model.RefundStatusPending {
+			found := *pr
+			refunds = append(refunds, &found)
+		}
+	}
+
+	sort.Slice(refunds, func(i, j int) bool {
+		return refunds[i].ID < refunds[j].ID
+	})
+
+	if len(refunds) > limit {
+		refunds = refunds[:limit]
+	}
+
+	return refunds, nil
+}
+
+// Claim ...
+func (r *PaymentRefundRepository) Claim(id int) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	pr, ok := r.refunds[id]
+	if !ok || pr.Status != model.RefundStatusPending {
+		return false, nil
+	}
+
+	pr.Status = model.RefundStatusProcessing
+
+	return true, nil
+}
+
+// Settle ...
+func (r *PaymentRefundRepository) Settle(id int, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if pr, ok := r.refunds[id]; ok {
+		pr.Status = model.RefundStatusDone
+		pr.SettledAt = &now
+	}
+
+	return nil
+}
+
+// Release ...
+func (r *PaymentRefundRepository) Release(id int, reason string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if pr, ok := r.refunds[id]; ok {
+		pr.Status = model.RefundStatusPending
+		pr.Attempts++
+		pr.LastError = reason
+	}
+
+	return nil
+}
+
+// SettleRef ...
+func (r *PaymentRefundRepository) SettleRef(ref string, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, pr := range r.refunds {
+		if pr.PaymentRef == ref && pr.Status != model.RefundStatusDone {
+			settledAt := now
+			pr.Status = model.RefundStatusDone
+			pr.SettledAt = &settledAt
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,123 @@
+package teststore
+
+import (
+	"errors"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+	"time"
+)
+
+var errSlotTaken = errors.New("pickup slot already exists")
+
+// PickupSlotRepository ...
+type PickupSlotRepository struct {
+	store  *Store
+	slots  map[int]*model.PickupSlot
+	nextID int
+}
+
+// Create ...
+func (r *PickupSlotRepository) Create(p *model.PickupSlot) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.slots {
+		if existing.StartsAt.Equal(p.StartsAt) {
+			return errSlotTaken
+		}
+	}
+
+	r.nextID++
+	p.ID = r.nextID
+	p.ReservedOrders = 0
+	p.ReservedItems = 0
+
+	stored := *p
+	r.slots[p.ID] = &stored
+
+	return nil
+}
+
+// Find ...
+func (r *PickupSlotRepository) Find(id int) (*model.PickupSlot, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.slots[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	found := *p
+	return &found, nil
+}
+
+// FindBetween ...
+func (r *PickupSlotRepository) FindBetween(from, to time.Time) ([]*model.PickupSlot, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var slots []*model.PickupSlot
+	for _, p := range r.slots {
+		if !p.StartsAt.Before(from) && p.StartsAt.Before(to) {
+			found := *p
+			slots = append(slots, &found)
+		}
+	}
+
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].StartsAt.Before(slots[j].StartsAt)
+	})
+
+	return slots, nil
+}
+
+// Reserve ...
+func (r *PickupSlotRepository) Reserve(id int, items int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.slots[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	if !p.CanFit(items) {
+		return store.ErrCapacityFull
+	}
+
+	p.ReservedOrders++
+	p.ReservedItems += items
+
+	return nil
+}
+
+// Release ...
+func (r *PickupSlotRepository) Release(id int, items int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if p, ok := r.slots[id]; ok {
+		p.ReservedOrders = max(p.ReservedOrders-1, 0)
+		p.ReservedItems = max(p.ReservedItems-items, 0)
+	}
+
+	return nil
+}
+
+// Delete ...
+func (r *PickupSlotRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, o := range r.store.OrderRepository.orders {
+		if o.PickupSlotId != nil && *o.PickupSlotId == id {
+			o.PickupSlotId = nil
+		}
+	}
+
+	delete(r.slots, id)
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+	"time"
+)
+
+// PromotionRepository ...
+type PromotionRepository struct {
+	store          *Store
+	promotions     map[int]*model.Promotion
+	discounts      map[int]*model.OrderDiscount
+	nextID         int
+	nextDiscountID int
+}
+
+// Create ...
+func (r *PromotionRepository) Create(p *model.Promotion) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.promotions {
+		if p.Code != "" && existing.Code == p.Code {
+			return errCouponCodeTaken
+		}
+	}
+
+	if _, ok := r.store.MenuItemRepository.menuItems[p.MenuItemId]; p.MenuItemId != 0 && !ok {
+		return errForeignKeyViolation
+	}
+
+	if _, ok := r.store.CategoryRepository.categories[p.CategoryID]; p.CategoryID != 0 && !ok {
+		return errForeignKeyViolation
+	}
+
+	r.nextID++
+	p.ID = r.nextID
+
+	stored := *p
+	r.promotions[p.ID] = &stored
+
+	return nil
+}
+
+// Find ...
+func (r *PromotionRepository) Find(id int) (*model.Promotion, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	p, ok := r.promotions[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	found := *p
+	return &found, nil
+}
+
+// FindForUpdate ...
+func (r *PromotionRepository) FindForUpdate(id int) (*model.Promotion, error) {
+	return r.Find(id)
+}
+
+// FindByCode ...
+func (r *PromotionRepository) FindByCode(code string) (*model.Promotion, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, p := range r.promotions {
+		if code != "" && p.Code == code {
+			found := *p
+			return &found, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// GetAutomatic ...
+func (r *PromotionRepository) GetAutomatic() ([]*model.Promotion, error) {
+	return r.filter(func(p *model.Promotion) bool {
+		return !p.IsCoupon()
+	})
+}
+
+// GetAll ...
+func (r *PromotionRepository) GetAll() ([]*model.Promotion, error) {
+	return r.filter(func(p *model.Promotion) bool {
+		return true
+	})
+}
+
+func (r *PromotionRepository) filter(keep func(*model.Promotion) bool) ([]*model.Promotion, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var promotions []*model.Promotion
+	for _, p := range r.promotions {
+		if keep(p) {
+			found := *p
+			promotions = append(promotions, &found)
+		}
+	}
+
+	sort.Slice(promotions, func(i, j int) bool {
+		return promotions[i].ID < promotions[j].ID
+	})
+
+	return promotions, nil
+}
+
+// Delete ...
+func (r *PromotionRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.promotions, id)
+
+	// Granted discounts outlive the promotion, as with ON DELETE SET NULL.
+	for _, d := range r.discounts {
+		if d.PromotionId == id {
+			d.PromotionId = 0
+		}
+	}
+
+	return nil
+}
+
+// CreateDiscount ...
+func (r *PromotionRepository) CreateDiscount(d *model.OrderDiscount) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.OrderRepository.orders[d.OrderId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	if _, ok := r.promotions[d.PromotionId]; d.PromotionId != 0 && !ok {
+		return errForeignKeyViolation
+	}
+
+	d.CreatedAt = time.Now()
+
+	r.nextDiscountID++
+	d.ID = r.nextDiscountID
+
+	stored := *d
+	r.discounts[d.ID] = &stored
+
+	return nil
+}
+
+// GetDiscountsByOrder ...
+func (r *PromotionRepository) GetDiscountsByOrder(orderId int) ([]*model.OrderDiscount, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var discounts []*model.OrderDiscount
+	for _, d := range r.discounts {
+		if d.OrderId == orderId {
+			found := *d
+			discounts = append(discounts, &found)
+		}
+	}
+
+	sort.Slice(discounts, func(i, j int) bool {
+		return discounts[i].ID < discounts[j].ID
+	})
+
+	return discounts, nil
+}
+
+// DeleteDiscountsByOrder ...
+func (r *PromotionRepository) DeleteDiscountsByOrder(orderId int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for id, d := range r.discounts {
+		if d.OrderId == orderId {
+			delete(r.discounts, id)
+		}
+	}
+
+	return nil
+}
+
+// CountUses ...
+func (r *PromotionRepository) CountUses(promotionId int) (int, error) {
+	return r.countOrders(func(d *model.OrderDiscount) bool {
+		return d.PromotionId == promotionId
+	})
+}
+
+// CountUserUses ...
+func (r *PromotionRepository) CountUserUses(promotionId int, userId int) (int, error) {
+	return r.countOrders(func(d *model.OrderDiscount) bool {
+		return d.PromotionId == promotionId && d.UserId == userId
+	})
+}
+
+// countOrders counts the orders with a matching discount, leaving out
+// cancelled and rejected orders as the SQL store does.
+func (r *PromotionRepository) countOrders(match func(*model.OrderDiscount) bool) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	orders := make(map[int]bool)
+	for _, d := range r.discounts {
+		o, ok := r.store.OrderRepository.orders[d.OrderId]
+		if !ok || o.Status == model.OrderStatusCancelled || o.Status == model.OrderStatusRejected {
+			continue
+		}
+
+		if match(d) {
+			orders[d.OrderId] = true
+		}
+	}
+
+	return len(orders), nil
+}
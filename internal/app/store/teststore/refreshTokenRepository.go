@@ -0,0 +1,76 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"time"
+)
+
+// RefreshTokenRepository ...
+type RefreshTokenRepository struct {
+	store  *Store
+	tokens map[int]*model.RefreshToken
+	nextID int
+}
+
+// Create ...
+func (r *RefreshTokenRepository) Create(t *model.RefreshToken) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.UserRepository.users[t.UserId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	if _, ok := r.store.SessionRepository.sessions[t.SessionId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	r.nextID++
+	t.ID = r.nextID
+
+	stored := *t
+	r.tokens[t.ID] = &stored
+
+	return nil
+}
+
+// FindByHash ...
+func (r *RefreshTokenRepository) FindByHash(hash string) (*model.RefreshToken, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			found := *t
+			return &found, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// Revoke ...
+func (r *RefreshTokenRepository) Revoke(id int, now time.Time) (bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	t, ok := r.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return false, nil
+	}
+
+	t.RevokedAt = &now
+
+	return true, nil
+}
+
+// removeUser deletes the tokens of a deleted user. The caller holds the
+// store mutex.
+func (r *RefreshTokenRepository) removeUser(userId int) {
+	for id, t := range r.tokens {
+		if t.UserId == userId {
+			delete(r.tokens, id)
+		}
+	}
+}
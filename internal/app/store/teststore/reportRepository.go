@@ -0,0 +1,245 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"math"
+	"sort"
+)
+
+// ReportRepository computes the reports from the data of the other
+// repositories.
+type ReportRepository struct {
+	store *Store
+}
+
+// Revenue ...
+func (r *ReportRepository) Revenue(rng store.ReportRange, period string) ([]*model.RevenuePeriod, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	byPeriod := make(map[int64]*model.RevenuePeriod)
+	for _, o := range r.orders(rng) {
+		start := store.PeriodStart(period, o.CreatedAt)
+		p, ok := byPeriod[start.Unix()]
+		if !ok {
+			p = &model.RevenuePeriod{PeriodStart: start}
+			byPeriod[start.Unix()] = p
+		}
+
+		p.Orders++
+		p.Subtotal += o.TotalAmount + o.DiscountAmount
+		p.Discounts += o.DiscountAmount
+		p.Revenue += o.TotalAmount
+	}
+
+	periods := []*model.RevenuePeriod{}
+	for _, p := range byPeriod {
+		periods = append(periods, p)
+	}
+
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].PeriodStart.Before(periods[j].PeriodStart)
+	})
+
+	return periods, nil
+}
+
+// TopItems ...
+func (r *ReportRepository) TopItems(rng store.ReportRange, limit int) ([]*model.ItemSales, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	byItem := make(map[int]*model.ItemSales)
+	sales := func(menuItemId int) *model.ItemSales {
+		mi, ok := r.store.MenuItemRepository.menuItems[menuItemId]
+		if !ok {
+			return nil
+		}
+
+		s, ok := byItem[menuItemId]
+		if !ok {
+			s = &model.ItemSales{MenuItemId: mi.ID, Name: mi.Name, CategoryID: mi.CategoryID}
+			byItem[menuItemId] = s
+		}
+
+		return s
+	}
+
+	for _, item := range r.items(rng) {
+		if !item.IsCombo() {
+			if s := sales(item.MenuItemId); s != nil {
+				s.Quantity += item.Quantity
+				s.Revenue += item.LineTotal
+			}
+
+			continue
+		}
+
+		for _, c := range item.Components {
+			if s := sales(c.MenuItemId); s != nil {
+				s.ComboQuantity += item.Quantity
+			}
+		}
+	}
+
+	items := []*model.ItemSales{}
+	for _, s := range byItem {
+		items = append(items, s)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Total() != b.Total() {
+			return a.Total() > b.Total()
+		}
+
+		if a.Revenue != b.Revenue {
+			return a.Revenue > b.Revenue
+		}
+
+		return a.MenuItemId < b.MenuItemId
+	})
+
+	if len(items) > limit {
+		items = items[:limit]
+	}
+
+	return items, nil
+}
+
+// SalesByCategory ...
+func (r *ReportRepository) SalesByCategory(rng store.ReportRange) ([]*model.CategorySales, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	categories := r.store.CategoryRepository.categories
+
+	byCategory := make(map[int]*model.CategorySales)
+	for _, item := range r.items(rng) {
+		var categoryId int
+		if mi, ok := r.store.MenuItemRepository.menuItems[item.MenuItemId]; ok {
+			categoryId = mi.CategoryID
+		} else if c, ok := r.store.ComboRepository.combos[item.ComboId]; ok {
+			categoryId = c.CategoryID
+		}
+
+		seen := make(map[int]bool)
+		for {
+			c, ok := categories[categoryId]
+			if !ok || seen[categoryId] {
+				break
+			}
+			seen[categoryId] = true
+
+			s, ok := byCategory[c.ID]
+			if !ok {
+				s = &model.CategorySales{CategoryID: c.ID, ParentID: c.ParentID, Name: c.Name}
+				byCategory[c.ID] = s
+			}
+
+			s.Quantity += item.Quantity
+			s.Revenue += item.LineTotal
+			categoryId = c.ParentID
+		}
+	}
+
+	sales := []*model.CategorySales{}
+	for _, s := range byCategory {
+		sales = append(sales, s)
+	}
+
+	sort.Slice(sales, func(i, j int) bool {
+		if sales[i].Revenue != sales[j].Revenue {
+			return sales[i].Revenue > sales[j].Revenue
+		}
+
+		return sales[i].CategoryID < sales[j].CategoryID
+	})
+
+	return sales, nil
+}
+
+// OrderValue ...
+func (r *ReportRepository) OrderValue(rng store.ReportRange) (*model.OrderValue, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	v := &model.OrderValue{}
+	for _, o := range r.orders(rng) {
+		v.Orders++
+		v.Revenue += o.TotalAmount
+	}
+
+	if v.Orders > 0 {
+		v.Average = int(math.Round(float64(v.Revenue) / float64(v.Orders)))
+	}
+
+	return v, nil
+}
+
+// PeakHours ...
+func (r *ReportRepository) PeakHours(rng store.ReportRange) ([]*model.HourSales, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	byHour := make(map[int]*model.HourSales)
+	for _, o := range r.orders(rng) {
+		h, ok := byHour[o.CreatedAt.Hour()]
+		if !ok {
+			h = &model.HourSales{Hour: o.CreatedAt.Hour()}
+			byHour[h.Hour] = h
+		}
+
+		h.Orders++
+		h.Revenue += o.TotalAmount
+	}
+
+	hours := []*model.HourSales{}
+	for _, h := range byHour {
+		hours = append(hours, h)
+	}
+
+	sort.Slice(hours, func(i, j int) bool {
+		return hours[i].Hour < hours[j].Hour
+	})
+
+	return hours, nil
+}
+
+// orders returns the sales of the range: orders that were picked up and not
+// refunded. The caller holds the store mutex.
+func (r *ReportRepository) orders(rng store.ReportRange) []*model.Order {
+	var orders []*model.Order
+	for _, o := range r.store.OrderRepository.orders {
+		if o.Status != model.OrderStatusPickedUp || o.IsRefunded() {
+			continue
+		}
+
+		if o.CreatedAt.Before(rng.From) || !o.CreatedAt.Before(rng.To) {
+			continue
+		}
+
+		orders = append(orders, o)
+	}
+
+	return orders
+}
+
+// items returns the order lines of the sales of the range. The caller holds
+// the store mutex.
+func (r *ReportRepository) items(rng store.ReportRange) []*model.OrderItem {
+	reported := make(map[int]bool)
+	for _, o := range r.orders(rng) {
+		reported[o.ID] = true
+	}
+
+	var items []*model.OrderItem
+	for _, item := range r.store.OrderItemRepository.orderItems {
+		if reported[item.OrderId] {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
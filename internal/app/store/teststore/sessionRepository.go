@@ -0,0 +1,134 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+	"time"
+)
+
+// SessionRepository ...
+type SessionRepository struct {
+	store    *Store
+	sessions map[int]*model.Session
+	nextID   int
+}
+
+// Create ...
+func (r *SessionRepository) Create(s *model.Session) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.UserRepository.users[s.UserId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	r.nextID++
+	s.ID = r.nextID
+
+	stored := *s
+	r.sessions[s.ID] = &stored
+
+	return nil
+}
+
+// Find ...
+func (r *SessionRepository) Find(id int) (*model.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s, ok := r.sessions[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	found := *s
+	return &found, nil
+}
+
+// FindByHash ...
+func (r *SessionRepository) FindByHash(hash string) (*model.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, s := range r.sessions {
+		if s.TokenHash == hash {
+			found := *s
+			return &found, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// GetByUser ...
+func (r *SessionRepository) GetByUser(userId int) ([]*model.Session, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var sessions []*model.Session
+	for _, s := range r.sessions {
+		if s.UserId == userId && s.RevokedAt == nil {
+			found := *s
+			sessions = append(sessions, &found)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		if !sessions[i].LastSeenAt.Equal(sessions[j].LastSeenAt) {
+			return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+		}
+
+		return sessions[i].ID > sessions[j].ID
+	})
+
+	return sessions, nil
+}
+
+// Touch ...
+func (r *SessionRepository) Touch(id int, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if s, ok := r.sessions[id]; ok {
+		s.LastSeenAt = now
+	}
+
+	return nil
+}
+
+// Revoke ...
+func (r *SessionRepository) Revoke(id int, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if s, ok := r.sessions[id]; ok && s.RevokedAt == nil {
+		s.RevokedAt = &now
+	}
+
+	return nil
+}
+
+// RevokeUser ...
+func (r *SessionRepository) RevokeUser(userId int, exceptId int, now time.Time) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, s := range r.sessions {
+		if s.UserId == userId && s.ID != exceptId && s.RevokedAt == nil {
+			s.RevokedAt = &now
+		}
+	}
+
+	return nil
+}
+
+// removeUser deletes the sessions of a deleted user. The caller holds the
+// store mutex.
+func (r *SessionRepository) removeUser(userId int) {
+	for id, s := range r.sessions {
+		if s.UserId == userId {
+			delete(r.sessions, id)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package teststore
+
+import (
+	"errors"
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sync"
+)
+
+var (
+	errEmailTaken          = errors.New("email already taken")
+	errCategoryNameTaken   = errors.New("category name already taken")
+	errMenuItemNameTaken   = errors.New("menu item name already taken")
+	errComboNameTaken      = errors.New("combo name already taken")
+	errCouponCodeTaken     = errors.New("coupon code already taken")
+	errForeignKeyViolation = errors.New("referenced record does not exist")
+)
+
+// Store is an in-memory implementation of store.Store meant for tests.
+// All repositories share a single mutex so that cross-repository checks
+// (foreign keys, uniqueness) see a consistent view of the data.
+type Store struct {
+	mu                           sync.Mutex
+	txMu                         sync.Mutex
+	UserRepository               *UserRepository
+	CategoryRepository           *CategoryRepository
+	MenuItemRepository           *MenuItemRepository
+	OrderRepository              *OrderRepository
+	OrderItemRepository          *OrderItemRepository
+	OrderStatusHistoryRepository *OrderStatusHistoryRepository
+	WalletRepository             *WalletRepository
+	PickupSlotRepository         *PickupSlotRepository
+	ModifierRepository           *ModifierRepository
+	ComboRepository              *ComboRepository
+	PromotionRepository          *PromotionRepository
+	LoyaltyRepository            *LoyaltyRepository
+	MenuScheduleRepository       *MenuScheduleRepository
+	ReportRepository             *ReportRepository
+	RefreshTokenRepository       *RefreshTokenRepository
+	SessionRepository            *SessionRepository
+	PasswordResetRepository      *PasswordResetRepository
+	EmailVerificationRepository  *EmailVerificationRepository
+	LoginThrottleRepository      *LoginThrottleRepository
+	AuditRepository              *AuditRepository
+	PaymentRefundRepository      *PaymentRefundRepository
+	PaymentEventRepository       *PaymentEventRepository
+}
+
+// New ...
+func New() *Store {
+	s := &Store{}
+	s.UserRepository = &UserRepository{store: s, users: make(map[int]*model.User)}
+	s.CategoryRepository = &CategoryRepository{store: s, categories: make(map[int]*model.Category)}
+	s.MenuItemRepository = &MenuItemRepository{store: s, menuItems: make(map[int]*model.MenuItem)}
+	s.OrderRepository = &OrderRepository{store: s, orders: make(map[int]*model.Order)}
+	s.OrderItemRepository = &OrderItemRepository{store: s, orderItems: make(map[int]*model.OrderItem)}
+	s.OrderStatusHistoryRepository = &OrderStatusHistoryRepository{store: s, changes: make(map[int]*model.OrderStatusChange)}
+	s.WalletRepository = &WalletRepository{store: s, entries: make(map[int]*model.WalletEntry)}
+	s.PickupSlotRepository = &PickupSlotRepository{store: s, slots: make(map[int]*model.PickupSlot)}
+	s.ModifierRepository = &ModifierRepository{
+		store:     s,
+		groups:    make(map[int]*model.ModifierGroup),
+		modifiers: make(map[int]*model.Modifier),
+	}
+	s.ComboRepository = &ComboRepository{store: s, combos: make(map[int]*model.Combo)}
+	s.PromotionRepository = &PromotionRepository{
+		store:      s,
+		promotions: make(map[int]*model.Promotion),
+		discounts:  make(map[int]*model.OrderDiscount),
+	}
+	s.LoyaltyRepository = &LoyaltyRepository{store: s, entries: make(map[int]*model.LoyaltyEntry)}
+	s.MenuScheduleRepository = &MenuScheduleRepository{store: s, schedules: make(map[int]*model.MenuSchedule)}
+	s.ReportRepository = &ReportRepository{store: s}
+	s.RefreshTokenRepository = &RefreshTokenRepository{store: s, tokens: make(map[int]*model.RefreshToken)}
+	s.SessionRepository = &SessionRepository{store: s, sessions: make(map[int]*model.Session)}
+	s.PasswordResetRepository = &PasswordResetRepository{store: s, resets: make(map[int]*model.PasswordReset)}
+	s.EmailVerificationRepository = &EmailVerificationRepository{store: s, verifications: make(map[int]*model.EmailVerification)}
+	s.LoginThrottleRepository = &LoginThrottleRepository{store: s, throttles: make(map[int]*model.LoginThrottle)}
+	s.AuditRepository = &AuditRepository{store: s, entries: make(map[int]*model.AuditEntry)}
+	s.PaymentRefundRepository = &PaymentRefundRepository{store: s, refunds: make(map[int]*model.PaymentRefund)}
+	s.PaymentEventRepository = &PaymentEventRepository{store: s, events: make(map[int]*model.PaymentEvent)}
+
+	return s
+}
+
+// User ...
+func (s *Store) User() store.UserRepository {
+	return s.UserRepository
+}
+
+// Order ...
+func (s *Store) Order() store.OrderRepository {
+	return s.OrderRepository
+}
+
+// Category ...
+func (s *Store) Category() store.CategoryRepository {
+	return s.CategoryRepository
+}
+
+// MenuItem ...
+func (s *Store) MenuItem() store.MenuItemRepository {
+	return s.MenuItemRepository
+}
+
+// OrderItem ...
+func (s *Store) OrderItem() store.OrderItemRepository {
+	return s.OrderItemRepository
+}
+
+// OrderStatusHistory ...
+func (s *Store) OrderStatusHistory() store.OrderStatusHistoryRepository {
+	return s.OrderStatusHistoryRepository
+}
+
+// Wallet ...
+func (s *Store) Wallet() store.WalletRepository {
+	return s.WalletRepository
+}
+
+// PickupSlot ...
+func (s *Store) PickupSlot() store.PickupSlotRepository {
+	return s.PickupSlotRepository
+}
+
+// Modifier ...
+func (s *Store) Modifier() store.ModifierRepository {
+	return s.ModifierRepository
+}
+
+// Combo ...
+func (s *Store) Combo() store.ComboRepository {
+	return s.ComboRepository
+}
+
+// Promotion ...
+func (s *Store) Promotion() store.PromotionRepository {
+	return s.PromotionRepository
+}
+
+// Loyalty ...
+func (s *Store) Loyalty() store.LoyaltyRepository {
+	return s.LoyaltyRepository
+}
+
+// MenuSchedule ...
+func (s *Store) MenuSchedule() store.MenuScheduleRepository {
+	return s.MenuScheduleRepository
+}
+
+// Report ...
+func (s *Store) Report() store.ReportRepository {
+	return s.ReportRepository
+}
+
+// RefreshToken ...
+func (s *Store) RefreshToken() store.RefreshTokenRepository {
+	return s.RefreshTokenRepository
+}
+
+// Session ...
+func (s *Store) Session() store.SessionRepository {
+	return s.SessionRepository
+}
+
+// PasswordReset ...
+func (s *Store) PasswordReset() store.PasswordResetRepository {
+	return s.PasswordResetRepository
+}
+
+// EmailVerification ...
+func (s *Store) EmailVerification() store.EmailVerificationRepository {
+	return s.EmailVerificationRepository
+}
+
+// LoginThrottle ...
+func (s *Store) LoginThrottle() store.LoginThrottleRepository {
+	return s.LoginThrottleRepository
+}
+
+// Audit ...
+func (s *Store) Audit() store.AuditRepository {
+	return s.AuditRepository
+}
+
+// PaymentRefund ...
+func (s *Store) PaymentRefund() store.PaymentRefundRepository {
+	return s.PaymentRefundRepository
+}
+
+// PaymentEvent ...
+func (s *Store) PaymentEvent() store.PaymentEventRepository {
+	return s.PaymentEventRepository
+}
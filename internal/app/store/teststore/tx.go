@@ -0,0 +1,151 @@
+package teststore
+
+import (
+	"context"
+	"github.com/yeboka/final-project/internal/app/store"
+)
+
+// txStore is handed to WithTx callbacks so that nested calls reuse the
+// already running transaction instead of taking txMu again.
+type txStore struct {
+	*Store
+}
+
+// WithTx ...
+func (t *txStore) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	return fn(t)
+}
+
+// WithTx runs fn against the store and restores the previous contents of
+// every repository when fn returns an error. Transactions are serialized.
+func (s *Store) WithTx(ctx context.Context, fn func(store.Store) error) error {
+	s.txMu.Lock()
+	defer s.txMu.Unlock()
+
+	snap := s.snapshot()
+	if err := fn(&txStore{Store: s}); err != nil {
+		s.restore(snap)
+		return err
+	}
+
+	return nil
+}
+
+// snapshot holds copies of every repository, including their ID counters.
+type snapshot struct {
+	users      UserRepository
+	categories CategoryRepository
+	menuItems  MenuItemRepository
+	orders     OrderRepository
+	orderItems OrderItemRepository
+	history    OrderStatusHistoryRepository
+	wallet     WalletRepository
+	slots      PickupSlotRepository
+	modifiers  ModifierRepository
+	combos     ComboRepository
+	promotions PromotionRepository
+	loyalty    LoyaltyRepository
+	schedules  MenuScheduleRepository
+	tokens     RefreshTokenRepository
+	sessions   SessionRepository
+	resets     PasswordResetRepository
+	verifies   EmailVerificationRepository
+	throttles  LoginThrottleRepository
+	audit      AuditRepository
+	refunds    PaymentRefundRepository
+	events     PaymentEventRepository
+}
+
+func (s *Store) snapshot() *snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := &snapshot{
+		users:      *s.UserRepository,
+		categories: *s.CategoryRepository,
+		menuItems:  *s.MenuItemRepository,
+		orders:     *s.OrderRepository,
+		orderItems: *s.OrderItemRepository,
+		history:    *s.OrderStatusHistoryRepository,
+		wallet:     *s.WalletRepository,
+		slots:      *s.PickupSlotRepository,
+		modifiers:  *s.ModifierRepository,
+		combos:     *s.ComboRepository,
+		promotions: *s.PromotionRepository,
+		loyalty:    *s.LoyaltyRepository,
+		schedules:  *s.MenuScheduleRepository,
+		tokens:     *s.RefreshTokenRepository,
+		sessions:   *s.SessionRepository,
+		resets:     *s.PasswordResetRepository,
+		verifies:   *s.EmailVerificationRepository,
+		throttles:  *s.LoginThrottleRepository,
+		audit:      *s.AuditRepository,
+		refunds:    *s.PaymentRefundRepository,
+		events:     *s.PaymentEventRepository,
+	}
+
+	snap.users.users = copyMap(s.UserRepository.users)
+	snap.categories.categories = copyMap(s.CategoryRepository.categories)
+	snap.menuItems.menuItems = copyMap(s.MenuItemRepository.menuItems)
+	snap.orders.orders = copyMap(s.OrderRepository.orders)
+	snap.orderItems.orderItems = copyMap(s.OrderItemRepository.orderItems)
+	snap.history.changes = copyMap(s.OrderStatusHistoryRepository.changes)
+	snap.wallet.entries = copyMap(s.WalletRepository.entries)
+	snap.slots.slots = copyMap(s.PickupSlotRepository.slots)
+	snap.modifiers.groups = copyMap(s.ModifierRepository.groups)
+	snap.modifiers.modifiers = copyMap(s.ModifierRepository.modifiers)
+	snap.combos.combos = copyMap(s.ComboRepository.combos)
+	snap.promotions.promotions = copyMap(s.PromotionRepository.promotions)
+	snap.promotions.discounts = copyMap(s.PromotionRepository.discounts)
+	snap.loyalty.entries = copyMap(s.LoyaltyRepository.entries)
+	snap.schedules.schedules = copyMap(s.MenuScheduleRepository.schedules)
+	snap.tokens.tokens = copyMap(s.RefreshTokenRepository.tokens)
+	snap.sessions.sessions = copyMap(s.SessionRepository.sessions)
+	snap.resets.resets = copyMap(s.PasswordResetRepository.resets)
+	snap.verifies.verifications = copyMap(s.EmailVerificationRepository.verifications)
+	snap.throttles.throttles = copyMap(s.LoginThrottleRepository.throttles)
+	snap.audit.entries = copyMap(s.AuditRepository.entries)
+	snap.refunds.refunds = copyMap(s.PaymentRefundRepository.refunds)
+	snap.events.events = copyMap(s.PaymentEventRepository.events)
+
+	return snap
+}
+
+func (s *Store) restore(snap *snapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	*s.UserRepository = snap.users
+	*s.CategoryRepository = snap.categories
+	*s.MenuItemRepository = snap.menuItems
+	*s.OrderRepository = snap.orders
+	*s.OrderItemRepository = snap.orderItems
+	*s.OrderStatusHistoryRepository = snap.history
+	*s.WalletRepository = snap.wallet
+	*s.PickupSlotRepository = snap.slots
+	*s.ModifierRepository = snap.modifiers
+	*s.ComboRepository = snap.combos
+	*s.PromotionRepository = snap.promotions
+	*s.LoyaltyRepository = snap.loyalty
+	*s.MenuScheduleRepository = snap.schedules
+	*s.RefreshTokenRepository = snap.tokens
+	*s.SessionRepository = snap.sessions
+	*s.PasswordResetRepository = snap.resets
+	*s.EmailVerificationRepository = snap.verifies
+	*s.LoginThrottleRepository = snap.throttles
+	*s.AuditRepository = snap.audit
+	*s.PaymentRefundRepository = snap.refunds
+	*s.PaymentEventRepository = snap.events
+}
+
+// copyMap copies the map and the values behind its pointers, so that
+// in-place updates made after the snapshot do not leak into it.
+func copyMap[T any](m map[int]*T) map[int]*T {
+	c := make(map[int]*T, len(m))
+	for k, v := range m {
+		cp := *v
+		c[k] = &cp
+	}
+
+	return c
+}
@@ -0,0 +1,168 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+)
+
+// UserRepository ...
+type UserRepository struct {
+	store  *Store
+	users  map[int]*model.User
+	nextID int
+}
+
+// Create ...
+func (r *UserRepository) Create(u *model.User) error {
+	if err := u.Validate(); err != nil {
+		return err
+	}
+
+	if err := u.BeforeCreate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, existing := range r.users {
+		if existing.Email == u.Email {
+			return errEmailTaken
+		}
+	}
+
+	r.nextID++
+	u.ID = r.nextID
+
+	stored := *u
+	stored.Password = ""
+	r.users[u.ID] = &stored
+
+	return nil
+}
+
+// Find ...
+func (r *UserRepository) Find(id int) (*model.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, store.ErrRecordNotFound
+	}
+
+	found := *u
+	return &found, nil
+}
+
+// FindByEmail ...
+func (r *UserRepository) FindByEmail(email string) (*model.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, u := range r.users {
+		if u.Email == email {
+			found := *u
+			return &found, nil
+		}
+	}
+
+	return nil, store.ErrRecordNotFound
+}
+
+// Update ...
+func (r *UserRepository) Update(user *model.User) error {
+	if err := user.Validate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, ok := r.users[user.ID]
+	if !ok {
+		return nil
+	}
+
+	for _, existing := range r.users {
+		if existing.ID != user.ID && existing.Email == user.Email {
+			return errEmailTaken
+		}
+	}
+
+	if u.Email != user.Email {
+		u.EmailVerified = false
+	}
+
+	u.Username = user.Username
+	u.Email = user.Email
+	user.EmailVerified = u.EmailVerified
+
+	return nil
+}
+
+// UpdateRole ...
+func (r *UserRepository) UpdateRole(userID int, newRole string) error {
+	if newRole != model.RoleUser && newRole != model.RoleStaff && newRole != model.RoleAdmin {
+		return store.ErrInvalidRole
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if u, ok := r.users[userID]; ok {
+		u.Role = newRole
+	}
+
+	return nil
+}
+
+// UpdatePassword ...
+func (r *UserRepository) UpdatePassword(user *model.User) error {
+	if err := user.ValidatePassword(); err != nil {
+		return err
+	}
+
+	if err := user.BeforeCreate(); err != nil {
+		return err
+	}
+
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if u, ok := r.users[user.ID]; ok {
+		u.EncryptedPassword = user.EncryptedPassword
+	}
+
+	return nil
+}
+
+// SetEmailVerified ...
+func (r *UserRepository) SetEmailVerified(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return store.ErrRecordNotFound
+	}
+
+	u.EmailVerified = true
+
+	return nil
+}
+
+// Delete ...
+func (r *UserRepository) Delete(id int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	delete(r.users, id)
+	r.store.SessionRepository.removeUser(id)
+	r.store.RefreshTokenRepository.removeUser(id)
+	r.store.PasswordResetRepository.removeUser(id)
+	r.store.EmailVerificationRepository.removeUser(id)
+	r.store.AuditRepository.detachUser(id)
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package teststore
+
+import (
+	"github.com/yeboka/final-project/internal/app/model"
+	"github.com/yeboka/final-project/internal/app/store"
+	"sort"
+)
+
+// WalletRepository ...
+type WalletRepository struct {
+	store   *Store
+	entries map[int]*model.WalletEntry
+	nextID  int
+}
+
+// Create ...
+func (r *WalletRepository) Create(e *model.WalletEntry) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	if _, ok := r.store.UserRepository.users[e.UserId]; !ok {
+		return errForeignKeyViolation
+	}
+
+	if e.OrderId != nil {
+		if _, ok := r.store.OrderRepository.orders[*e.OrderId]; !ok {
+			return errForeignKeyViolation
+		}
+	}
+
+	r.nextID++
+	e.ID = r.nextID
+
+	stored := *e
+	r.entries[e.ID] = &stored
+
+	return nil
+}
+
+// Balance ...
+func (r *WalletRepository) Balance(userId int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var balance int
+	for _, e := range r.entries {
+		if e.UserId == userId {
+			balance += e.Amount
+		}
+	}
+
+	return balance, nil
+}
+
+// BalanceForUpdate ...
+func (r *WalletRepository) BalanceForUpdate(userId int) (int, error) {
+	r.store.mu.Lock()
+	_, ok := r.store.UserRepository.users[userId]
+	r.store.mu.Unlock()
+
+	if !ok {
+		return 0, store.ErrRecordNotFound
+	}
+
+	return r.Balance(userId)
+}
+
+// OrderBalance ...
+func (r *WalletRepository) OrderBalance(orderId int) (int, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var balance int
+	for _, e := range r.entries {
+		if e.OrderId != nil && *e.OrderId == orderId {
+			balance += e.Amount
+		}
+	}
+
+	return balance, nil
+}
+
+// GetEntries ...
+func (r *WalletRepository) GetEntries(userId int) ([]*model.WalletEntry, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var entries []*model.WalletEntry
+	for _, e := range r.entries {
+		if e.UserId == userId {
+			found := *e
+			entries = append(entries, &found)
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID > entries[j].ID
+	})
+
+	return entries, nil
+}
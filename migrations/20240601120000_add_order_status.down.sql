@@ -0,0 +1,2 @@
+drop table if exists order_status_history;
+alter table orders drop column if exists status;
@@ -0,0 +1,14 @@
+ALTER TABLE orders
+    ADD COLUMN status varchar NOT NULL DEFAULT 'placed';
+
+CREATE TABLE order_status_history
+(
+    id          bigserial not null primary key,
+    order_id    int       not null,
+    from_status varchar   not null,
+    to_status   varchar   not null,
+    changed_by  int       not null,
+    changed_at  timestamp not null,
+    foreign key (order_id) references orders (id) on delete cascade,
+    foreign key (changed_by) references users (id)
+);
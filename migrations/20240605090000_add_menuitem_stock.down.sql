@@ -0,0 +1,2 @@
+alter table menuitem drop column if exists stock;
+alter table menuitem drop column if exists low_stock_threshold;
@@ -0,0 +1,3 @@
+ALTER TABLE menuitem
+    ADD COLUMN stock               int CHECK (stock >= 0),
+    ADD COLUMN low_stock_threshold int NOT NULL DEFAULT 0;
@@ -0,0 +1,4 @@
+drop index if exists orders_payment_ref_idx;
+alter table orders drop column if exists payment_method;
+alter table orders drop column if exists payment_status;
+alter table orders drop column if exists payment_ref;
@@ -0,0 +1,6 @@
+ALTER TABLE orders
+    ADD COLUMN payment_method varchar NOT NULL DEFAULT 'wallet',
+    ADD COLUMN payment_status varchar NOT NULL DEFAULT 'captured',
+    ADD COLUMN payment_ref    varchar;
+
+CREATE UNIQUE INDEX orders_payment_ref_idx ON orders (payment_ref);
@@ -0,0 +1,2 @@
+alter table orders drop column if exists pickup_slot_id;
+drop table if exists pickup_slots;
@@ -0,0 +1,16 @@
+CREATE TABLE pickup_slots
+(
+    id              serial    not null primary key,
+    starts_at       timestamp not null unique,
+    ends_at         timestamp not null,
+    max_orders      int       not null,
+    max_items       int       not null,
+    reserved_orders int       not null default 0,
+    reserved_items  int       not null default 0,
+    check (ends_at > starts_at),
+    check (reserved_orders between 0 and max_orders),
+    check (reserved_items between 0 and max_items)
+);
+
+ALTER TABLE orders
+    ADD COLUMN pickup_slot_id int REFERENCES pickup_slots (id);
@@ -0,0 +1,3 @@
+drop table if exists orderitem_modifiers;
+drop table if exists modifiers;
+drop table if exists modifier_groups;
@@ -0,0 +1,30 @@
+CREATE TABLE modifier_groups
+(
+    id           serial  not null primary key,
+    menu_item_id int     not null,
+    name         varchar not null,
+    min_select   int     not null default 0,
+    max_select   int     not null default 1,
+    foreign key (menu_item_id) references menuitem (id) on delete cascade,
+    check (min_select >= 0 and max_select >= min_select and max_select > 0)
+);
+
+CREATE TABLE modifiers
+(
+    id          serial  not null primary key,
+    group_id    int     not null,
+    name        varchar not null,
+    price_delta int     not null default 0,
+    foreign key (group_id) references modifier_groups (id) on delete cascade
+);
+
+CREATE TABLE orderitem_modifiers
+(
+    id            serial  not null primary key,
+    order_item_id int     not null,
+    modifier_id   int,
+    name          varchar not null,
+    price_delta   int     not null,
+    foreign key (order_item_id) references orderitem (id) on delete cascade,
+    foreign key (modifier_id) references modifiers (id) on delete set null
+);
@@ -0,0 +1,7 @@
+delete from orderitem where menu_item_id is null;
+alter table orderitem drop constraint if exists orderitem_menu_item_id_fkey;
+alter table orderitem add constraint orderitem_menu_item_id_fkey foreign key (menu_item_id) references menuitem (id);
+alter table orderitem alter column menu_item_id set not null;
+alter table orderitem drop column if exists line_total;
+alter table orderitem drop column if exists unit_price;
+alter table orderitem drop column if exists item_name;
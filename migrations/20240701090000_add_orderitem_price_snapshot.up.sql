@@ -0,0 +1,17 @@
+ALTER TABLE orderitem
+    ADD COLUMN item_name  varchar NOT NULL DEFAULT '',
+    ADD COLUMN unit_price int     NOT NULL DEFAULT 0,
+    ADD COLUMN line_total int     NOT NULL DEFAULT 0;
+
+UPDATE orderitem oi
+SET item_name  = m.name,
+    unit_price = m.price,
+    line_total = m.price * oi.quantity
+FROM menuitem m
+WHERE m.id = oi.menu_item_id;
+
+ALTER TABLE orderitem
+    ALTER COLUMN menu_item_id DROP NOT NULL,
+    DROP CONSTRAINT IF EXISTS orderitem_menu_item_id_fkey,
+    ADD CONSTRAINT orderitem_menu_item_id_fkey
+        FOREIGN KEY (menu_item_id) REFERENCES menuitem (id) ON DELETE SET NULL;
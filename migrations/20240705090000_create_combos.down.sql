@@ -0,0 +1,6 @@
+drop table if exists orderitem_components;
+delete from orderitem where combo_id is not null;
+alter table orderitem drop column if exists combo_id;
+drop table if exists combo_slot_items;
+drop table if exists combo_slots;
+drop table if exists combos;
@@ -0,0 +1,43 @@
+CREATE TABLE combos
+(
+    id          serial  not null primary key,
+    category_id int references categories (id),
+    name        varchar not null unique,
+    price       int     not null check (price >= 0),
+    description varchar not null default ''
+);
+
+CREATE TABLE combo_slots
+(
+    id          serial  not null primary key,
+    combo_id    int     not null,
+    name        varchar not null,
+    category_id int,
+    foreign key (combo_id) references combos (id) on delete cascade,
+    foreign key (category_id) references categories (id) on delete set null
+);
+
+CREATE TABLE combo_slot_items
+(
+    slot_id      int not null,
+    menu_item_id int not null,
+    primary key (slot_id, menu_item_id),
+    foreign key (slot_id) references combo_slots (id) on delete cascade,
+    foreign key (menu_item_id) references menuitem (id) on delete cascade
+);
+
+ALTER TABLE orderitem
+    ADD COLUMN combo_id int REFERENCES combos (id) ON DELETE SET NULL;
+
+CREATE TABLE orderitem_components
+(
+    id            serial  not null primary key,
+    order_item_id int     not null,
+    combo_slot_id int,
+    slot_name     varchar not null,
+    menu_item_id  int,
+    item_name     varchar not null,
+    foreign key (order_item_id) references orderitem (id) on delete cascade,
+    foreign key (combo_slot_id) references combo_slots (id) on delete set null,
+    foreign key (menu_item_id) references menuitem (id) on delete set null
+);
@@ -0,0 +1,4 @@
+alter table orders drop column if exists discount_amount;
+alter table orders drop column if exists coupon_code;
+drop table if exists order_discounts;
+drop table if exists promotions;
@@ -0,0 +1,40 @@
+CREATE TABLE promotions
+(
+    id                serial  not null primary key,
+    name              varchar not null,
+    code              varchar unique,
+    kind              varchar not null,
+    percent           int     not null default 0 check (percent between 0 and 100),
+    amount            int     not null default 0 check (amount >= 0),
+    menu_item_id      int references menuitem (id) on delete cascade,
+    category_id       int references categories (id) on delete cascade,
+    buy_quantity      int     not null default 0,
+    free_quantity     int     not null default 0,
+    starts_at         timestamp,
+    ends_at           timestamp,
+    daily_from        varchar,
+    daily_to          varchar,
+    max_uses          int     not null default 0,
+    max_uses_per_user int     not null default 0
+);
+
+CREATE TABLE order_discounts
+(
+    id           serial    not null primary key,
+    order_id     int       not null,
+    promotion_id int,
+    user_id      int       not null,
+    name         varchar   not null,
+    code         varchar,
+    amount       int       not null,
+    created_at   timestamp not null default now(),
+    foreign key (order_id) references orders (id) on delete cascade,
+    foreign key (promotion_id) references promotions (id) on delete set null,
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+CREATE INDEX order_discounts_promotion_idx ON order_discounts (promotion_id, user_id);
+
+ALTER TABLE orders
+    ADD COLUMN coupon_code     varchar,
+    ADD COLUMN discount_amount int NOT NULL DEFAULT 0;
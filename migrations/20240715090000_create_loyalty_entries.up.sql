@@ -0,0 +1,18 @@
+CREATE TABLE loyalty_entries
+(
+    id         bigserial not null primary key,
+    user_id    int       not null,
+    kind       varchar   not null,
+    points     int       not null,
+    order_id   int,
+    created_by int       not null,
+    note       varchar   not null default '',
+    expires_at timestamp,
+    created_at timestamp not null,
+    foreign key (user_id) references users (id),
+    foreign key (order_id) references orders (id) on delete set null,
+    foreign key (created_by) references users (id)
+);
+
+CREATE INDEX loyalty_entries_user_id_idx ON loyalty_entries (user_id);
+CREATE INDEX loyalty_entries_order_id_idx ON loyalty_entries (order_id);
@@ -0,0 +1,2 @@
+alter table menuitem drop column if exists allergens;
+alter table menuitem drop column if exists diets;
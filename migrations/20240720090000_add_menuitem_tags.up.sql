@@ -0,0 +1,3 @@
+ALTER TABLE menuitem
+    ADD COLUMN allergens varchar[] NOT NULL DEFAULT '{}',
+    ADD COLUMN diets     varchar[] NOT NULL DEFAULT '{}';
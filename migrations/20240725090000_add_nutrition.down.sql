@@ -0,0 +1,10 @@
+alter table orderitem drop column if exists kcal;
+alter table orderitem drop column if exists protein;
+alter table orderitem drop column if exists carbs;
+alter table orderitem drop column if exists fat;
+alter table orderitem drop column if exists salt;
+alter table menuitem drop column if exists kcal;
+alter table menuitem drop column if exists protein;
+alter table menuitem drop column if exists carbs;
+alter table menuitem drop column if exists fat;
+alter table menuitem drop column if exists salt;
@@ -0,0 +1,13 @@
+ALTER TABLE menuitem
+    ADD COLUMN kcal    int CHECK (kcal >= 0),
+    ADD COLUMN protein numeric(7, 2) CHECK (protein >= 0),
+    ADD COLUMN carbs   numeric(7, 2) CHECK (carbs >= 0),
+    ADD COLUMN fat     numeric(7, 2) CHECK (fat >= 0),
+    ADD COLUMN salt    numeric(7, 2) CHECK (salt >= 0);
+
+ALTER TABLE orderitem
+    ADD COLUMN kcal    int,
+    ADD COLUMN protein numeric(7, 2),
+    ADD COLUMN carbs   numeric(7, 2),
+    ADD COLUMN fat     numeric(7, 2),
+    ADD COLUMN salt    numeric(7, 2);
@@ -0,0 +1,12 @@
+CREATE TABLE menu_schedules
+(
+    id           serial     not null primary key,
+    menu_item_id int references menuitem (id) on delete cascade,
+    category_id  int references categories (id) on delete cascade,
+    weekdays     smallint[] not null default '{}',
+    daily_from   varchar,
+    daily_to     varchar,
+    starts_on    date,
+    ends_on      date,
+    check ((menu_item_id is null) <> (category_id is null))
+);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS orders_createdat_idx;
+
+ALTER TABLE orders
+    ALTER COLUMN createdat TYPE date USING createdat::date;
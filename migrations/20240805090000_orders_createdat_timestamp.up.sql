@@ -0,0 +1,11 @@
+ALTER TABLE orders
+    ALTER COLUMN createdat TYPE timestamp USING createdat::timestamp;
+
+UPDATE orders o
+SET createdat = h.changed_at
+FROM order_status_history h
+WHERE h.order_id = o.id
+  AND h.from_status = ''
+  AND h.to_status = 'placed';
+
+CREATE INDEX orders_createdat_idx ON orders (createdat);
@@ -0,0 +1,14 @@
+CREATE TABLE refresh_tokens
+(
+    id         bigserial not null primary key,
+    user_id    int       not null,
+    token_hash varchar   not null unique,
+    family_id  varchar   not null,
+    expires_at timestamp not null,
+    created_at timestamp not null,
+    revoked_at timestamp,
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
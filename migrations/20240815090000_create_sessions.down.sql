@@ -0,0 +1,9 @@
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN session_id,
+    ADD COLUMN family_id varchar NOT NULL;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+drop table if exists sessions;
@@ -0,0 +1,24 @@
+CREATE TABLE sessions
+(
+    id           bigserial not null primary key,
+    user_id      int       not null,
+    token_hash   varchar   not null unique,
+    device       varchar   not null default '',
+    ip           varchar   not null default '',
+    created_at   timestamp not null,
+    last_seen_at timestamp not null,
+    revoked_at   timestamp,
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
+
+-- Refresh tokens now belong to a session. Tokens issued before have none,
+-- so their clients have to log in again.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN family_id,
+    ADD COLUMN session_id bigint NOT NULL REFERENCES sessions (id) ON DELETE CASCADE;
+
+CREATE INDEX refresh_tokens_session_id_idx ON refresh_tokens (session_id);
@@ -0,0 +1,12 @@
+CREATE TABLE password_resets
+(
+    id         bigserial not null primary key,
+    user_id    int       not null,
+    token_hash varchar   not null unique,
+    created_at timestamp not null,
+    expires_at timestamp not null,
+    used_at    timestamp,
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+CREATE INDEX password_resets_user_id_idx ON password_resets (user_id);
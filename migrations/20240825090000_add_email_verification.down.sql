@@ -0,0 +1,3 @@
+drop table if exists email_verifications;
+
+ALTER TABLE users DROP COLUMN email_verified;
@@ -0,0 +1,17 @@
+ALTER TABLE users ADD COLUMN email_verified boolean NOT NULL DEFAULT false;
+
+-- Accounts created before sign-up verification existed stay usable.
+UPDATE users SET email_verified = true;
+
+CREATE TABLE email_verifications
+(
+    id         bigserial not null primary key,
+    user_id    int       not null,
+    token_hash varchar   not null unique,
+    created_at timestamp not null,
+    expires_at timestamp not null,
+    used_at    timestamp,
+    foreign key (user_id) references users (id) on delete cascade
+);
+
+CREATE INDEX email_verifications_user_id_idx ON email_verifications (user_id);
@@ -0,0 +1,2 @@
+drop table if exists audit_entries;
+drop table if exists login_throttles;
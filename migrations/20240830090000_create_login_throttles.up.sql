@@ -0,0 +1,26 @@
+CREATE TABLE login_throttles
+(
+    id              bigserial not null primary key,
+    kind            varchar   not null,
+    key             varchar   not null,
+    failures        int       not null default 0,
+    last_failure_at timestamp,
+    next_attempt_at timestamp,
+    locked_until    timestamp,
+    unique (kind, key)
+);
+
+CREATE TABLE audit_entries
+(
+    id         bigserial not null primary key,
+    action     varchar   not null,
+    user_id    int,
+    actor_id   int,
+    ip         varchar   not null default '',
+    detail     varchar   not null default '',
+    created_at timestamp not null,
+    foreign key (user_id) references users (id) on delete set null,
+    foreign key (actor_id) references users (id) on delete set null
+);
+
+CREATE INDEX audit_entries_action_idx ON audit_entries (action, created_at);
@@ -0,0 +1,15 @@
+CREATE TABLE payment_refunds
+(
+    id          bigserial not null primary key,
+    order_id    int,
+    payment_ref varchar   not null,
+    amount      int       not null check (amount > 0),
+    status      varchar   not null default 'pending',
+    attempts    int       not null default 0,
+    last_error  varchar   not null default '',
+    created_at  timestamp not null,
+    settled_at  timestamp,
+    foreign key (order_id) references orders (id) on delete set null
+);
+
+CREATE INDEX payment_refunds_status_idx ON payment_refunds (status);
@@ -0,0 +1,8 @@
+CREATE TABLE payment_events
+(
+    id          bigserial not null primary key,
+    event_id    varchar   not null unique,
+    type        varchar   not null,
+    ref         varchar   not null,
+    received_at timestamp not null
+);
@@ -0,0 +1,5 @@
+ALTER TABLE orders
+    DROP CONSTRAINT IF EXISTS orders_pickup_slot_id_fkey;
+ALTER TABLE orders
+    ADD CONSTRAINT orders_pickup_slot_id_fkey
+        FOREIGN KEY (pickup_slot_id) REFERENCES pickup_slots (id) ON DELETE SET NULL;